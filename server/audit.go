@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+const (
+	// AuditBatchWindow is how long the completions are gathered before they are logged together in the audit channel
+	AuditBatchWindow = 10 * time.Second
+	// MaxAuditBatch is the number of completions logged in a single post of the audit channel
+	MaxAuditBatch = 50
+)
+
+// auditLog gathers the lines logged in the audit channel for window, so a burst of completions makes a few posts
+// instead of one each.
+type auditLog struct {
+	window time.Duration
+	post   func(lines []string)
+
+	mutex     sync.Mutex
+	pending   []string
+	scheduled bool
+}
+
+func newAuditLog(window time.Duration, post func(lines []string)) *auditLog {
+	return &auditLog{
+		window: window,
+		post:   post,
+	}
+}
+
+// add queues line, to be posted with the others queued within the window.
+func (a *auditLog) add(line string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.pending = append(a.pending, line)
+	if !a.scheduled {
+		a.scheduled = true
+		time.AfterFunc(a.window, a.flush)
+	}
+}
+
+// flush posts the queued lines, MaxAuditBatch at a time.
+func (a *auditLog) flush() {
+	a.mutex.Lock()
+	lines := a.pending
+	a.pending = nil
+	a.scheduled = false
+	a.mutex.Unlock()
+
+	for len(lines) > 0 {
+		batch := lines
+		if len(batch) > MaxAuditBatch {
+			batch = batch[:MaxAuditBatch]
+		}
+		lines = lines[len(batch):]
+		a.post(batch)
+	}
+}
+
+// logCompletion logs in the audit channel that userName completed issue at completedAt, if the channel is set.
+func (p *Plugin) logCompletion(userName string, issue *Issue, completedAt time.Time) {
+	if p.getConfiguration().AuditChannelID == "" {
+		return
+	}
+
+	message := strings.Join(strings.Fields(issue.Message), " ")
+	p.auditLog.add(fmt.Sprintf("* %s: @%s completed \"%s\"", completedAt.UTC().Format("2006-01-02 15:04:05 MST"), userName, message))
+}
+
+// postAuditLines posts lines in the audit channel.
+func (p *Plugin) postAuditLines(lines []string) {
+	channelID := p.getConfiguration().AuditChannelID
+	if channelID == "" {
+		return
+	}
+
+	_, appErr := p.API.CreatePost(&model.Post{
+		UserId:    p.BotUserID,
+		ChannelId: channelID,
+		Message:   "Completed Todos:\n\n" + strings.Join(lines, "\n"),
+	})
+	if appErr != nil {
+		p.API.LogError("Unable to post in the audit channel err=" + appErr.Error())
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLogBatches(t *testing.T) {
+	posted := make(chan []string, 10)
+	log := newAuditLog(20*time.Millisecond, func(lines []string) { posted <- lines })
+
+	log.add("first")
+	log.add("second")
+	select {
+	case lines := <-posted:
+		assert.Equal(t, []string{"first", "second"}, lines)
+	case <-time.After(time.Second):
+		require.Fail(t, "the batch was not posted")
+	}
+
+	for i := 0; i < MaxAuditBatch+1; i++ {
+		log.add(fmt.Sprintf("line %d", i))
+	}
+	log.flush()
+	require.Len(t, posted, 2)
+	assert.Len(t, <-posted, MaxAuditBatch)
+	assert.Equal(t, []string{fmt.Sprintf("line %d", MaxAuditBatch)}, <-posted)
+}
+
+func TestCompletionsPostedToAuditChannel(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	p := env.newPlugin()
+
+	first, err := p.listManager.AddIssue("alice", "water\nthe plants", "", "", IssueFields{})
+	require.NoError(t, err)
+	second, err := p.listManager.AddIssue("alice", "call the bank", "", "", IssueFields{})
+	require.NoError(t, err)
+	third, err := p.listManager.AddIssue("alice", "feed the cat", "", "", IssueFields{})
+	require.NoError(t, err)
+
+	// Nothing is logged without a channel
+	w := env.serve(p, "alice", http.MethodPost, "/complete", &completeAPIRequest{ID: first.ID})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	p.auditLog.flush()
+	assert.Empty(t, env.postsTo("audit"))
+
+	p.setConfiguration(&configuration{AuditChannelID: "audit"})
+	for _, id := range []string{second.ID, third.ID} {
+		w = env.serve(p, "alice", http.MethodPost, "/complete", &completeAPIRequest{ID: id})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+	assert.Empty(t, env.postsTo("audit"), "completions are batched")
+
+	p.auditLog.flush()
+	posts := env.postsTo("audit")
+	require.Len(t, posts, 1)
+	assert.Equal(t, testBotID, posts[0].UserId)
+	assert.Regexp(t, `^Completed Todos:\n\n\* \d{4}-\d\d-\d\d \d\d:\d\d:\d\d UTC: @alice completed "call the bank"\n\* .* UTC: @alice completed "feed the cat"$`, posts[0].Message)
+}
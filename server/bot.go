@@ -1,12 +1,116 @@
 package main
 
 import (
+	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin"
 	"github.com/pkg/errors"
 )
 
+const installAnnouncement = "Hi everyone! The Todo plugin is now installed. It keeps track of your Todos and sends you a daily reminder of them.\n\n" +
+	"* `/todo add [message]` adds a Todo to your list\n" +
+	"* `/todo send @user [message]` asks someone else to do something\n" +
+	"* `/todo help` shows everything else it can do"
+
+const reminderReplyHelp = "\nReply `done <number>` to complete a Todo of this reminder."
+
+var reminderReplyRegExp = regexp.MustCompile(`(?i)^\s*(?:done|complete)\s+#?(\d+)\s*$`)
+
+// reminderReplyCommandRegExp matches the messages meant as a reply to the daily reminder, valid or not
+var reminderReplyCommandRegExp = regexp.MustCompile(`(?i)^\s*(?:done|complete)\b`)
+
+// parseReminderReply parses a reply to the daily reminder like "done 2", and returns the
+// 1-based index of the reminder issue to complete.
+func parseReminderReply(message string) (int, bool) {
+	matches := reminderReplyRegExp.FindStringSubmatch(message)
+	if matches == nil {
+		return 0, false
+	}
+
+	index, err := strconv.Atoi(matches[1])
+	if err != nil || index < 1 {
+		return 0, false
+	}
+
+	return index, true
+}
+
+// announceInstall posts installAnnouncement to the configured announcement channel, only the first time
+// the plugin is activated with one.
+func (p *Plugin) announceInstall() error {
+	channelID := p.getConfiguration().AnnouncementChannelID
+	if channelID == "" {
+		return nil
+	}
+
+	marked, err := p.markInstallAnnounced()
+	if err != nil || !marked {
+		return err
+	}
+
+	_, appErr := p.API.CreatePost(&model.Post{
+		UserId:    p.BotUserID,
+		ChannelId: channelID,
+		Message:   installAnnouncement,
+	})
+	if appErr != nil {
+		// Let the next activation try again
+		if deleteErr := p.API.KVDelete(StoreInstallAnnouncedKey); deleteErr != nil {
+			p.API.LogError("Unable to clear the install announcement flag err=" + deleteErr.Error())
+		}
+		return appErr
+	}
+
+	return nil
+}
+
+// MessageHasBeenPosted lets users act on their daily reminder by replying to the bot.
+func (p *Plugin) MessageHasBeenPosted(c *plugin.Context, post *model.Post) {
+	// Most messages are not for the bot, leave them before looking up their channel
+	if post.UserId == p.BotUserID || post.IsSystemMessage() || !reminderReplyCommandRegExp.MatchString(post.Message) {
+		return
+	}
+
+	channel, appErr := p.API.GetChannel(post.ChannelId)
+	if appErr != nil {
+		p.API.LogError("Unable to get channel of posted message err=" + appErr.Error())
+		return
+	}
+	if channel.Type != model.CHANNEL_DIRECT || channel.Name != model.GetDMNameFromIds(post.UserId, p.BotUserID) {
+		return
+	}
+
+	index, ok := parseReminderReply(post.Message)
+	if !ok {
+		p.postBotDirectMessage(post.UserId, "Sorry, I did not understand that."+reminderReplyHelp)
+		return
+	}
+
+	issueIDs, err := p.getReminderIssues(post.UserId)
+	if err != nil {
+		p.API.LogError("Unable to get last reminder issues err=" + err.Error())
+		return
+	}
+	if index > len(issueIDs) {
+		p.postBotDirectMessage(post.UserId, fmt.Sprintf("There is no Todo number %d on your last reminder.", index))
+		return
+	}
+
+	foreignMuted := p.listManager.IsMutedByForeignUser(post.UserId, issueIDs[index-1])
+	issue, foreignID, listToUpdate, err := p.listManager.CompleteIssue(post.UserId, issueIDs[index-1])
+	if err != nil {
+		p.postBotDirectMessage(post.UserId, fmt.Sprintf("Todo number %d of your last reminder could not be completed. It may have already been completed or removed.", index))
+		return
+	}
+
+	p.notifyIssueCompleted(post.UserId, issue, foreignID, listToUpdate, foreignMuted, "")
+	p.postBotDirectMessage(post.UserId, "Completed Todo: "+issue.Message)
+}
+
 // PostBotDM posts a DM as the cloud bot user.
 func (p *Plugin) PostBotDM(userID string, message string) {
 	p.createBotPostDM(&model.Post{
@@ -15,9 +119,18 @@ func (p *Plugin) PostBotDM(userID string, message string) {
 	}, userID)
 }
 
-// PostBotCustomDM posts a DM as the cloud bot user using custom post with action buttons.
+// postBotDirectMessage posts a message in the DM of userID with the bot, for the messages that can be replied there.
+func (p *Plugin) postBotDirectMessage(userID string, message string) {
+	p.createBotPostDirect(&model.Post{
+		UserId:  p.BotUserID,
+		Message: message,
+	}, userID)
+}
+
+// PostBotCustomDM posts a DM as the cloud bot user using custom post with action buttons. It always goes to the DM,
+// wherever the user receives their other notifications.
 func (p *Plugin) PostBotCustomDM(userID string, message string, todo string, issueID string) {
-	p.createBotPostDM(&model.Post{
+	post := &model.Post{
 		UserId:  p.BotUserID,
 		Message: message + ": " + todo,
 		Type:    "custom_todo",
@@ -27,10 +140,54 @@ func (p *Plugin) PostBotCustomDM(userID string, message string, todo string, iss
 			"todo":    todo,
 			"issueId": issueID,
 		},
-	}, userID)
+	}
+	if p.holdBackInFocus(userID, post) {
+		return
+	}
+	p.createBotPostDirect(post, userID)
+}
+
+// holdBackInFocus queues post for the summary of focus mode if userID is in focus mode, and tells whether it did.
+func (p *Plugin) holdBackInFocus(userID string, post *model.Post) bool {
+	queued, err := p.queueFocusNotification(userID, post.Message)
+	if err != nil {
+		p.API.LogError("Unable to check the focus mode of user_id=" + userID + " err=" + err.Error())
+	}
+	return queued
+}
+
+// isOnlyChannelMember checks whether userID is the only member of channelID, so what is posted there is theirs alone.
+func (p *Plugin) isOnlyChannelMember(channelID, userID string) bool {
+	members, appErr := p.API.GetChannelMembers(channelID, 0, 2)
+	if appErr != nil || members == nil {
+		return false
+	}
+	return len(*members) == 1 && (*members)[0].UserId == userID
 }
 
+// createBotPostDM posts a notification for userID in the channel they chose to receive them in, as long as they
+// are still its only member, or in their DM with the bot otherwise. It is held back if they are in focus mode.
 func (p *Plugin) createBotPostDM(post *model.Post, userID string) {
+	if p.holdBackInFocus(userID, post) {
+		return
+	}
+
+	if channelID := p.getNotificationChannelPreference(userID); channelID != "" {
+		if p.isOnlyChannelMember(channelID, userID) {
+			post.ChannelId = channelID
+			_, appError := p.API.CreatePost(post)
+			if appError == nil {
+				return
+			}
+			p.API.LogError("Unable to create bot post in the notification channel err=" + appError.Error())
+		}
+	}
+
+	p.createBotPostDirect(post, userID)
+}
+
+// createBotPostDirect posts in the DM of userID with the bot, regardless of where they receive their notifications.
+func (p *Plugin) createBotPostDirect(post *model.Post, userID string) {
 	channel, appError := p.API.GetDirectChannel(userID, p.BotUserID)
 
 	if appError != nil {
@@ -50,7 +207,8 @@ func (p *Plugin) createBotPostDM(post *model.Post, userID string) {
 	}
 }
 
-// ReplyPostBot post a message and a todo in the same thread as the post postID
+// ReplyPostBot post a message and a todo in the same thread as the post postID, unless the post is in one of the
+// quiet thread channels
 func (p *Plugin) ReplyPostBot(postID, message, todo string) error {
 	if postID == "" {
 		return errors.New("post ID not defined")
@@ -60,6 +218,9 @@ func (p *Plugin) ReplyPostBot(postID, message, todo string) error {
 	if appErr != nil {
 		return appErr
 	}
+	if p.getConfiguration().isQuietThreadChannel(post.ChannelId) {
+		return nil
+	}
 	rootID := post.Id
 	if post.RootId != "" {
 		rootID = post.RootId
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReminderReply(t *testing.T) {
+	tests := []struct {
+		message string
+		index   int
+		ok      bool
+	}{
+		{message: "done 2", index: 2, ok: true},
+		{message: "Done 1", index: 1, ok: true},
+		{message: "  complete   #3 ", index: 3, ok: true},
+		{message: "done 0", ok: false},
+		{message: "done", ok: false},
+		{message: "done two", ok: false},
+		{message: "done 2 and 3", ok: false},
+		{message: "thanks!", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			index, ok := parseReminderReply(tt.message)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.index, index)
+		})
+	}
+}
+
+func TestMessageHasBeenPostedCompletesReminderIssue(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+	dm := &model.Channel{Id: "dm_alice", Type: model.CHANNEL_DIRECT, Name: model.GetDMNameFromIds("alice", testBotID)}
+	env.api.On("GetChannel", dm.Id).Return(dm, nil)
+
+	_, err := p.listManager.AddIssue("alice", "first", "", "", IssueFields{})
+	require.NoError(t, err)
+	receivedID, err := p.listManager.SendIssue("bob", "alice", "second", "", "", IssueFields{})
+	require.NoError(t, err)
+	_, _, err = p.listManager.AcceptIssue("alice", receivedID)
+	require.NoError(t, err)
+
+	issues, err := p.listManager.GetIssueList("alice", MyListKey)
+	require.NoError(t, err)
+	p.sendDailyReminder("alice", issues)
+
+	// A new issue added after the reminder does not shift the reminder numbers
+	_, err = p.listManager.AddIssue("alice", "third", "", "", IssueFields{})
+	require.NoError(t, err)
+	env.resetRecords()
+
+	p.MessageHasBeenPosted(nil, &model.Post{UserId: "alice", ChannelId: dm.Id, Message: "done 2"})
+
+	issues, err = p.listManager.GetIssueList("alice", MyListKey)
+	require.NoError(t, err)
+	require.Len(t, issues, 2)
+	assert.Equal(t, "first", issues[0].Message)
+	assert.Equal(t, "third", issues[1].Message)
+
+	replies := env.postsTo("dm_alice")
+	require.Len(t, replies, 1)
+	assert.Equal(t, "Completed Todo: second", replies[0].Message)
+	require.Len(t, env.postsTo("dm_bob"), 1)
+
+	env.resetRecords()
+	p.MessageHasBeenPosted(nil, &model.Post{UserId: "alice", ChannelId: dm.Id, Message: "done 2"})
+	replies = env.postsTo("dm_alice")
+	require.Len(t, replies, 1)
+	assert.Contains(t, replies[0].Message, "could not be completed")
+
+	env.resetRecords()
+	p.MessageHasBeenPosted(nil, &model.Post{UserId: "alice", ChannelId: dm.Id, Message: "done two"})
+	replies = env.postsTo("dm_alice")
+	require.Len(t, replies, 1)
+	assert.Contains(t, replies[0].Message, "did not understand")
+}
+
+func TestMessageHasBeenPostedIgnoresOtherMessages(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	p := env.newPlugin()
+	dm := &model.Channel{Id: "dm_alice", Type: model.CHANNEL_DIRECT, Name: model.GetDMNameFromIds("alice", testBotID)}
+	env.api.On("GetChannel", dm.Id).Return(dm, nil)
+	town := &model.Channel{Id: "town", Type: model.CHANNEL_OPEN, Name: "town-square"}
+	env.api.On("GetChannel", town.Id).Return(town, nil)
+
+	p.MessageHasBeenPosted(nil, &model.Post{UserId: "alice", ChannelId: dm.Id, Message: "thanks!"})
+	p.MessageHasBeenPosted(nil, &model.Post{UserId: "alice", ChannelId: town.Id, Message: "are we done?"})
+	env.api.AssertNotCalled(t, "GetChannel", mock.Anything)
+
+	p.MessageHasBeenPosted(nil, &model.Post{UserId: "alice", ChannelId: town.Id, Message: "done 1"})
+	assert.Empty(t, env.postsTo("dm_alice"), "only the replies in the DM with the bot are answered")
+	assert.Empty(t, env.postsTo(town.Id))
+}
+
+func TestAnnounceInstallPostsOnce(t *testing.T) {
+	env := newTestEnv()
+	p := env.newPlugin()
+
+	require.NoError(t, p.announceInstall())
+	assert.Empty(t, env.kv[StoreInstallAnnouncedKey], "nothing is announced without a channel")
+
+	p.setConfiguration(&configuration{AnnouncementChannelID: "town"})
+	require.NoError(t, p.announceInstall())
+	require.NoError(t, p.announceInstall())
+
+	posts := env.postsTo("town")
+	require.Len(t, posts, 1)
+	assert.Equal(t, testBotID, posts[0].UserId)
+	assert.Equal(t, installAnnouncement, posts[0].Message)
+}
+
+func TestNotificationsInChosenChannel(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+	env.api.On("GetChannel", "personal").Return(&model.Channel{Id: "personal", Name: "alice-todos", Type: model.CHANNEL_PRIVATE}, nil)
+	env.api.On("GetChannel", "dm_alice").Return(&model.Channel{Id: "dm_alice", Type: model.CHANNEL_DIRECT}, nil)
+	env.api.On("GetChannel", "team").Return(&model.Channel{Id: "team", Name: "team", Type: model.CHANNEL_PRIVATE}, nil)
+	env.api.On("GetChannelMembers", "personal", 0, 2).Return(&model.ChannelMembers{{ChannelId: "personal", UserId: "alice"}}, nil)
+	env.api.On("GetChannelMembers", "team", 0, 2).Return(&model.ChannelMembers{{ChannelId: "team", UserId: "alice"}, {ChannelId: "team", UserId: "bob"}}, nil)
+
+	isUserError, err := p.runSettingsCommand([]string{"notifications", "here"}, &model.CommandArgs{UserId: "alice", ChannelId: "dm_alice"})
+	assert.Error(t, err, "direct channels cannot be chosen")
+	assert.True(t, isUserError)
+
+	isUserError, err = p.runSettingsCommand([]string{"notifications", "here"}, &model.CommandArgs{UserId: "alice", ChannelId: "team"})
+	assert.Error(t, err, "channels with other members cannot be chosen")
+	assert.True(t, isUserError)
+
+	_, err = p.runSettingsCommand([]string{"notifications", "here"}, &model.CommandArgs{UserId: "alice", ChannelId: "personal"})
+	require.NoError(t, err)
+	assert.Contains(t, env.lastEphemeral(), "~alice-todos")
+
+	p.PostBotDM("alice", "something happened")
+	p.sendDailyReminder("alice", nil)
+	require.Len(t, env.postsTo("personal"), 1)
+	assert.Equal(t, "something happened", env.postsTo("personal")[0].Message)
+	require.Len(t, env.postsTo("dm_alice"), 1, "the daily reminder stays on the DM")
+
+	p.PostBotCustomDM("alice", "You have received a new Todo from @bob", "a secret", "issue_id")
+	assert.Len(t, env.postsTo("personal"), 1)
+	require.Len(t, env.postsTo("dm_alice"), 2, "the Todos to accept or decline stay on the DM")
+	assert.Equal(t, "custom_todo", env.postsTo("dm_alice")[1].Type)
+
+	// Users who are not the only member of the channel are sent a DM instead
+	require.NoError(t, p.saveNotificationChannelPreference("bob", "personal"))
+	p.PostBotDM("bob", "something else happened")
+	assert.Len(t, env.postsTo("personal"), 1)
+	assert.Len(t, env.postsTo("dm_bob"), 1)
+
+	_, err = p.runSettingsCommand([]string{"notifications", "dm"}, &model.CommandArgs{UserId: "alice"})
+	require.NoError(t, err)
+	p.PostBotDM("alice", "back to the DM")
+	assert.Len(t, env.postsTo("dm_alice"), 3)
+}
+
+func TestSummaryHeaderSetting(t *testing.T) {
+	env := newTestEnv()
+	p := env.newPlugin()
+	extra := &model.CommandArgs{UserId: "alice"}
+
+	p.sendDailyReminder("alice", nil)
+	require.Len(t, env.postsTo("dm_alice"), 1)
+	assert.True(t, strings.HasPrefix(env.postsTo("dm_alice")[0].Message, "Daily Reminder:\n\n"))
+
+	_, err := p.runSettingsCommand([]string{"summary_header", "Good", "morning!", "Here's", "your", "list"}, extra)
+	require.NoError(t, err)
+	env.resetRecords()
+	p.sendDailyReminder("alice", nil)
+	require.Len(t, env.postsTo("dm_alice"), 1)
+	assert.True(t, strings.HasPrefix(env.postsTo("dm_alice")[0].Message, "Good morning! Here's your list\n\n"))
+
+	isUserError, err := p.runSettingsCommand([]string{"summary_header", strings.Repeat("a", MaxReminderHeaderLength+1)}, extra)
+	assert.Error(t, err)
+	assert.True(t, isUserError)
+
+	_, err = p.runSettingsCommand([]string{"summary_header", "default"}, extra)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultReminderHeader, p.getReminderHeaderPreference("alice"))
+}
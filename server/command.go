@@ -4,55 +4,275 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mattermost/mattermost-server/v5/model"
 	"github.com/mattermost/mattermost-server/v5/plugin"
 )
 
 const (
-	listHeaderMessage = " Todo List:\n\n"
-	MyFlag            = "my"
-	InFlag            = "in"
-	OutFlag           = "out"
+	listHeaderMessage  = " Todo List:\n\n"
+	MyFlag             = "my"
+	InFlag             = "in"
+	OutFlag            = "out"
+	StarredFlag        = "starred"
+	OverdueFlag        = "overdue"
+	SomedayFlag        = "someday"
+	ByChannelFlag      = "by-channel"
+	BySenderFlag       = "by-sender"
+	PendingFlag        = "pending"
+	AcceptedFlag       = "accepted"
+	noChannelGroup     = "No channel"
+	unknownSenderGroup = "Unknown sender"
+	directGroup        = "Direct and group messages"
 )
 
 func getHelp() string {
 	return `Available Commands:
 
 add [message]
-	Adds a Todo.
+	Adds a Todo. Add channel:~channel-name to link the Todo with a channel, points:N to estimate its size, and role:name for the role it requires.
+	Start the message with !high, !normal or !low to set its priority, the Todos of higher priority are listed first.
+	Todos added without one get your default priority.
 
 	example: /todo add Don't forget to be awesome
+	example: /todo add Prepare the demo channel:~team-standup
+	example: /todo add Refactor the importer points:5
+	example: /todo add Test the upgrade role:qa
+	example: /todo add !high Renew the certificates
 
 list
 	Lists your Todo issues.
 
-list [listName]
-	List your issues in certain list
+list [listName] [role:name]
+	List your issues in certain list, optionally only the ones that require a role
 
 	example: /todo list in
 	example: /todo list out
+	example (sent Todos not accepted yet): /todo list out pending
+	example (sent Todos already accepted): /todo list out accepted
+	example: /todo list starred
+	example (Todos past their due date): /todo list overdue
+	example: /todo list someday
+	example (your list grouped by the channel of the attached posts): /todo list by-channel
 	example (same as /todo list): /todo list my
+	example (Todos of your list that require the qa role): /todo list role:qa
+	example: /todo list in role:qa
+
+inbox [by-sender]
+	Lists the Todos you received, optionally grouped by who sent them
+
+	example: /todo inbox by-sender
+
+thread [permalink]
+	Lists your Todos attached to a post, on any of your lists
+
+	example: /todo thread https://example.com/team/pl/8xsyqbrwbfy1mjfbdsn3ju4ude
+
+mentions [user]
+	Lists your Todos that mention a user in their message or description, on any of your lists
+
+	example: /todo mentions @awesomePerson
+
+search [query]
+	Lists your Todos matching every filter and word of the query, on any of your lists. The filters are is:starred,
+	is:overdue, is:waiting, role:name and priority:high, normal or low, and anything else is looked for in the message and description
+
+	example: /todo search is:overdue priority:high review
+
+next
+	Suggests the Todo of your list to do next, favoring starred, nagging and older Todos over the ones waiting on someone
+
+stale [days]
+	Lists the Todos of your list created at least that many days ago (14 by default), to help you clean them up
+
+	example: /todo stale
+	example: /todo stale 30
 
 pop
-	Removes the Todo issue at the top of the list.
+	Removes the Todo issue at the top of the list, or the one due the soonest if you set pop_order to urgent.
+
+show [listName] [index]
+	Shows all the details of the Todo issue at that position of the list (my list by default)
+
+	example: /todo show 2
+	example: /todo show out 1
+
+star [listName] [index]
+	Stars or unstars the Todo issue at that position of the list (my list by default)
+
+	example: /todo star 2
+	example: /todo star in 1
+
+complete [listName] [index] [note: text]
+	Completes the Todo issue at that position of the list (my list by default).
+	Instead of the position, the Todo can be given by its whole message between double quotes, in any case.
+	A note is posted along with the completion on the thread the Todo is attached to.
+
+	example: /todo complete 2
+	example: /todo complete "water the plants"
+	example: /todo complete in 1 note: deployed to production
+
+mute [listName] [index]
+	Stops or resumes the messages about the changes the other person makes to the shared Todo at that position of the list (my list by default)
+
+	example: /todo mute out 2
+
+waiting [index] [user] [notify]
+	Marks the Todo issue at that position of your list as waiting on some user, without sending it to them.
+	Add notify to let them know with a message. Leave the user out to clear it.
+
+	example: /todo waiting 2 @awesomePerson
+	example: /todo waiting 2 @awesomePerson notify
+	example: /todo waiting 2
+
+someday [index]
+	Puts the Todo at that position of your list aside on your someday list. It is left out of the daily reminders.
+
+	example: /todo someday 3
+
+activate [index]
+	Moves the Todo at that position of your someday list back to your list
+
+	example: /todo activate 1
+
+relate [index] [index]
+	Links the Todos at those positions of your list as related to each other. They show each other when shown.
+
+	example: /todo relate 1 3
+
+unrelate [index] [index]
+	Removes the link between the Todos at those positions of your list, on both of them
+
+	example: /todo unrelate 1 3
+
+nag [index] [interval]
+	Reminds you again and again about the Todo at that position of your list until it is done.
+	The interval can be given in minutes, hours or days. Use off to stop it.
+
+	example: /todo nag 1 4h
+	example: /todo nag 1 off
+
+stats
+	Shows how many Todos you have open and the total of their points
+
+focus [on, off]
+	Holds back the notifications about your Todos while you work, and sends you a summary of them when you turn it off
+
+	example: /todo focus on
+
+resend [index]
+	Sends you again the message with the actions for the Todo at that position of your incoming list
+
+	example: /todo resend 1
+
+accept-from [user]
+	Accepts all the Todos you received from some user
+
+	example: /todo accept-from @awesomePerson
 
 send [user] [message]
-	Sends some user a Todo
+	Sends some user a Todo. Add channel:~channel-name to link the Todo with a channel, points:N to estimate its size, and role:name for the role it requires.
+	Add track:off to hand it over without keeping it on your outgoing list or being notified about it.
+	In a direct message, the user can be left out to send the Todo to the other person in it.
 
 	example: /todo send @awesomePerson Don't forget to be awesome
+	example: /todo send @awesomePerson Water the plants track:off
+
+cancel [index]
+	Withdraws the Todo at that position of your outgoing list, as long as it has not been accepted yet
+
+	example: /todo cancel 1
+
+redirect [index] [user]
+	Sends the Todo at that position of your outgoing list to a different user instead, removing it from the previous receiver.
+	Only the Todos that have not been accepted yet can be redirected
+
+	example: /todo redirect 1 @awesomePerson
 
 settings summary [on, off]
 	Sets user preference on daily reminders
 
 	example: /todo settings summary on
 
+settings summary snooze [days]
+	Pauses the daily reminders for a number of days. They resume automatically afterwards.
+
+	example: /todo settings summary snooze 3
+
+settings summary_descriptions [on, off]
+	Sets whether the daily reminders include the description of each Todo
+
+	example: /todo settings summary_descriptions on
+
+settings summary_header [text, default]
+	Sets the text the daily reminders start with, or goes back to "Daily Reminder:"
+
+	example: /todo settings summary_header Good morning! Here's your list
+
 settings allow_incoming_task_requests [on, off]
 	Allow other Mattermost users to send a task for you to accept/decline?
 
 	example: /todo settings allow_incoming_task_requests on
 
+settings inbox_order [top, bottom]
+	Sets whether new received Todos are placed at the top or the bottom of your incoming list
+
+	example: /todo settings inbox_order top
+
+settings team_default [summary, allow_incoming_task_requests] [on, off, unset]
+	Team admins only. Sets the setting for the members of this team who did not choose it themselves
+
+	example: /todo settings team_default summary off
+
+settings completed_sent [keep, remove]
+	Sets whether the Todos you sent stay on your outgoing list, marked as completed, when their receivers complete them
+
+	example: /todo settings completed_sent keep
+
+settings self_send [list, inbox]
+	Sets whether the Todos you send to yourself are added to your list, or to your incoming list to accept or decline them later
+
+	example: /todo settings self_send inbox
+
+settings pop_order [top, urgent]
+	Sets whether pop removes the Todo at the top of your list, or the one due the soonest with overdue Todos first.
+	When none of your Todos is due, pop removes the top one.
+
+	example: /todo settings pop_order urgent
+
+settings share_counts [on, off]
+	Sets whether integrations of the channels you are in, like a standup bot, can see how many Todos are on your list
+
+	example: /todo settings share_counts on
+
+settings default_priority [high, normal, low]
+	Sets the priority of the Todos you add without choosing one
+
+	example: /todo settings default_priority high
+
+settings priority_emoji [high, normal, low] [emoji, none, default]
+	Sets the emoji shown before the Todos of a priority on your lists, none to show nothing, or default to show the default emoji again
+
+	example: /todo settings priority_emoji high :red_circle:
+
+settings triage_reminder [interval, off]
+	Reminds you at that interval, like 4h or 1d, about the received Todos you have not accepted or declined yet, until you do
+
+	example: /todo settings triage_reminder 1d
+
+settings notifications [dm, here]
+	Sets whether you receive your Todo notifications in your DM with the bot, or in the channel you run it in, which must
+	be a channel you are the only member of. Daily reminders and the Todos to accept or decline stay in the DM.
+
+	example: /todo settings notifications here
+
+settings reset
+	Sets all your settings back to their defaults, after asking you to confirm
+
+	example: /todo settings reset confirm
 
 help
 	Display usage.
@@ -72,12 +292,106 @@ func getAllowIncomingTaskRequestsSetting(flag bool) string {
 	return "Allow incoming task requests setting is set to `off`. **Other users cannot send you task request. They will see a message saying you don't accept Todo requests.**"
 }
 
-func getAllSettings(summaryFlag, blockIncomingFlag bool) string {
+func getInboxOrderSetting(receivedOnTop bool) string {
+	if receivedOnTop {
+		return "Inbox order setting is set to `top`. **New received Todos will be placed at the top of your incoming list.**"
+	}
+	return "Inbox order setting is set to `bottom`. **New received Todos will be placed at the bottom of your incoming list.**"
+}
+
+func getSummaryDescriptionsSetting(flag bool) string {
+	if flag {
+		return "Summary descriptions setting is set to `on`. **Daily reminders will include the description of each Todo.**"
+	}
+	return "Summary descriptions setting is set to `off`. **Daily reminders will only include the Todo messages.**"
+}
+
+func getSummaryHeaderSetting(header string) string {
+	if header == DefaultReminderHeader {
+		return "Summary header setting is set to `default`. **Daily reminders start with \"" + header + "\"**"
+	}
+	return "Summary header setting is set to a custom text. **Daily reminders start with \"" + header + "\"**"
+}
+
+func getCompletedSentSetting(keep bool) string {
+	if keep {
+		return "Completed sent Todos setting is set to `keep`. **Todos you sent stay on your outgoing list when their receivers complete them.**"
+	}
+	return "Completed sent Todos setting is set to `remove`. **Todos you sent are removed from your outgoing list when their receivers complete them.**"
+}
+
+func getSelfSendSetting(toInbox bool) string {
+	if toInbox {
+		return "Self send setting is set to `inbox`. **Todos you send to yourself are placed on your incoming list to accept or decline.**"
+	}
+	return "Self send setting is set to `list`. **Todos you send to yourself are added to your list.**"
+}
+
+// getNotificationsSetting describes where notifications go, given the name of the notification channel or empty for the DM
+func getNotificationsSetting(channelName string) string {
+	if channelName != "" {
+		return fmt.Sprintf("Notifications setting is set to ~%s. **Todo notifications are posted in that channel.**", channelName)
+	}
+	return "Notifications setting is set to `dm`. **Todo notifications are sent to you as a direct message.**"
+}
+
+func getPopOrderSetting(mostUrgent bool) string {
+	if mostUrgent {
+		return "Pop order setting is set to `urgent`. **Pop removes the Todo of your list due the soonest, or the top one if none is due.**"
+	}
+	return "Pop order setting is set to `top`. **Pop removes the Todo at the top of your list.**"
+}
+
+func getShareCountsSetting(flag bool) string {
+	if flag {
+		return "Share counts setting is set to `on`. **Integrations of the channels you are in can see how many Todos are on your list.**"
+	}
+	return "Share counts setting is set to `off`. **How many Todos are on your list is kept to yourself.**"
+}
+
+func getDefaultPrioritySetting(priority int) string {
+	return fmt.Sprintf("Default priority setting is set to `%s`. **The Todos you add without choosing a priority are %s priority.**", priorityName(priority), priorityName(priority))
+}
+
+// priorityEmojiRegexp matches an emoji, by its :name: or as is
+var priorityEmojiRegexp = regexp.MustCompile(`^(:[a-z0-9_+\-]+:|[^\x00-\x7F]{1,8})$`)
+
+// getPriorityEmojiSetting describes the emoji shown before the Todos of each priority, given them by priority name
+func getPriorityEmojiSetting(emoji map[string]string) string {
+	describe := func(name string) string {
+		if emoji[name] == "" {
+			return name + " `none`"
+		}
+		return name + " " + emoji[name]
+	}
+	return fmt.Sprintf("Priority emoji setting is set to %s, %s and %s. **Todos are listed with the emoji of their priority.**", describe("high"), describe("normal"), describe("low"))
+}
+
+// getTriageReminderSetting describes how often the incoming list is reminded about, given the interval in milliseconds or 0 for never
+func getTriageReminderSetting(interval int64) string {
+	if interval > 0 {
+		return fmt.Sprintf("Triage reminder setting is set to `%s`. **You will be reminded at that interval about the received Todos you have not accepted or declined.**", formatNagInterval(time.Duration(interval)*time.Millisecond))
+	}
+	return "Triage reminder setting is set to `off`. **You will not be reminded about the received Todos you have not accepted or declined.**"
+}
+
+func getAllSettings(summaryFlag, summaryDescriptionsFlag, blockIncomingFlag, receivedOnTopFlag, keepCompletedSentFlag, selfSendToInboxFlag, popUrgentFlag, shareCountsFlag bool, triageReminderInterval int64, summaryHeader, notificationChannelName string, defaultPriority int, priorityEmoji map[string]string) string {
 	return fmt.Sprintf(`Current Settings:
 
 %s
 %s
-	`, getSummarySetting(summaryFlag), getAllowIncomingTaskRequestsSetting(blockIncomingFlag))
+%s
+%s
+%s
+%s
+%s
+%s
+%s
+%s
+%s
+%s
+%s
+	`, getSummarySetting(summaryFlag), getSummaryDescriptionsSetting(summaryDescriptionsFlag), getSummaryHeaderSetting(summaryHeader), getAllowIncomingTaskRequestsSetting(blockIncomingFlag), getInboxOrderSetting(receivedOnTopFlag), getCompletedSentSetting(keepCompletedSentFlag), getSelfSendSetting(selfSendToInboxFlag), getPopOrderSetting(popUrgentFlag), getShareCountsSetting(shareCountsFlag), getTriageReminderSetting(triageReminderInterval), getNotificationsSetting(notificationChannelName), getDefaultPrioritySetting(defaultPriority), getPriorityEmojiSetting(priorityEmoji))
 }
 
 func getCommand() *model.Command {
@@ -86,7 +400,7 @@ func getCommand() *model.Command {
 		DisplayName:      "Todo Bot",
 		Description:      "Interact with your Todo list.",
 		AutoComplete:     true,
-		AutoCompleteDesc: "Available commands: add, list, pop, send, help",
+		AutoCompleteDesc: todoCommandsDescription(),
 		AutoCompleteHint: "[command]",
 		AutocompleteData: getAutocompleteData(),
 	}
@@ -109,28 +423,18 @@ func (p *Plugin) ExecuteCommand(c *plugin.Context, args *model.CommandArgs) (*mo
 	lengthOfArgs := len(stringArgs)
 	restOfArgs := []string{}
 
-	var handler func([]string, *model.CommandArgs) (bool, error)
+	var handler func(*Plugin, []string, *model.CommandArgs) (bool, error)
 	if lengthOfArgs == 1 {
-		handler = p.runListCommand
+		handler = (*Plugin).runListCommand
 		p.trackCommand(args.UserId, "")
 	} else {
 		command := stringArgs[1]
 		if lengthOfArgs > 2 {
 			restOfArgs = stringArgs[2:]
 		}
-		switch command {
-		case "add":
-			handler = p.runAddCommand
-		case "list":
-			handler = p.runListCommand
-		case "pop":
-			handler = p.runPopCommand
-		case "send":
-			handler = p.runSendCommand
-		case "settings":
-			handler = p.runSettingsCommand
-		default:
-			if command == "help" {
+		todoCommand := findTodoCommand(command)
+		if todoCommand == nil || todoCommand.handler == nil {
+			if todoCommand != nil {
 				p.trackCommand(args.UserId, command)
 			} else {
 				p.trackCommand(args.UserId, "not found")
@@ -138,9 +442,10 @@ func (p *Plugin) ExecuteCommand(c *plugin.Context, args *model.CommandArgs) (*mo
 			p.postCommandResponse(args, getHelp())
 			return &model.CommandResponse{}, nil
 		}
+		handler = todoCommand.handler
 		p.trackCommand(args.UserId, command)
 	}
-	isUserError, err := handler(restOfArgs, args)
+	isUserError, err := handler(p, restOfArgs, args)
 	if err != nil {
 		if isUserError {
 			p.postCommandResponse(args, fmt.Sprintf("__Error: %s.__\n\nRun `/todo help` for usage instructions.", err.Error()))
@@ -154,67 +459,181 @@ func (p *Plugin) ExecuteCommand(c *plugin.Context, args *model.CommandArgs) (*mo
 }
 
 func (p *Plugin) runSendCommand(args []string, extra *model.CommandArgs) (bool, error) {
-	if len(args) < 2 {
+	receiver, messageArgs := p.getSendRecipient(args, extra)
+	if receiver == nil && len(args) >= 2 {
+		p.postCommandResponse(extra, "Please, provide a valid user.\n"+getHelp())
+		return false, nil
+	}
+	if receiver == nil || len(messageArgs) == 0 {
 		p.postCommandResponse(extra, "You must specify a user and a message.\n"+getHelp())
 		return false, nil
 	}
+	userName := receiver.Username
 
-	userName := args[0]
-	if args[0][0] == '@' {
-		userName = args[0][1:]
+	// Todos sent to yourself skip the checks for other receivers, and go to your own list unless
+	// you chose to triage them on your incoming list
+	selfSend := receiver.Id == extra.UserId
+	if selfSend && !p.getSelfSendToInboxPreference(extra.UserId) {
+		return p.runAddCommand(messageArgs, extra)
 	}
-	receiver, appErr := p.API.GetUserByUsername(userName)
-	if appErr != nil {
-		p.postCommandResponse(extra, "Please, provide a valid user.\n"+getHelp())
-		return false, nil
+
+	if !selfSend {
+		if !p.getConfiguration().EnableSendToOthers {
+			p.postCommandResponse(extra, "Sending Todos to other users is disabled on this server. You can still add Todos to your own list.")
+			return false, nil
+		}
+
+		if !p.isReceiverInCommandChannel(receiver.Id, extra) {
+			p.postCommandResponse(extra, fmt.Sprintf("@%s is not a member of this channel. Todos can only be sent to members of the channel.", userName))
+			return false, nil
+		}
+
+		receiverAllowIncomingTaskRequestsPreference, err := p.getAllowIncomingTaskRequestsPreference(receiver.Id)
+		if err != nil {
+			p.API.LogError("Error when getting allow incoming task request preference, err=", err)
+			receiverAllowIncomingTaskRequestsPreference = true
+		}
+		if !receiverAllowIncomingTaskRequestsPreference {
+			p.postCommandResponse(extra, fmt.Sprintf("@%s has blocked Todo requests", userName))
+			return false, nil
+		}
 	}
 
-	if receiver.Id == extra.UserId {
-		return p.runAddCommand(args[1:], extra)
+	messageArgs, channel, err := p.extractChannelTag(messageArgs, extra)
+	if err != nil {
+		return true, err
 	}
 
-	receiverAllowIncomingTaskRequestsPreference, err := p.getAllowIncomingTaskRequestsPreference(receiver.Id)
+	messageArgs, points, err := extractPointsTag(messageArgs)
 	if err != nil {
-		p.API.LogError("Error when getting allow incoming task request preference, err=", err)
-		receiverAllowIncomingTaskRequestsPreference = true
+		return true, err
 	}
-	if !receiverAllowIncomingTaskRequestsPreference {
-		p.postCommandResponse(extra, fmt.Sprintf("@%s has blocked Todo requests", userName))
-		return false, nil
+
+	messageArgs, role, err := extractRoleTag(messageArgs)
+	if err != nil {
+		return true, err
+	}
+
+	messageArgs, track, err := extractTrackTag(messageArgs)
+	if err != nil {
+		return true, err
 	}
 
-	message := strings.Join(args[1:], " ")
+	message, _, err := sanitizeIssueText(strings.Join(messageArgs, " "), "")
+	if err != nil {
+		return true, err
+	}
 
-	receiverIssueID, err := p.listManager.SendIssue(extra.UserId, receiver.Id, message, "", "")
+	sendIssue := p.listManager.SendIssue
+	if !track || selfSend {
+		sendIssue = p.listManager.SendUntrackedIssue
+	}
+	receiverIssueID, err := sendIssue(extra.UserId, receiver.Id, message, "", "", IssueFields{ChannelID: channelID(channel), Points: points, Role: role})
 	if err != nil {
 		return false, err
 	}
 
 	p.trackSendIssue(extra.UserId, sourceCommand, false)
 
-	p.sendRefreshEvent(extra.UserId, []string{OutListKey})
+	if selfSend {
+		p.sendRefreshEvent(extra.UserId, []string{InListKey})
+		p.postCommandResponse(extra, "Todo placed on your incoming list.")
+		return false, nil
+	}
+	p.rememberRecipient(extra.UserId, receiver.Id)
+
+	if track {
+		p.sendRefreshEvent(extra.UserId, []string{OutListKey})
+	}
 	p.sendRefreshEvent(receiver.Id, []string{InListKey})
 
 	responseMessage := fmt.Sprintf("Todo sent to @%s.", userName)
+	if !track {
+		responseMessage += " It is not kept on your outgoing list, and you will not be notified about it."
+	}
 
 	senderName := p.listManager.GetUserName(extra.UserId)
 
 	receiverMessage := fmt.Sprintf("You have received a new Todo from @%s", senderName)
+	// Like on their lists, the receiver only sees the name of a channel they are a member of
+	if channel != nil {
+		if _, appErr := p.API.GetChannelMember(channel.Id, receiver.Id); appErr == nil {
+			receiverMessage += fmt.Sprintf(" about ~%s", channel.Name)
+		}
+	}
 
 	p.PostBotCustomDM(receiver.Id, receiverMessage, message, receiverIssueID)
 	p.postCommandResponse(extra, responseMessage)
 	return false, nil
 }
 
+// isReceiverInCommandChannel checks whether receiverID can be sent Todos from the channel the command was run in.
+// It always can unless sending is restricted to channel members.
+func (p *Plugin) isReceiverInCommandChannel(receiverID string, extra *model.CommandArgs) bool {
+	if !p.getConfiguration().RestrictSendToChannelMembers || extra.ChannelId == "" {
+		return true
+	}
+
+	_, appErr := p.API.GetChannelMember(extra.ChannelId, receiverID)
+	return appErr == nil
+}
+
+// getSendRecipient resolves the receiver of /todo send from its first argument, and returns the rest of the
+// arguments as the message. When the first argument is not a user and the command is run in a DM, the other
+// participant of the DM is the receiver and all the arguments are the message.
+func (p *Plugin) getSendRecipient(args []string, extra *model.CommandArgs) (*model.User, []string) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	receiver, appErr := p.API.GetUserByUsername(strings.TrimPrefix(args[0], "@"))
+	if appErr == nil {
+		return receiver, args[1:]
+	}
+	if strings.HasPrefix(args[0], "@") || extra.ChannelId == "" {
+		return nil, nil
+	}
+
+	channel, appErr := p.API.GetChannel(extra.ChannelId)
+	if appErr != nil || channel.Type != model.CHANNEL_DIRECT {
+		return nil, nil
+	}
+	otherID := channel.GetOtherUserIdForDM(extra.UserId)
+	if otherID == "" || otherID == p.BotUserID {
+		return nil, nil
+	}
+	receiver, appErr = p.API.GetUser(otherID)
+	if appErr != nil || receiver.IsBot {
+		return nil, nil
+	}
+
+	return receiver, args
+}
+
 func (p *Plugin) runAddCommand(args []string, extra *model.CommandArgs) (bool, error) {
-	message := strings.Join(args, " ")
+	messageArgs, channel, err := p.extractChannelTag(args, extra)
+	if err != nil {
+		return true, err
+	}
 
-	if message == "" {
-		p.postCommandResponse(extra, "Please add a task.")
-		return false, nil
+	messageArgs, points, err := extractPointsTag(messageArgs)
+	if err != nil {
+		return true, err
+	}
+
+	messageArgs, role, err := extractRoleTag(messageArgs)
+	if err != nil {
+		return true, err
+	}
+
+	messageArgs, priority, hasPriority := extractPriorityToken(messageArgs)
+
+	message, _, err := sanitizeIssueText(strings.Join(messageArgs, " "), "")
+	if err != nil {
+		return true, err
 	}
 
-	newIssue, err := p.listManager.AddIssue(extra.UserId, message, "", "")
+	newIssue, err := p.listManager.AddIssue(extra.UserId, message, "", "", IssueFields{ChannelID: channelID(channel), Points: points, Role: role, Priority: priority, HasPriority: hasPriority})
 	if err != nil {
 		return false, err
 	}
@@ -244,7 +663,8 @@ func (p *Plugin) runAddCommand(args []string, extra *model.CommandArgs) (bool, e
 	}
 	if !issueIncluded {
 		issues = append(issues, &ExtendedIssue{
-			Issue: *newIssue,
+			Issue:         *newIssue,
+			PriorityEmoji: getPriorityEmojiPreference(p.API, extra.UserId)[priorityName(newIssue.Priority)],
 		})
 	}
 
@@ -259,6 +679,14 @@ func (p *Plugin) runListCommand(args []string, extra *model.CommandArgs) (bool,
 	listID := MyListKey
 	responseMessage := "Todo List:\n\n"
 
+	args, role, err := extractRoleTag(args)
+	if err != nil {
+		return true, err
+	}
+	if role != "" && (len(args) > 1 || (len(args) == 1 && args[0] != MyFlag && args[0] != InFlag && args[0] != OutFlag && args[0] != SomedayFlag)) {
+		return true, errors.New("the Todos of the my, in, out and someday lists can be listed by role")
+	}
+
 	if len(args) > 0 {
 		switch args[0] {
 		case MyFlag:
@@ -266,8 +694,22 @@ func (p *Plugin) runListCommand(args []string, extra *model.CommandArgs) (bool,
 			listID = InListKey
 			responseMessage = "Received Todo list:\n\n"
 		case OutFlag:
+			if len(args) > 1 {
+				return p.runListOutByAcceptanceCommand(args[1:], extra)
+			}
 			listID = OutListKey
 			responseMessage = "Sent Todo list:\n\n"
+		case StarredFlag:
+			listID = StarredListKey
+			responseMessage = "Starred Todo list:\n\n"
+		case OverdueFlag:
+			listID = OverdueListKey
+			responseMessage = "Overdue Todo list:\n\n"
+		case SomedayFlag:
+			listID = SomedayListKey
+			responseMessage = "Someday Todo list:\n\n"
+		case ByChannelFlag:
+			return p.runListByChannelCommand(extra)
 		default:
 			p.postCommandResponse(extra, getHelp())
 			return true, nil
@@ -279,7 +721,12 @@ func (p *Plugin) runListCommand(args []string, extra *model.CommandArgs) (bool,
 		return false, err
 	}
 
-	p.sendRefreshEvent(extra.UserId, []string{MyListKey, OutListKey, InListKey})
+	p.sendRefreshEvent(extra.UserId, viewedLists(listID))
+
+	if role != "" {
+		p.postCommandResponse(extra, responseMessage+issuesWithRoleToString(issues, role))
+		return false, nil
+	}
 
 	responseMessage += issuesListToString(issues)
 	p.postCommandResponse(extra, responseMessage)
@@ -287,67 +734,1257 @@ func (p *Plugin) runListCommand(args []string, extra *model.CommandArgs) (bool,
 	return false, nil
 }
 
-func (p *Plugin) runPopCommand(args []string, extra *model.CommandArgs) (bool, error) {
-	issue, foreignID, err := p.listManager.PopIssue(extra.UserId)
+// issuesWithRoleToString renders the issues that require role, with their positions on the list.
+func issuesWithRoleToString(issues []*ExtendedIssue, role string) string {
+	group := filterIssueGroup(issues, "Role: "+role, func(issue *ExtendedIssue) bool {
+		return issue.Role == role
+	})
+	if group == nil {
+		return fmt.Sprintf("No Todos require the role %s.", role)
+	}
+	return issueGroupsToString([]*issueGroup{group})
+}
+
+// viewedLists returns the stored lists shown when viewing listID, so only those are refreshed on the
+// client. Nothing changes when a list is viewed, the refresh only brings the client up to date with it.
+func viewedLists(listID string) []string {
+	if listID == StarredListKey || listID == OverdueListKey {
+		return []string{MyListKey, OutListKey, InListKey}
+	}
+	return []string{listID}
+}
+
+func (p *Plugin) runListByChannelCommand(extra *model.CommandArgs) (bool, error) {
+	issues, err := p.listManager.GetIssueList(extra.UserId, MyListKey)
 	if err != nil {
-		if err.Error() == "cannot find issue" {
-			p.postCommandResponse(extra, "There are no Todos to pop.")
-			return false, nil
-		}
 		return false, err
 	}
 
-	userName := p.listManager.GetUserName(extra.UserId)
+	p.sendRefreshEvent(extra.UserId, viewedLists(MyListKey))
 
-	if foreignID != "" {
-		p.sendRefreshEvent(foreignID, []string{OutListKey})
+	p.postCommandResponse(extra, "Todo List by channel:\n"+issueGroupsToString(p.groupIssuesByChannel(issues)))
+	return false, nil
+}
+
+// groupIssuesByChannel groups issues by the channel of the post they are attached to, in the order
+// each channel first appears. Issues without a post, or whose post is gone, are grouped last.
+func (p *Plugin) groupIssuesByChannel(issues []*ExtendedIssue) []*issueGroup {
+	channelNames := map[string]string{}
 
-		message := fmt.Sprintf("@%s popped a Todo you sent: %s", userName, issue.Message)
-		p.PostBotDM(foreignID, message)
+	return groupIssues(issues, func(issue *ExtendedIssue) string {
+		if issue.PostID == "" {
+			return noChannelGroup
+		}
+		post, appErr := p.API.GetPost(issue.PostID)
+		if appErr != nil {
+			return noChannelGroup
+		}
+		channelName, ok := channelNames[post.ChannelId]
+		if !ok {
+			channelName = p.getChannelGroupName(post.ChannelId)
+			channelNames[post.ChannelId] = channelName
+		}
+		return channelName
+	}, noChannelGroup)
+}
+
+// groupIssuesBySender groups received issues under the name of their sender, with the number of issues each sent.
+func groupIssuesBySender(issues []*ExtendedIssue) []*issueGroup {
+	groups := groupIssues(issues, func(issue *ExtendedIssue) string {
+		if issue.ForeignUser == "" {
+			return unknownSenderGroup
+		}
+		return "@" + issue.ForeignUser
+	}, unknownSenderGroup)
+
+	for _, group := range groups {
+		group.Name = fmt.Sprintf("%s (%d)", group.Name, len(group.Issues))
 	}
+	return groups
+}
 
-	p.sendRefreshEvent(extra.UserId, []string{MyListKey})
+func (p *Plugin) runListOutByAcceptanceCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	if len(args) > 1 {
+		return true, errors.New("too many arguments")
+	}
 
-	responseMessage := "Removed top Todo."
+	var accepted bool
+	var groupName string
+	switch args[0] {
+	case PendingFlag:
+		groupName = "Not accepted yet"
+	case AcceptedFlag:
+		accepted = true
+		groupName = "Accepted"
+	default:
+		return true, fmt.Errorf("`%s` is not a valid filter, use %s or %s", args[0], PendingFlag, AcceptedFlag)
+	}
 
-	replyMessage := fmt.Sprintf("@%s popped a todo attached to this thread", userName)
-	p.postReplyIfNeeded(issue.PostID, replyMessage, issue.Message)
+	issues, err := p.listManager.GetIssueList(extra.UserId, OutListKey)
+	if err != nil {
+		return false, err
+	}
 
-	issues, err := p.listManager.GetIssueList(extra.UserId, MyListKey)
+	p.postCommandResponse(extra, "Sent Todo list:\n"+issueGroupsToString(groupIssuesByAcceptance(issues, accepted, groupName)))
+	return false, nil
+}
+
+// postIDFromPermalink returns the ID of the post a permalink like https://example.com/team/pl/postid
+// points to. A bare post ID is returned as it is.
+func postIDFromPermalink(permalink string) string {
+	permalink = strings.TrimSuffix(permalink, "/")
+	return permalink[strings.LastIndex(permalink, "/")+1:]
+}
+
+func (p *Plugin) runThreadCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	if len(args) != 1 {
+		return true, errors.New("missing the permalink of the post")
+	}
+
+	postID := postIDFromPermalink(args[0])
+	if !model.IsValidId(postID) {
+		return true, fmt.Errorf("`%s` is not a permalink to a post", args[0])
+	}
+
+	groups, err := p.findIssues(extra.UserId, func(issue *ExtendedIssue) bool {
+		return issue.PostID == postID
+	})
 	if err != nil {
-		p.API.LogError(err.Error())
-		p.postCommandResponse(extra, responseMessage)
-		return false, nil
+		return false, err
 	}
 
-	responseMessage += listHeaderMessage
-	responseMessage += issuesListToString(issues)
-	p.postCommandResponse(extra, responseMessage)
+	if len(groups) == 0 {
+		p.postCommandResponse(extra, "You have no Todos attached to that post.")
+		return false, nil
+	}
 
+	p.postCommandResponse(extra, "Todos attached to the post:\n"+issueGroupsToString(groups))
 	return false, nil
 }
 
-func (p *Plugin) runSettingsCommand(args []string, extra *model.CommandArgs) (bool, error) {
-	const (
-		on  = "on"
-		off = "off"
-	)
-	if len(args) < 1 {
-		currentSummarySetting := p.getReminderPreference(extra.UserId)
-		currentAllowIncomingTaskRequestsSetting, err := p.getAllowIncomingTaskRequestsPreference(extra.UserId)
-		if err != nil {
-			p.API.LogError("Error when getting allow incoming task request preference, err=", err)
-			currentAllowIncomingTaskRequestsSetting = true
-		}
-		p.postCommandResponse(extra, getAllSettings(currentSummarySetting, currentAllowIncomingTaskRequestsSetting))
+func (p *Plugin) runMentionsCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	if len(args) != 1 {
+		return true, errors.New("missing the user mentioned in the Todos")
+	}
+
+	user, appErr := p.API.GetUserByUsername(strings.TrimPrefix(args[0], "@"))
+	if appErr != nil {
+		p.postCommandResponse(extra, "Please, provide a valid user.\n"+getHelp())
+		return false, nil
+	}
+
+	groups, err := p.findIssues(extra.UserId, func(issue *ExtendedIssue) bool {
+		return mentionsUser(issue.Message, user.Username) || mentionsUser(issue.Description, user.Username)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if len(groups) == 0 {
+		p.postCommandResponse(extra, fmt.Sprintf("None of your Todos mention @%s.", user.Username))
+		return false, nil
+	}
+
+	p.postCommandResponse(extra, fmt.Sprintf("Todos mentioning @%s:\n", user.Username)+issueGroupsToString(groups))
+	return false, nil
+}
+
+// findIssues returns the issues of every list of userID for which keep is true, grouped by list.
+func (p *Plugin) findIssues(userID string, keep func(issue *ExtendedIssue) bool) ([]*issueGroup, error) {
+	groups := []*issueGroup{}
+	for _, list := range []struct {
+		ID   string
+		Name string
+	}{
+		{MyListKey, "Your list"},
+		{InListKey, "Received"},
+		{OutListKey, "Sent"},
+		{SomedayListKey, "Someday"},
+	} {
+		issues, err := p.listManager.GetIssueList(userID, list.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if group := filterIssueGroup(issues, list.Name, keep); group != nil {
+			groups = append(groups, group)
+		}
+	}
+
+	return groups, nil
+}
+
+func (p *Plugin) runInboxCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	if len(args) == 0 {
+		return p.runListCommand([]string{InFlag}, extra)
+	}
+	if len(args) > 1 || args[0] != BySenderFlag {
+		return true, errors.New("the incoming list can only be grouped `by-sender`")
+	}
+
+	issues, err := p.listManager.GetIssueList(extra.UserId, InListKey)
+	if err != nil {
+		return false, err
+	}
+
+	p.sendRefreshEvent(extra.UserId, viewedLists(InListKey))
+
+	p.postCommandResponse(extra, "Received Todo list by sender:\n"+issueGroupsToString(groupIssuesBySender(issues)))
+	return false, nil
+}
+
+func (p *Plugin) getChannelGroupName(channelID string) string {
+	channel, appErr := p.API.GetChannel(channelID)
+	if appErr != nil {
+		return noChannelGroup
+	}
+	if channel.Type == model.CHANNEL_DIRECT || channel.Type == model.CHANNEL_GROUP {
+		return directGroup
+	}
+	return "~" + channel.Name
+}
+
+func (p *Plugin) runPopCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	mostUrgent := p.getPopUrgentPreference(extra.UserId)
+
+	foreignMuted := false
+	if issues, err := p.listManager.GetIssueList(extra.UserId, MyListKey); err == nil && len(issues) > 0 {
+		position := 0
+		if mostUrgent {
+			plainIssues := make([]*Issue, len(issues))
+			for i, issue := range issues {
+				plainIssues[i] = &issue.Issue
+			}
+			position = mostUrgentPosition(plainIssues)
+		}
+		foreignMuted = p.listManager.IsMutedByForeignUser(extra.UserId, issues[position].ID)
+	}
+
+	issue, foreignID, err := p.listManager.PopIssue(extra.UserId, mostUrgent)
+	if err != nil {
+		if err.Error() == "cannot find issue" {
+			p.postCommandResponse(extra, "There are no Todos to pop.")
+			return false, nil
+		}
+		return false, err
+	}
+
+	userName := p.listManager.GetUserName(extra.UserId)
+
+	if foreignID != "" {
+		p.sendRefreshEvent(foreignID, []string{OutListKey})
+		if !foreignMuted {
+			message := fmt.Sprintf("@%s popped a Todo you sent: %s", userName, issue.Message)
+			p.PostBotDM(foreignID, message)
+		}
+	}
+
+	p.sendRefreshEvent(extra.UserId, []string{MyListKey})
+
+	responseMessage := "Removed top Todo."
+	if mostUrgent {
+		responseMessage = "Removed most urgent Todo."
+	}
+
+	replyMessage := fmt.Sprintf("@%s popped a todo attached to this thread", userName)
+	p.postReplyIfNeeded(issue.PostID, replyMessage, issue.Message, threadReplyRemove)
+
+	issues, err := p.listManager.GetIssueList(extra.UserId, MyListKey)
+	if err != nil {
+		p.API.LogError(err.Error())
+		p.postCommandResponse(extra, responseMessage)
+		return false, nil
+	}
+
+	responseMessage += listHeaderMessage
+	responseMessage += issuesListToString(issues)
+	p.postCommandResponse(extra, responseMessage)
+
+	return false, nil
+}
+
+func (p *Plugin) runShowCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	listID, index, err := parseListAndIndex(args)
+	if err != nil {
+		return true, err
+	}
+
+	listIssue, isUserError, err := p.getIssueByIndex(extra.UserId, listID, index)
+	if err != nil {
+		return isUserError, err
+	}
+
+	issue, err := p.listManager.GetIssue(extra.UserId, listIssue.ID)
+	if err != nil {
+		return false, err
+	}
+
+	userNames := map[string]string{}
+	for _, change := range issue.History {
+		if _, ok := userNames[change.UserID]; !ok {
+			userNames[change.UserID] = p.listManager.GetUserName(change.UserID)
+		}
+	}
+
+	p.postCommandResponse(extra, issueToString(issue)+issueHistoryToString(issue.History, userNames))
+	return false, nil
+}
+
+func (p *Plugin) runStarCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	listID, index, err := parseListAndIndex(args)
+	if err != nil {
+		return true, err
+	}
+
+	issue, isUserError, err := p.getIssueByIndex(extra.UserId, listID, index)
+	if err != nil {
+		return isUserError, err
+	}
+
+	starred, err := p.listManager.StarIssue(extra.UserId, issue.ID)
+	if err != nil {
+		return false, err
+	}
+
+	p.sendRefreshEvent(extra.UserId, []string{listID})
+
+	responseMessage := fmt.Sprintf("Starred Todo: %s", issue.Message)
+	if !starred {
+		responseMessage = fmt.Sprintf("Unstarred Todo: %s", issue.Message)
+	}
+	p.postCommandResponse(extra, responseMessage)
+
+	return false, nil
+}
+
+func (p *Plugin) runCompleteCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	indexArgs, note := extractNoteTag(args)
+	listID, message, byMessage := parseListAndQuotedMessage(indexArgs)
+	var index string
+	var err error
+	if !byMessage {
+		if listID, index, err = parseListAndIndex(indexArgs); err != nil {
+			return true, err
+		}
+	}
+	if listID == OutListKey {
+		return true, errors.New("the Todos you sent are completed by their receivers")
+	}
+
+	var issue *ExtendedIssue
+	var isUserError bool
+	if byMessage {
+		issue, isUserError, err = p.getIssueByMessage(extra.UserId, listID, message)
+	} else {
+		issue, isUserError, err = p.getIssueByIndex(extra.UserId, listID, index)
+	}
+	if err != nil {
+		return isUserError, err
+	}
+
+	foreignMuted := p.listManager.IsMutedByForeignUser(extra.UserId, issue.ID)
+	completed, foreignID, listToUpdate, err := p.listManager.CompleteIssue(extra.UserId, issue.ID)
+	if err != nil {
+		return false, err
+	}
+
+	p.notifyIssueCompleted(extra.UserId, completed, foreignID, listToUpdate, foreignMuted, note)
+	p.postCommandResponse(extra, "Completed Todo: "+issue.Message)
+	return false, nil
+}
+
+func (p *Plugin) runMuteCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	listID, index, err := parseListAndIndex(args)
+	if err != nil {
+		return true, err
+	}
+
+	issue, isUserError, err := p.getIssueByIndex(extra.UserId, listID, index)
+	if err != nil {
+		return isUserError, err
+	}
+	if issue.ForeignUser == "" {
+		return true, errors.New("only Todos shared with someone else can be muted")
+	}
+
+	muted, err := p.listManager.MuteIssue(extra.UserId, issue.ID)
+	if err != nil {
+		return false, err
+	}
+
+	responseMessage := fmt.Sprintf("Muted Todo: %s\n\nYou will not be notified when @%s changes it.", issue.Message, issue.ForeignUser)
+	if !muted {
+		responseMessage = fmt.Sprintf("Unmuted Todo: %s", issue.Message)
+	}
+	p.postCommandResponse(extra, responseMessage)
+
+	return false, nil
+}
+
+func (p *Plugin) runWaitingCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	if len(args) < 1 {
+		return true, errors.New("missing index")
+	}
+	if len(args) > 3 || (len(args) == 3 && args[2] != "notify") {
+		return true, errors.New("too many arguments")
+	}
+
+	issue, isUserError, err := p.getIssueByIndex(extra.UserId, MyListKey, args[0])
+	if err != nil {
+		return isUserError, err
+	}
+
+	if len(args) == 1 {
+		if _, err = p.listManager.SetWaitingOn(extra.UserId, issue.ID, ""); err != nil {
+			return false, err
+		}
+		p.sendRefreshEvent(extra.UserId, []string{MyListKey})
+		p.postCommandResponse(extra, fmt.Sprintf("Todo is no longer waiting on anyone: %s", issue.Message))
+		return false, nil
+	}
+
+	userName := strings.TrimPrefix(args[1], "@")
+	waitingOn, appErr := p.API.GetUserByUsername(userName)
+	if appErr != nil {
+		return true, fmt.Errorf("user `%s` not found", userName)
+	}
+
+	if _, err = p.listManager.SetWaitingOn(extra.UserId, issue.ID, waitingOn.Id); err != nil {
+		return false, err
+	}
+
+	p.sendRefreshEvent(extra.UserId, []string{MyListKey})
+
+	if len(args) == 3 && waitingOn.Id != extra.UserId {
+		senderName := p.listManager.GetUserName(extra.UserId)
+		p.PostBotDM(waitingOn.Id, fmt.Sprintf("@%s is waiting on your input for a Todo: %s", senderName, issue.Message))
+	}
+
+	p.postCommandResponse(extra, fmt.Sprintf("Todo is now waiting on @%s: %s", waitingOn.Username, issue.Message))
+	return false, nil
+}
+
+func (p *Plugin) runSomedayCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	return p.moveSomeday(args, extra, true)
+}
+
+func (p *Plugin) runActivateCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	return p.moveSomeday(args, extra, false)
+}
+
+func (p *Plugin) moveSomeday(args []string, extra *model.CommandArgs, someday bool) (bool, error) {
+	fromList, listName := MyListKey, "your list"
+	if !someday {
+		fromList, listName = SomedayListKey, "your someday list"
+	}
+
+	if len(args) != 1 {
+		return true, fmt.Errorf("you must specify the position of the Todo in %s", listName)
+	}
+
+	issue, isUserError, err := p.getIssueByIndex(extra.UserId, fromList, args[0])
+	if err != nil {
+		return isUserError, err
+	}
+
+	if issue.ForeignUser != "" {
+		return true, errors.New("only your own Todos can be put aside for some day")
+	}
+
+	if err = p.listManager.SetSomeday(extra.UserId, issue.ID, someday); err != nil {
+		return false, err
+	}
+
+	p.sendRefreshEvent(extra.UserId, []string{MyListKey, SomedayListKey})
+
+	if someday {
+		p.postCommandResponse(extra, fmt.Sprintf("Todo put aside for some day: %s", issue.Message))
+		return false, nil
+	}
+	p.postCommandResponse(extra, fmt.Sprintf("Todo moved back to your list: %s", issue.Message))
+	return false, nil
+}
+
+func (p *Plugin) runStatsCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	if len(args) > 0 {
+		return true, errors.New("stats does not take any arguments")
+	}
+
+	myIssues, err := p.listManager.GetIssueList(extra.UserId, MyListKey)
+	if err != nil {
+		return false, err
+	}
+	inIssues, err := p.listManager.GetIssueList(extra.UserId, InListKey)
+	if err != nil {
+		return false, err
+	}
+
+	myPoints, inPoints := totalPoints(myIssues), totalPoints(inIssues)
+	p.postCommandResponse(extra, fmt.Sprintf("Your Todos:\n* Your list: %d open, %d points\n* Incoming: %d open, %d points\n\nTotal: %d points",
+		len(myIssues), myPoints, len(inIssues), inPoints, myPoints+inPoints))
+	return false, nil
+}
+
+func (p *Plugin) runRelateCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	return p.relateIssues(args, extra, true)
+}
+
+func (p *Plugin) runUnrelateCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	return p.relateIssues(args, extra, false)
+}
+
+func (p *Plugin) relateIssues(args []string, extra *model.CommandArgs, related bool) (bool, error) {
+	if len(args) != 2 {
+		return true, errors.New("you must specify the positions of the two Todos in your list")
+	}
+
+	issue, isUserError, err := p.getIssueByIndex(extra.UserId, MyListKey, args[0])
+	if err != nil {
+		return isUserError, err
+	}
+
+	other, isUserError, err := p.getIssueByIndex(extra.UserId, MyListKey, args[1])
+	if err != nil {
+		return isUserError, err
+	}
+
+	if issue.ID == other.ID {
+		return true, errors.New("a Todo cannot be related to itself")
+	}
+
+	if err = p.listManager.RelateIssues(extra.UserId, issue.ID, other.ID, related); err != nil {
+		return false, err
+	}
+
+	if related {
+		p.postCommandResponse(extra, fmt.Sprintf("Todos are now related:\n* %s\n* %s", issue.Message, other.Message))
+		return false, nil
+	}
+	p.postCommandResponse(extra, fmt.Sprintf("Todos are no longer related:\n* %s\n* %s", issue.Message, other.Message))
+	return false, nil
+}
+
+func (p *Plugin) runNagCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	if len(args) != 2 {
+		return true, errors.New("you must specify the position of the Todo in your list and the interval")
+	}
+
+	issue, isUserError, err := p.getIssueByIndex(extra.UserId, MyListKey, args[0])
+	if err != nil {
+		return isUserError, err
+	}
+
+	var interval time.Duration
+	if args[1] != "off" {
+		interval, err = parseNagInterval(args[1])
+		if err != nil {
+			return true, err
+		}
+	}
+
+	if _, err = p.listManager.SetNagInterval(extra.UserId, issue.ID, int64(interval/time.Millisecond)); err != nil {
+		return false, err
+	}
+
+	if interval == 0 {
+		p.postCommandResponse(extra, fmt.Sprintf("You will no longer be reminded about: %s", issue.Message))
+		return false, nil
+	}
+
+	if err = p.addNag(extra.UserId, issue.ID); err != nil {
+		return false, err
+	}
+
+	p.postCommandResponse(extra, fmt.Sprintf("You will be reminded every %s about: %s", args[1], issue.Message))
+	return false, nil
+}
+
+func (p *Plugin) runResendCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	if len(args) != 1 {
+		return true, errors.New("you must specify the position of the Todo in your incoming list")
+	}
+
+	issue, isUserError, err := p.getIssueByIndex(extra.UserId, InListKey, args[0])
+	if err != nil {
+		return isUserError, err
+	}
+
+	message := "You have received a new Todo"
+	if issue.ForeignUser != "" {
+		message = fmt.Sprintf("You have received a new Todo from @%s", issue.ForeignUser)
+	}
+	p.PostBotCustomDM(extra.UserId, message, issue.Message, issue.ID)
+
+	p.postCommandResponse(extra, "The Todo has been sent to you again by the Todo bot.")
+	return false, nil
+}
+
+// extractTag removes the argument matching tagRegexp from args, if any, and returns it with the value
+// captured by tagRegexp. duplicateErr is returned if more than one argument matches.
+func extractTag(args []string, tagRegexp *regexp.Regexp, duplicateErr error) (rest []string, arg, value string, err error) {
+	rest = []string{}
+	for _, a := range args {
+		match := tagRegexp.FindStringSubmatch(a)
+		if match == nil {
+			rest = append(rest, a)
+			continue
+		}
+		if arg != "" {
+			return nil, "", "", duplicateErr
+		}
+		arg, value = a, match[1]
+	}
+
+	return rest, arg, value, nil
+}
+
+var channelTagRegexp = regexp.MustCompile(`^channel:~(\S+)$`)
+
+// extractChannelTag removes the channel:~channel-name argument from args, if any, and returns the
+// channel it refers to. The channel must exist on the current team and be accessible by the user.
+func (p *Plugin) extractChannelTag(args []string, extra *model.CommandArgs) ([]string, *model.Channel, error) {
+	rest, _, channelName, err := extractTag(args, channelTagRegexp, errors.New("a Todo can only be linked with one channel"))
+	if err != nil || channelName == "" {
+		return rest, nil, err
+	}
+
+	channel, appErr := p.API.GetChannelByName(extra.TeamId, channelName, false)
+	if appErr != nil {
+		return nil, nil, fmt.Errorf("channel `~%s` not found", channelName)
+	}
+
+	if channel.Type != model.CHANNEL_OPEN {
+		if _, appErr = p.API.GetChannelMember(channel.Id, extra.UserId); appErr != nil {
+			return nil, nil, fmt.Errorf("channel `~%s` not found", channelName)
+		}
+	}
+
+	return rest, channel, nil
+}
+
+// channelID returns the ID of channel, or empty if there is none.
+func channelID(channel *model.Channel) string {
+	if channel == nil {
+		return ""
+	}
+	return channel.Id
+}
+
+var pointsTagRegexp = regexp.MustCompile(`^points:(\S*)$`)
+
+// extractPointsTag removes the points:N argument from args, if any, and returns the points, 0 if none
+// were given. Points must be a non-negative whole number.
+func extractPointsTag(args []string) ([]string, int, error) {
+	rest, arg, value, err := extractTag(args, pointsTagRegexp, errors.New("a Todo can only have one points estimate"))
+	if err != nil || arg == "" {
+		return rest, 0, err
+	}
+
+	points, err := strconv.Atoi(value)
+	if err != nil || points < 0 {
+		return nil, 0, fmt.Errorf("`%s` is not a valid points estimate, use a whole number like points:3", arg)
+	}
+
+	return rest, points, nil
+}
+
+var roleTagRegexp = regexp.MustCompile(`^role:(\S*)$`)
+
+var roleRegexp = regexp.MustCompile(`^[a-z0-9_-]{1,30}$`)
+
+// extractRoleTag removes the role:name argument from args, if any, and returns the role in lower case,
+// or empty if none was given. Roles are made of letters, digits, dashes and underscores.
+func extractRoleTag(args []string) ([]string, string, error) {
+	rest, arg, value, err := extractTag(args, roleTagRegexp, errors.New("a Todo can only have one role"))
+	if err != nil || arg == "" {
+		return rest, "", err
+	}
+
+	role := strings.ToLower(value)
+	if !roleRegexp.MatchString(role) {
+		return nil, "", fmt.Errorf("`%s` is not a valid role, use up to 30 letters, digits, dashes or underscores like role:qa", arg)
+	}
+
+	return rest, role, nil
+}
+
+// extractPriorityToken removes a leading !high, !normal or !low argument from args, and returns the priority it sets
+// and whether there was one.
+func extractPriorityToken(args []string) ([]string, int, bool) {
+	if len(args) == 0 {
+		return args, PriorityNormal, false
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "!high":
+		return args[1:], PriorityHigh, true
+	case "!normal":
+		return args[1:], PriorityNormal, true
+	case "!low":
+		return args[1:], PriorityLow, true
+	}
+	return args, PriorityNormal, false
+}
+
+var trackTagRegexp = regexp.MustCompile(`^track:(\S*)$`)
+
+// noteTag starts the closing note of a completed Todo. Everything after it is part of the note.
+const noteTag = "note:"
+
+// extractNoteTag splits args at the first one starting with noteTag, and returns the arguments before it
+// and the note made of the rest.
+func extractNoteTag(args []string) ([]string, string) {
+	for i, arg := range args {
+		if strings.HasPrefix(arg, noteTag) {
+			note := strings.Join(append([]string{strings.TrimPrefix(arg, noteTag)}, args[i+1:]...), " ")
+			return args[:i], strings.TrimSpace(note)
+		}
+	}
+	return args, ""
+}
+
+// extractTrackTag removes the track:on or track:off argument from args, if any, and returns whether
+// the sent Todo should be tracked on the sender's outgoing list. It is tracked by default.
+func extractTrackTag(args []string) ([]string, bool, error) {
+	rest, arg, value, err := extractTag(args, trackTagRegexp, errors.New("track can only be given once"))
+	if err != nil || arg == "" {
+		return rest, true, err
+	}
+
+	switch value {
+	case "on":
+		return rest, true, nil
+	case "off":
+		return rest, false, nil
+	default:
+		return nil, false, fmt.Errorf("`%s` is not valid, use track:on or track:off", arg)
+	}
+}
+
+func (p *Plugin) runRedirectCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	if len(args) != 2 {
+		return true, errors.New("you must specify the position of the Todo in your outgoing list and the new receiver")
+	}
+
+	issue, isUserError, err := p.getIssueByIndex(extra.UserId, OutListKey, args[0])
+	if err != nil {
+		return isUserError, err
+	}
+
+	if issue.CompletedBy != "" {
+		return true, fmt.Errorf("@%s has already completed that Todo", issue.CompletedByUser)
+	}
+	if issue.ForeignList != InFlag {
+		return true, fmt.Errorf("@%s has already accepted that Todo", issue.ForeignUser)
+	}
+
+	userName := strings.TrimPrefix(args[1], "@")
+	receiver, appErr := p.API.GetUserByUsername(userName)
+	if appErr != nil {
+		return true, fmt.Errorf("user `%s` not found", userName)
+	}
+
+	if receiver.Id != extra.UserId {
+		if !p.getConfiguration().EnableSendToOthers {
+			p.postCommandResponse(extra, "Sending Todos to other users is disabled on this server. You can still add Todos to your own list.")
+			return false, nil
+		}
+
+		if !p.isReceiverInCommandChannel(receiver.Id, extra) {
+			p.postCommandResponse(extra, fmt.Sprintf("@%s is not a member of this channel. Todos can only be sent to members of the channel.", userName))
+			return false, nil
+		}
+
+		receiverAllowIncomingTaskRequestsPreference, prefErr := p.getAllowIncomingTaskRequestsPreference(receiver.Id)
+		if prefErr != nil {
+			p.API.LogError("Error when getting allow incoming task request preference, err=", prefErr)
+			receiverAllowIncomingTaskRequestsPreference = true
+		}
+		if !receiverAllowIncomingTaskRequestsPreference {
+			p.postCommandResponse(extra, fmt.Sprintf("@%s has blocked Todo requests", userName))
+			return false, nil
+		}
+	}
+
+	issueMessage, receiverIssueID, oldOwner, err := p.listManager.ChangeAssignment(issue.ID, extra.UserId, receiver.Id, true)
+	if err != nil {
+		return false, err
+	}
+
+	p.trackChangeAssignment(extra.UserId)
+
+	p.notifyAssignmentChanged(extra.UserId, receiver.Id, receiverIssueID, oldOwner, issueMessage)
+
+	if receiver.Id == extra.UserId {
+		p.postCommandResponse(extra, "Todo moved back to your list.")
+		return false, nil
+	}
+	p.postCommandResponse(extra, fmt.Sprintf("Todo redirected to @%s.", receiver.Username))
+	return false, nil
+}
+
+func (p *Plugin) runCancelCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	if len(args) != 1 {
+		return true, errors.New("you must specify the position of the Todo in your outgoing list")
+	}
+
+	issue, isUserError, err := p.getIssueByIndex(extra.UserId, OutListKey, args[0])
+	if err != nil {
+		return isUserError, err
+	}
+
+	if issue.CompletedBy != "" {
+		return true, fmt.Errorf("@%s has already completed that Todo", issue.CompletedByUser)
+	}
+	if issue.ForeignList != InFlag {
+		return true, fmt.Errorf("@%s has already accepted that Todo", issue.ForeignUser)
+	}
+
+	foreignMuted := p.listManager.IsMutedByForeignUser(extra.UserId, issue.ID)
+	_, foreignID, _, listToUpdate, err := p.listManager.RemoveIssue(extra.UserId, issue.ID)
+	if err != nil {
+		return false, err
+	}
+
+	p.trackRemoveIssue(extra.UserId)
+
+	p.sendRefreshEvent(extra.UserId, []string{listToUpdate})
+
+	userName := p.listManager.GetUserName(extra.UserId)
+	replyMessage := fmt.Sprintf("@%s removed a todo attached to this thread", userName)
+	p.postReplyIfNeeded(issue.PostID, replyMessage, issue.Message, threadReplyRemove)
+
+	if foreignID != "" {
+		p.sendRefreshEvent(foreignID, []string{InListKey})
+		if !foreignMuted {
+			p.PostBotDM(foreignID, fmt.Sprintf("@%s withdrew a Todo they sent you: %s", userName, issue.Message))
+		}
+	}
+
+	p.postCommandResponse(extra, fmt.Sprintf("Todo withdrawn: %s", issue.Message))
+	return false, nil
+}
+
+func (p *Plugin) runAcceptFromCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	if len(args) != 1 {
+		return true, errors.New("you must specify exactly one user")
+	}
+
+	userName := strings.TrimPrefix(args[0], "@")
+	sender, appErr := p.API.GetUserByUsername(userName)
+	if appErr != nil {
+		return true, fmt.Errorf("user `%s` not found", userName)
+	}
+
+	todoMessages, unmutedMessages, err := p.listManager.AcceptIssuesFrom(extra.UserId, sender.Id)
+	if err != nil {
+		return false, err
+	}
+
+	if len(todoMessages) == 0 {
+		p.postCommandResponse(extra, fmt.Sprintf("There are no Todos from @%s to accept.", sender.Username))
+		return false, nil
+	}
+
+	for range todoMessages {
+		p.trackAcceptIssue(extra.UserId)
+	}
+
+	p.sendRefreshEvent(extra.UserId, []string{MyListKey, InListKey})
+	p.sendRefreshEvent(sender.Id, []string{OutListKey})
+
+	// The sender is not told about the Todos they muted
+	if len(unmutedMessages) > 0 {
+		p.PostBotDM(sender.Id, acceptedTodosMessage(p.listManager.GetUserName(extra.UserId), unmutedMessages))
+	}
+
+	if len(todoMessages) == 1 {
+		p.postCommandResponse(extra, fmt.Sprintf("Accepted a Todo from @%s: %s", sender.Username, todoMessages[0]))
+		return false, nil
+	}
+
+	summary := ""
+	for _, todoMessage := range todoMessages {
+		summary += "\n* " + todoMessage
+	}
+	p.postCommandResponse(extra, fmt.Sprintf("Accepted %d Todos from @%s:%s", len(todoMessages), sender.Username, summary))
+
+	return false, nil
+}
+
+// parseListAndIndex parses the `[listName] [index]` arguments used by the commands acting on
+// a single issue of a list. The list defaults to my list when only the index is given.
+func parseListAndIndex(args []string) (listID string, index string, err error) {
+	switch len(args) {
+	case 1:
+		return MyListKey, args[0], nil
+	case 2:
+		switch args[0] {
+		case MyFlag:
+			listID = MyListKey
+		case InFlag:
+			listID = InListKey
+		case OutFlag:
+			listID = OutListKey
+		case SomedayFlag:
+			listID = SomedayListKey
+		default:
+			return "", "", fmt.Errorf("list `%s` not recognized", args[0])
+		}
+		return listID, args[1], nil
+	case 0:
+		return "", "", errors.New("missing index")
+	default:
+		return "", "", errors.New("too many arguments")
+	}
+}
+
+// parseListAndQuotedMessage parses args like `in "water the plants"`, where the list is optional and my by default,
+// and returns false if the message is not between double quotes.
+func parseListAndQuotedMessage(args []string) (listID string, message string, ok bool) {
+	text := strings.TrimSpace(strings.Join(args, " "))
+	listID = MyListKey
+	for flag, flagListID := range map[string]string{MyFlag: MyListKey, InFlag: InListKey, OutFlag: OutListKey, SomedayFlag: SomedayListKey} {
+		if strings.HasPrefix(text, flag+" ") {
+			listID = flagListID
+			text = strings.TrimSpace(strings.TrimPrefix(text, flag+" "))
+			break
+		}
+	}
+
+	if len(text) < 2 || !strings.HasPrefix(text, `"`) || !strings.HasSuffix(text, `"`) {
+		return "", "", false
+	}
+	return listID, strings.TrimSpace(text[1 : len(text)-1]), true
+}
+
+// getIssueByMessage gets the only issue of the list listID of userID whose whole message is message, ignoring case,
+// and whether any error is caused by the user input. The error of an ambiguous message lists the matching issues
+// with their positions.
+func (p *Plugin) getIssueByMessage(userID, listID, message string) (*ExtendedIssue, bool, error) {
+	if message == "" {
+		return nil, true, errors.New("missing message")
+	}
+
+	issues, err := p.listManager.GetIssueList(userID, listID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	matches := filterIssueGroup(issues, "", func(issue *ExtendedIssue) bool {
+		return strings.EqualFold(strings.TrimSpace(issue.Message), message)
+	})
+	if matches == nil {
+		return nil, true, fmt.Errorf("there is no Todo matching `%s`", message)
+	}
+	if len(matches.Issues) == 1 {
+		return matches.Issues[0], false, nil
+	}
+
+	candidates := []string{}
+	for i, issue := range matches.Issues {
+		candidates = append(candidates, fmt.Sprintf("%d. %s", matches.Positions[i], issue.Message))
+	}
+	return nil, true, fmt.Errorf("`%s` matches %d Todos, use the position of one of them instead: %s", message, len(matches.Issues), strings.Join(candidates, ", "))
+}
+
+// getIssueByIndex gets the issue at the 1-based position index of the list listID of userID,
+// as numbered by issuesListToString, and whether any error is caused by the user input.
+func (p *Plugin) getIssueByIndex(userID, listID, index string) (*ExtendedIssue, bool, error) {
+	n, err := strconv.Atoi(index)
+	if err != nil {
+		return nil, true, fmt.Errorf("`%s` is not a valid index", index)
+	}
+
+	issues, err := p.listManager.GetIssueList(userID, listID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if n < 1 || n > len(issues) {
+		return nil, true, fmt.Errorf("there is no Todo at position %d", n)
+	}
+
+	return issues[n-1], false, nil
+}
+
+func (p *Plugin) runSettingsCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	const (
+		on     = "on"
+		off    = "off"
+		top    = "top"
+		bottom = "bottom"
+		keep   = "keep"
+		remove = "remove"
+		list   = "list"
+		inbox  = "inbox"
+		dm     = "dm"
+		here   = "here"
+		urgent = "urgent"
+	)
+	if len(args) < 1 {
+		p.postCommandResponse(extra, p.getCurrentSettings(extra.UserId))
 		return false, nil
 	}
 
 	switch args[0] {
 	case "summary":
 		if len(args) < 2 {
-			currentSummarySetting := p.getReminderPreference(extra.UserId)
-			p.postCommandResponse(extra, getSummarySetting(currentSummarySetting))
+			currentSummarySetting := p.getReminderPreference(extra.UserId)
+			p.postCommandResponse(extra, getSummarySetting(currentSummarySetting))
+			return false, nil
+		}
+		if args[1] == "snooze" {
+			return p.runSnoozeSummaryCommand(args[2:], extra)
+		}
+		if len(args) > 2 {
+			return true, errors.New("too many arguments")
+		}
+		var responseMessage string
+		var err error
+
+		switch args[1] {
+		case on:
+			err = p.saveReminderPreference(extra.UserId, true)
+			if err == nil {
+				// Turning the reminders on also ends any snooze
+				err = p.saveReminderSnoozedUntil(extra.UserId, 0)
+			}
+			responseMessage = "You will start receiving daily summaries."
+		case off:
+			err = p.saveReminderPreference(extra.UserId, false)
+			responseMessage = "You will stop receiving daily summaries."
+		default:
+			responseMessage = "invalid input, allowed values for \"settings summary\" are `on` or `off`"
+			return true, errors.New(responseMessage)
+		}
+
+		if err != nil {
+			responseMessage = "error saving the reminder preference"
+			p.API.LogDebug("runSettingsCommand: error saving the reminder preference", "error", err.Error())
+			return false, errors.New(responseMessage)
+		}
+
+		p.postCommandResponse(extra, responseMessage)
+
+	case "summary_descriptions":
+		if len(args) < 2 {
+			p.postCommandResponse(extra, getSummaryDescriptionsSetting(p.getReminderDescriptionsPreference(extra.UserId)))
+			return false, nil
+		}
+		if len(args) > 2 {
+			return true, errors.New("too many arguments")
+		}
+		var responseMessage string
+		var err error
+
+		switch args[1] {
+		case on:
+			err = p.saveReminderDescriptionsPreference(extra.UserId, true)
+			responseMessage = "Daily reminders will include the description of each Todo."
+		case off:
+			err = p.saveReminderDescriptionsPreference(extra.UserId, false)
+			responseMessage = "Daily reminders will only include the Todo messages."
+		default:
+			responseMessage = "invalid input, allowed values for \"settings summary_descriptions\" are `on` or `off`"
+			return true, errors.New(responseMessage)
+		}
+
+		if err != nil {
+			responseMessage = "error saving the summary_descriptions preference"
+			p.API.LogDebug("runSettingsCommand: error saving the summary_descriptions preference", "error", err.Error())
+			return false, errors.New(responseMessage)
+		}
+
+		p.postCommandResponse(extra, responseMessage)
+
+	case "summary_header":
+		if len(args) < 2 {
+			p.postCommandResponse(extra, getSummaryHeaderSetting(p.getReminderHeaderPreference(extra.UserId)))
+			return false, nil
+		}
+
+		header := strings.TrimSpace(strings.Join(args[1:], " "))
+		if len(args) == 2 && args[1] == "default" {
+			header = ""
+		}
+		if len(header) > MaxReminderHeaderLength {
+			return true, fmt.Errorf("the summary header cannot be longer than %d characters", MaxReminderHeaderLength)
+		}
+
+		if err := p.saveReminderHeaderPreference(extra.UserId, header); err != nil {
+			p.API.LogDebug("runSettingsCommand: error saving the summary_header preference", "error", err.Error())
+			return false, errors.New("error saving the summary_header preference")
+		}
+
+		p.postCommandResponse(extra, "Daily reminders will start with \""+p.getReminderHeaderPreference(extra.UserId)+"\"")
+
+	case "allow_incoming_task_requests":
+		if len(args) < 2 {
+			currentAllowIncomingTaskRequestsSetting, err := p.getAllowIncomingTaskRequestsPreference(extra.UserId)
+			if err != nil {
+				p.API.LogError("unable to parse the allow incoming task requests preference, err=", err.Error())
+				currentAllowIncomingTaskRequestsSetting = true
+			}
+			p.postCommandResponse(extra, getAllowIncomingTaskRequestsSetting(currentAllowIncomingTaskRequestsSetting))
+			return false, nil
+		}
+		if len(args) > 2 {
+			return true, errors.New("too many arguments")
+		}
+		var responseMessage string
+		var err error
+
+		switch args[1] {
+		case on:
+			err = p.saveAllowIncomingTaskRequestsPreference(extra.UserId, true)
+			responseMessage = "Other users can send task for you to accept/decline"
+		case off:
+			err = p.saveAllowIncomingTaskRequestsPreference(extra.UserId, false)
+			responseMessage = "Other users cannot send you task request. They will see a message saying you have blocked incoming task requests"
+		default:
+			responseMessage = "invalid input, allowed values for \"settings allow_incoming_task_requests\" are `on` or `off`"
+			return true, errors.New(responseMessage)
+		}
+
+		if err != nil {
+			responseMessage = "error saving the block_incoming preference"
+			p.API.LogDebug("runSettingsCommand: error saving the block_incoming preference", "error", err.Error())
+			return false, errors.New(responseMessage)
+		}
+
+		p.postCommandResponse(extra, responseMessage)
+
+	case "inbox_order":
+		if len(args) < 2 {
+			p.postCommandResponse(extra, getInboxOrderSetting(getReceivedOnTopPreference(p.API, extra.UserId)))
+			return false, nil
+		}
+		if len(args) > 2 {
+			return true, errors.New("too many arguments")
+		}
+		var responseMessage string
+		var err error
+
+		switch args[1] {
+		case top:
+			err = p.saveReceivedOnTopPreference(extra.UserId, true)
+			responseMessage = "New received Todos will be placed at the top of your incoming list."
+		case bottom:
+			err = p.saveReceivedOnTopPreference(extra.UserId, false)
+			responseMessage = "New received Todos will be placed at the bottom of your incoming list."
+		default:
+			responseMessage = "invalid input, allowed values for \"settings inbox_order\" are `top` or `bottom`"
+			return true, errors.New(responseMessage)
+		}
+
+		if err != nil {
+			responseMessage = "error saving the inbox_order preference"
+			p.API.LogDebug("runSettingsCommand: error saving the inbox_order preference", "error", err.Error())
+			return false, errors.New(responseMessage)
+		}
+
+		p.postCommandResponse(extra, responseMessage)
+
+	case "team_default":
+		return p.runTeamDefaultSettingCommand(args[1:], extra)
+
+	case "completed_sent":
+		if len(args) < 2 {
+			p.postCommandResponse(extra, getCompletedSentSetting(getKeepCompletedSentPreference(p.API, extra.UserId)))
+			return false, nil
+		}
+		if len(args) > 2 {
+			return true, errors.New("too many arguments")
+		}
+		var responseMessage string
+		var err error
+
+		switch args[1] {
+		case keep:
+			err = p.saveKeepCompletedSentPreference(extra.UserId, true)
+			responseMessage = "Todos you sent will stay on your outgoing list, marked as completed, when their receivers complete them."
+		case remove:
+			err = p.saveKeepCompletedSentPreference(extra.UserId, false)
+			responseMessage = "Todos you sent will be removed from your outgoing list when their receivers complete them."
+		default:
+			responseMessage = "invalid input, allowed values for \"settings completed_sent\" are `keep` or `remove`"
+			return true, errors.New(responseMessage)
+		}
+
+		if err != nil {
+			responseMessage = "error saving the completed_sent preference"
+			p.API.LogDebug("runSettingsCommand: error saving the completed_sent preference", "error", err.Error())
+			return false, errors.New(responseMessage)
+		}
+
+		p.postCommandResponse(extra, responseMessage)
+
+	case "self_send":
+		if len(args) < 2 {
+			p.postCommandResponse(extra, getSelfSendSetting(p.getSelfSendToInboxPreference(extra.UserId)))
+			return false, nil
+		}
+		if len(args) > 2 {
+			return true, errors.New("too many arguments")
+		}
+		var responseMessage string
+		var err error
+
+		switch args[1] {
+		case list:
+			err = p.saveSelfSendToInboxPreference(extra.UserId, false)
+			responseMessage = "Todos you send to yourself will be added to your list."
+		case inbox:
+			err = p.saveSelfSendToInboxPreference(extra.UserId, true)
+			responseMessage = "Todos you send to yourself will be placed on your incoming list to accept or decline."
+		default:
+			responseMessage = "invalid input, allowed values for \"settings self_send\" are `list` or `inbox`"
+			return true, errors.New(responseMessage)
+		}
+
+		if err != nil {
+			responseMessage = "error saving the self_send preference"
+			p.API.LogDebug("runSettingsCommand: error saving the self_send preference", "error", err.Error())
+			return false, errors.New(responseMessage)
+		}
+
+		p.postCommandResponse(extra, responseMessage)
+
+	case "pop_order":
+		if len(args) < 2 {
+			p.postCommandResponse(extra, getPopOrderSetting(p.getPopUrgentPreference(extra.UserId)))
+			return false, nil
+		}
+		if len(args) > 2 {
+			return true, errors.New("too many arguments")
+		}
+		var responseMessage string
+		var err error
+
+		switch args[1] {
+		case top:
+			err = p.savePopUrgentPreference(extra.UserId, false)
+			responseMessage = "Pop will remove the Todo at the top of your list."
+		case urgent:
+			err = p.savePopUrgentPreference(extra.UserId, true)
+			responseMessage = "Pop will remove the Todo of your list due the soonest, or the top one if none is due."
+		default:
+			responseMessage = "invalid input, allowed values for \"settings pop_order\" are `top` or `urgent`"
+			return true, errors.New(responseMessage)
+		}
+
+		if err != nil {
+			responseMessage = "error saving the pop_order preference"
+			p.API.LogDebug("runSettingsCommand: error saving the pop_order preference", "error", err.Error())
+			return false, errors.New(responseMessage)
+		}
+
+		p.postCommandResponse(extra, responseMessage)
+
+	case "share_counts":
+		if len(args) < 2 {
+			p.postCommandResponse(extra, getShareCountsSetting(p.getShareCountsPreference(extra.UserId)))
 			return false, nil
 		}
 		if len(args) > 2 {
@@ -358,32 +1995,111 @@ func (p *Plugin) runSettingsCommand(args []string, extra *model.CommandArgs) (bo
 
 		switch args[1] {
 		case on:
-			err = p.saveReminderPreference(extra.UserId, true)
-			responseMessage = "You will start receiving daily summaries."
+			err = p.saveShareCountsPreference(extra.UserId, true)
+			responseMessage = "Integrations of the channels you are in will be able to see how many Todos are on your list."
 		case off:
-			err = p.saveReminderPreference(extra.UserId, false)
-			responseMessage = "You will stop receiving daily summaries."
+			err = p.saveShareCountsPreference(extra.UserId, false)
+			responseMessage = "How many Todos are on your list will be kept to yourself."
 		default:
-			responseMessage = "invalid input, allowed values for \"settings summary\" are `on` or `off`"
+			responseMessage = "invalid input, allowed values for \"settings share_counts\" are `on` or `off`"
 			return true, errors.New(responseMessage)
 		}
 
 		if err != nil {
-			responseMessage = "error saving the reminder preference"
-			p.API.LogDebug("runSettingsCommand: error saving the reminder preference", "error", err.Error())
+			responseMessage = "error saving the share_counts preference"
+			p.API.LogDebug("runSettingsCommand: error saving the share_counts preference", "error", err.Error())
 			return false, errors.New(responseMessage)
 		}
 
 		p.postCommandResponse(extra, responseMessage)
 
-	case "allow_incoming_task_requests":
+	case "default_priority":
 		if len(args) < 2 {
-			currentAllowIncomingTaskRequestsSetting, err := p.getAllowIncomingTaskRequestsPreference(extra.UserId)
+			p.postCommandResponse(extra, getDefaultPrioritySetting(getDefaultPriorityPreference(p.API, extra.UserId)))
+			return false, nil
+		}
+		if len(args) > 2 {
+			return true, errors.New("too many arguments")
+		}
+
+		priority, err := parsePriority(args[1])
+		if err != nil {
+			return true, errors.New("invalid input, allowed values for \"settings default_priority\" are `high`, `normal` or `low`")
+		}
+
+		if err := p.saveDefaultPriorityPreference(extra.UserId, priority); err != nil {
+			p.API.LogDebug("runSettingsCommand: error saving the default_priority preference", "error", err.Error())
+			return false, errors.New("error saving the default_priority preference")
+		}
+
+		p.postCommandResponse(extra, fmt.Sprintf("The Todos you add without choosing a priority will be %s priority.", priorityName(priority)))
+
+	case "priority_emoji":
+		if len(args) < 2 {
+			p.postCommandResponse(extra, getPriorityEmojiSetting(getPriorityEmojiPreference(p.API, extra.UserId)))
+			return false, nil
+		}
+		if len(args) != 3 {
+			return true, errors.New("you must specify a priority and its emoji")
+		}
+
+		priority, err := parsePriority(args[1])
+		if err != nil {
+			return true, err
+		}
+		name := priorityName(priority)
+
+		emoji := getChosenPriorityEmoji(p.API, extra.UserId)
+		switch {
+		case args[2] == "default":
+			delete(emoji, name)
+		case args[2] == "none":
+			emoji[name] = ""
+		case priorityEmojiRegexp.MatchString(args[2]):
+			emoji[name] = args[2]
+		default:
+			return true, errors.New("invalid input, the emoji must be like `:red_circle:`, or `none` or `default`")
+		}
+
+		if err := p.savePriorityEmojiPreference(extra.UserId, emoji); err != nil {
+			p.API.LogDebug("runSettingsCommand: error saving the priority_emoji preference", "error", err.Error())
+			return false, errors.New("error saving the priority_emoji preference")
+		}
+
+		p.postCommandResponse(extra, getPriorityEmojiSetting(getPriorityEmojiPreference(p.API, extra.UserId)))
+
+	case "triage_reminder":
+		if len(args) < 2 {
+			p.postCommandResponse(extra, getTriageReminderSetting(p.getTriageReminderInterval(extra.UserId)))
+			return false, nil
+		}
+		if len(args) > 2 {
+			return true, errors.New("too many arguments")
+		}
+
+		var interval time.Duration
+		if args[1] != off {
+			var err error
+			interval, err = parseNagInterval(args[1])
 			if err != nil {
-				p.API.LogError("unable to parse the allow incoming task requests preference, err=", err.Error())
-				currentAllowIncomingTaskRequestsSetting = true
+				return true, err
 			}
-			p.postCommandResponse(extra, getAllowIncomingTaskRequestsSetting(currentAllowIncomingTaskRequestsSetting))
+		}
+
+		if err := p.setTriageReminder(extra.UserId, int64(interval/time.Millisecond), model.GetMillis()); err != nil {
+			p.API.LogDebug("runSettingsCommand: error saving the triage_reminder preference", "error", err.Error())
+			return false, errors.New("error saving the triage_reminder preference")
+		}
+
+		responseMessage := "You will no longer be reminded about the received Todos you have not accepted or declined."
+		if interval > 0 {
+			responseMessage = fmt.Sprintf("You will be reminded every %s about the received Todos you have not accepted or declined.", formatNagInterval(interval))
+		}
+		p.postCommandResponse(extra, responseMessage)
+
+	case "notifications":
+		if len(args) < 2 {
+			p.postCommandResponse(extra, getNotificationsSetting(p.getNotificationChannelName(extra.UserId)))
 			return false, nil
 		}
 		if len(args) > 2 {
@@ -393,64 +2109,506 @@ func (p *Plugin) runSettingsCommand(args []string, extra *model.CommandArgs) (bo
 		var err error
 
 		switch args[1] {
-		case on:
-			err = p.saveAllowIncomingTaskRequestsPreference(extra.UserId, true)
-			responseMessage = "Other users can send task for you to accept/decline"
-		case off:
-			err = p.saveAllowIncomingTaskRequestsPreference(extra.UserId, false)
-			responseMessage = "Other users cannot send you task request. They will see a message saying you have blocked incoming task requests"
+		case dm:
+			err = p.saveNotificationChannelPreference(extra.UserId, "")
+			responseMessage = "Todo notifications will be sent to you as a direct message."
+		case here:
+			channel, appErr := p.API.GetChannel(extra.ChannelId)
+			if appErr != nil {
+				return false, appErr
+			}
+			if channel.Type != model.CHANNEL_OPEN && channel.Type != model.CHANNEL_PRIVATE {
+				return true, errors.New("notifications can only be posted in a public or private channel, run it there or use `dm`")
+			}
+			if !p.isOnlyChannelMember(channel.Id, extra.UserId) {
+				return true, errors.New("notifications can only be posted in a channel you are the only member of, since they tell what your Todos are")
+			}
+			err = p.saveNotificationChannelPreference(extra.UserId, channel.Id)
+			responseMessage = fmt.Sprintf("Todo notifications will be posted in ~%s. Daily reminders will still be sent as a direct message.", channel.Name)
 		default:
-			responseMessage = "invalid input, allowed values for \"settings allow_incoming_task_requests\" are `on` or `off`"
+			responseMessage = "invalid input, allowed values for \"settings notifications\" are `dm` or `here`"
 			return true, errors.New(responseMessage)
 		}
 
 		if err != nil {
-			responseMessage = "error saving the block_incoming preference"
-			p.API.LogDebug("runSettingsCommand: error saving the block_incoming preference", "error", err.Error())
+			responseMessage = "error saving the notifications preference"
+			p.API.LogDebug("runSettingsCommand: error saving the notifications preference", "error", err.Error())
 			return false, errors.New(responseMessage)
 		}
 
 		p.postCommandResponse(extra, responseMessage)
+
+	case "reset":
+		if len(args) == 1 {
+			p.postCommandResponse(extra, "This will set all your settings back to their defaults. Run `/todo settings reset confirm` to go ahead.")
+			return false, nil
+		}
+		if len(args) > 2 || args[1] != "confirm" {
+			return true, errors.New("invalid input, run `/todo settings reset confirm` to reset your settings")
+		}
+
+		if err := p.resetPreferences(extra.UserId); err != nil {
+			p.API.LogDebug("runSettingsCommand: error resetting the preferences", "error", err.Error())
+			return false, errors.New("error resetting the preferences")
+		}
+
+		p.postCommandResponse(extra, "Your settings were reset to their defaults.\n\n"+p.getCurrentSettings(extra.UserId))
 	default:
 		return true, fmt.Errorf("setting `%s` not recognized", args[0])
 	}
 	return false, nil
 }
 
+// getCurrentSettings describes all the settings of userID as they currently apply
+func (p *Plugin) getCurrentSettings(userID string) string {
+	currentSummarySetting := p.getReminderPreference(userID)
+	currentAllowIncomingTaskRequestsSetting, err := p.getAllowIncomingTaskRequestsPreference(userID)
+	if err != nil {
+		p.API.LogError("Error when getting allow incoming task request preference, err=", err)
+		currentAllowIncomingTaskRequestsSetting = true
+	}
+	currentSummaryDescriptionsSetting := p.getReminderDescriptionsPreference(userID)
+	currentReceivedOnTopSetting := getReceivedOnTopPreference(p.API, userID)
+	currentKeepCompletedSentSetting := getKeepCompletedSentPreference(p.API, userID)
+	currentSelfSendToInboxSetting := p.getSelfSendToInboxPreference(userID)
+	currentPopUrgentSetting := p.getPopUrgentPreference(userID)
+	currentShareCountsSetting := p.getShareCountsPreference(userID)
+	currentTriageReminderInterval := p.getTriageReminderInterval(userID)
+	currentSummaryHeader := p.getReminderHeaderPreference(userID)
+	currentNotificationChannelName := p.getNotificationChannelName(userID)
+	currentDefaultPriority := getDefaultPriorityPreference(p.API, userID)
+	currentPriorityEmoji := getPriorityEmojiPreference(p.API, userID)
+	return getAllSettings(currentSummarySetting, currentSummaryDescriptionsSetting, currentAllowIncomingTaskRequestsSetting, currentReceivedOnTopSetting, currentKeepCompletedSentSetting, currentSelfSendToInboxSetting, currentPopUrgentSetting, currentShareCountsSetting, currentTriageReminderInterval, currentSummaryHeader, currentNotificationChannelName, currentDefaultPriority, currentPriorityEmoji)
+}
+
+// getNotificationChannelName returns the name of the channel userID receives their notifications in, or empty for the DM
+func (p *Plugin) getNotificationChannelName(userID string) string {
+	channelID := p.getNotificationChannelPreference(userID)
+	if channelID == "" {
+		return ""
+	}
+
+	channel, appErr := p.API.GetChannel(channelID)
+	if appErr != nil {
+		p.API.LogError("unable to get the notification channel, err=", appErr.Error())
+		return ""
+	}
+	return channel.Name
+}
+
+func getTeamDefaultsSetting(defaults *teamDefaults) string {
+	describe := func(value *bool) string {
+		if value == nil {
+			return "not set"
+		}
+		if *value {
+			return "`on`"
+		}
+		return "`off`"
+	}
+	return fmt.Sprintf("Team defaults for members who did not choose their own settings:\n* summary: %s\n* allow_incoming_task_requests: %s",
+		describe(defaults.Reminder), describe(defaults.AllowIncomingTaskRequests))
+}
+
+func (p *Plugin) runTeamDefaultSettingCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	if !p.API.HasPermissionToTeam(extra.UserId, extra.TeamId, model.PERMISSION_MANAGE_TEAM) {
+		return true, errors.New("only team admins can change the team defaults")
+	}
+
+	defaults, err := p.getTeamDefaults(extra.TeamId)
+	if err != nil {
+		return false, err
+	}
+
+	if len(args) == 0 {
+		p.postCommandResponse(extra, getTeamDefaultsSetting(defaults))
+		return false, nil
+	}
+	if len(args) != 2 {
+		return true, errors.New("you must specify the setting and whether it is `on`, `off` or `unset`")
+	}
+
+	var value *bool
+	switch args[1] {
+	case "on":
+		value = model.NewBool(true)
+	case "off":
+		value = model.NewBool(false)
+	case "unset":
+	default:
+		return true, errors.New("invalid input, allowed values for \"settings team_default\" are `on`, `off` or `unset`")
+	}
+
+	switch args[0] {
+	case "summary":
+		defaults.Reminder = value
+	case "allow_incoming_task_requests":
+		defaults.AllowIncomingTaskRequests = value
+	default:
+		return true, fmt.Errorf("setting `%s` has no team default", args[0])
+	}
+
+	if err = p.saveTeamDefaults(extra.TeamId, defaults); err != nil {
+		return false, err
+	}
+
+	p.postCommandResponse(extra, getTeamDefaultsSetting(defaults))
+	return false, nil
+}
+
+// MaxReminderSnoozeDays is the maximum number of days the daily reminder can be snoozed for
+const MaxReminderSnoozeDays = 365
+
+func (p *Plugin) runSnoozeSummaryCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	if len(args) != 1 {
+		return true, errors.New("you must specify the number of days to snooze the daily reminder for")
+	}
+
+	days, err := strconv.Atoi(args[0])
+	if err != nil || days < 1 || days > MaxReminderSnoozeDays {
+		return true, fmt.Errorf("the number of days must be between 1 and %d", MaxReminderSnoozeDays)
+	}
+
+	snoozedUntil := model.GetMillis() + int64(days)*int64(24*time.Hour/time.Millisecond)
+	if err := p.saveReminderSnoozedUntil(extra.UserId, snoozedUntil); err != nil {
+		p.API.LogDebug("runSnoozeSummaryCommand: error saving the reminder snooze", "error", err.Error())
+		return false, errors.New("error saving the reminder snooze")
+	}
+
+	p.postCommandResponse(extra, fmt.Sprintf("Daily reminders are snoozed for %d days. They will resume automatically afterwards.", days))
+	return false, nil
+}
+
+// todoCommand is a /todo subcommand, with the handler running it and the autocomplete data suggesting it
+type todoCommand struct {
+	name         string
+	handler      func(p *Plugin, args []string, extra *model.CommandArgs) (bool, error)
+	autocomplete func() *model.AutocompleteData
+}
+
+// todoCommands are all the /todo subcommands, in the order they are suggested. The commands without a handler
+// show the help.
+var todoCommands = []*todoCommand{
+	{
+		name:    "add",
+		handler: (*Plugin).runAddCommand,
+		autocomplete: func() *model.AutocompleteData {
+			add := model.NewAutocompleteData("add", "[message]", "Adds a Todo")
+			add.AddTextArgument("E.g. be awesome", "[message]", "")
+			return add
+		},
+	},
+	{
+		name:    "list",
+		handler: (*Plugin).runListCommand,
+		autocomplete: func() *model.AutocompleteData {
+			list := model.NewAutocompleteData("list", "[name]", "Lists your Todo issues")
+			items := []model.AutocompleteListItem{{
+				HelpText: "Received Todos",
+				Hint:     "(optional)",
+				Item:     "in",
+			}, {
+				HelpText: "Sent Todos",
+				Hint:     "(optional)",
+				Item:     "out",
+			}, {
+				HelpText: "Starred Todos",
+				Hint:     "(optional)",
+				Item:     "starred",
+			}, {
+				HelpText: "Todos past their due date",
+				Hint:     "(optional)",
+				Item:     "overdue",
+			}, {
+				HelpText: "Todos put aside for some day",
+				Hint:     "(optional)",
+				Item:     "someday",
+			}, {
+				HelpText: "Your Todos grouped by channel",
+				Hint:     "(optional)",
+				Item:     "by-channel",
+			}}
+			list.AddStaticListArgument("Lists your Todo issues", false, items)
+			return list
+		},
+	},
+	{
+		name:    "pop",
+		handler: (*Plugin).runPopCommand,
+		autocomplete: func() *model.AutocompleteData {
+			return model.NewAutocompleteData("pop", "", "Removes the Todo issue at the top of the list")
+		},
+	},
+	{
+		name:    "show",
+		handler: (*Plugin).runShowCommand,
+		autocomplete: func() *model.AutocompleteData {
+			show := model.NewAutocompleteData("show", "[list] [index]", "Shows the details of a Todo")
+			show.AddTextArgument("Position of the Todo, optionally preceded by the list (my, in, out)", "[list] [index]", "")
+			return show
+		},
+	},
+	{
+		name:    "star",
+		handler: (*Plugin).runStarCommand,
+		autocomplete: func() *model.AutocompleteData {
+			star := model.NewAutocompleteData("star", "[list] [index]", "Stars or unstars a Todo")
+			star.AddTextArgument("Position of the Todo, optionally preceded by the list (my, in, out)", "[list] [index]", "")
+			return star
+		},
+	},
+	{
+		name:    "complete",
+		handler: (*Plugin).runCompleteCommand,
+		autocomplete: func() *model.AutocompleteData {
+			complete := model.NewAutocompleteData("complete", "[list] [index] [note: text]", "Completes a Todo, optionally with a note for its thread")
+			complete.AddTextArgument("Position of the Todo, optionally preceded by the list (my, in), and followed by note: and the note", "[list] [index] [note: text]", "")
+			return complete
+		},
+	},
+	{
+		name:    "mute",
+		handler: (*Plugin).runMuteCommand,
+		autocomplete: func() *model.AutocompleteData {
+			mute := model.NewAutocompleteData("mute", "[list] [index]", "Mutes or unmutes the messages about a shared Todo")
+			mute.AddTextArgument("Position of the Todo, optionally preceded by the list (my, in, out)", "[list] [index]", "")
+			return mute
+		},
+	},
+	{
+		name:    "waiting",
+		handler: (*Plugin).runWaitingCommand,
+		autocomplete: func() *model.AutocompleteData {
+			waiting := model.NewAutocompleteData("waiting", "[index] [@user] [notify]", "Marks a Todo as waiting on someone")
+			waiting.AddTextArgument("Position of the Todo in your list", "[index]", "")
+			waiting.AddTextArgument("Whom it is waiting on, leave empty to clear", "[@awesomePerson]", "")
+			return waiting
+		},
+	},
+	{
+		name:    "thread",
+		handler: (*Plugin).runThreadCommand,
+		autocomplete: func() *model.AutocompleteData {
+			thread := model.NewAutocompleteData("thread", "[permalink]", "Lists your Todos attached to a post")
+			thread.AddTextArgument("Permalink of the post", "[permalink]", "")
+			return thread
+		},
+	},
+	{
+		name:    "mentions",
+		handler: (*Plugin).runMentionsCommand,
+		autocomplete: func() *model.AutocompleteData {
+			mentions := model.NewAutocompleteData("mentions", "[user]", "Lists your Todos that mention a user")
+			mentions.AddTextArgument("User mentioned in the Todos", "[@awesomePerson]", "")
+			return mentions
+		},
+	},
+	{
+		name:    "search",
+		handler: (*Plugin).runSearchCommand,
+		autocomplete: func() *model.AutocompleteData {
+			search := model.NewAutocompleteData("search", "[query]", "Lists your Todos matching the filters and words of a query")
+			search.AddTextArgument("Filters like is:overdue, is:starred, is:waiting, role:name or priority:high, and words", "[query]", "")
+			return search
+		},
+	},
+	{
+		name:    "inbox",
+		handler: (*Plugin).runInboxCommand,
+		autocomplete: func() *model.AutocompleteData {
+			inbox := model.NewAutocompleteData("inbox", "[by-sender]", "Lists the Todos you received")
+			inbox.AddStaticListArgument("Lists the Todos you received", false, []model.AutocompleteListItem{{
+				HelpText: "Grouped by who sent them",
+				Hint:     "(optional)",
+				Item:     BySenderFlag,
+			}})
+			return inbox
+		},
+	},
+	{
+		name:    "stats",
+		handler: (*Plugin).runStatsCommand,
+		autocomplete: func() *model.AutocompleteData {
+			return model.NewAutocompleteData("stats", "", "Shows your open Todos and the total of their points")
+		},
+	},
+	{
+		name:    "focus",
+		handler: (*Plugin).runFocusCommand,
+		autocomplete: func() *model.AutocompleteData {
+			focus := model.NewAutocompleteData("focus", "[on, off]", "Holds back your Todo notifications until you turn it off")
+			focus.AddStaticListArgument("Turns focus mode on or off", true, []model.AutocompleteListItem{
+				{HelpText: "Holds back the notifications", Item: "on"},
+				{HelpText: "Sends you a summary of the notifications held back", Item: "off"},
+			})
+			return focus
+		},
+	},
+	{
+		name:    "relate",
+		handler: (*Plugin).runRelateCommand,
+		autocomplete: func() *model.AutocompleteData {
+			relate := model.NewAutocompleteData("relate", "[index] [index]", "Links two Todos of your list as related")
+			relate.AddTextArgument("Positions of the Todos in your list", "[index] [index]", "")
+			return relate
+		},
+	},
+	{
+		name:    "unrelate",
+		handler: (*Plugin).runUnrelateCommand,
+		autocomplete: func() *model.AutocompleteData {
+			unrelate := model.NewAutocompleteData("unrelate", "[index] [index]", "Removes the link between two related Todos of your list")
+			unrelate.AddTextArgument("Positions of the Todos in your list", "[index] [index]", "")
+			return unrelate
+		},
+	},
+	{
+		name:    "next",
+		handler: (*Plugin).runNextCommand,
+		autocomplete: func() *model.AutocompleteData {
+			return model.NewAutocompleteData("next", "", "Suggests the Todo of your list to do next")
+		},
+	},
+	{
+		name:    "stale",
+		handler: (*Plugin).runStaleCommand,
+		autocomplete: func() *model.AutocompleteData {
+			stale := model.NewAutocompleteData("stale", "[days]", "Lists the old Todos of your list")
+			stale.AddTextArgument("Minimum age in days, 14 by default", "[days]", "")
+			return stale
+		},
+	},
+	{
+		name:    "someday",
+		handler: (*Plugin).runSomedayCommand,
+		autocomplete: func() *model.AutocompleteData {
+			someday := model.NewAutocompleteData("someday", "[index]", "Puts a Todo of your list aside for some day")
+			someday.AddTextArgument("Position of the Todo in your list", "[index]", "")
+			return someday
+		},
+	},
+	{
+		name:    "activate",
+		handler: (*Plugin).runActivateCommand,
+		autocomplete: func() *model.AutocompleteData {
+			activate := model.NewAutocompleteData("activate", "[index]", "Moves a Todo of your someday list back to your list")
+			activate.AddTextArgument("Position of the Todo in your someday list", "[index]", "")
+			return activate
+		},
+	},
+	{
+		name:    "nag",
+		handler: (*Plugin).runNagCommand,
+		autocomplete: func() *model.AutocompleteData {
+			nag := model.NewAutocompleteData("nag", "[index] [interval]", "Reminds you about a Todo at an interval until it is done")
+			nag.AddTextArgument("Position of the Todo in your list", "[index]", "")
+			nag.AddTextArgument("Interval like 30m, 4h or 2d, or off", "[interval]", "")
+			return nag
+		},
+	},
+	{
+		name:    "resend",
+		handler: (*Plugin).runResendCommand,
+		autocomplete: func() *model.AutocompleteData {
+			resend := model.NewAutocompleteData("resend", "[index]", "Sends you again the message with the actions for a received Todo")
+			resend.AddTextArgument("Position of the Todo in your incoming list", "[index]", "")
+			return resend
+		},
+	},
+	{
+		name:    "redirect",
+		handler: (*Plugin).runRedirectCommand,
+		autocomplete: func() *model.AutocompleteData {
+			redirect := model.NewAutocompleteData("redirect", "[index] [user]", "Sends a Todo of your outgoing list to a different user")
+			redirect.AddTextArgument("Position of the Todo in your outgoing list", "[index]", "")
+			redirect.AddTextArgument("User to send the Todo to instead", "[@awesomePerson]", "")
+			return redirect
+		},
+	},
+	{
+		name:    "cancel",
+		handler: (*Plugin).runCancelCommand,
+		autocomplete: func() *model.AutocompleteData {
+			cancel := model.NewAutocompleteData("cancel", "[index]", "Withdraws a Todo you sent that has not been accepted yet")
+			cancel.AddTextArgument("Position of the Todo in your outgoing list", "[index]", "")
+			return cancel
+		},
+	},
+	{
+		name:    "accept-from",
+		handler: (*Plugin).runAcceptFromCommand,
+		autocomplete: func() *model.AutocompleteData {
+			acceptFrom := model.NewAutocompleteData("accept-from", "[@user]", "Accepts all the Todos received from a user")
+			acceptFrom.AddTextArgument("Whose Todos to accept", "[@awesomePerson]", "")
+			return acceptFrom
+		},
+	},
+	{
+		name:    "send",
+		handler: (*Plugin).runSendCommand,
+		autocomplete: func() *model.AutocompleteData {
+			send := model.NewAutocompleteData("send", "[user] [todo]", "Sends a Todo to a specified user")
+			send.AddDynamicListArgument("Whom to send, the users you sent Todos to recently first", "plugins/"+manifest.Id+"/autocomplete/recipients", true)
+			send.AddTextArgument("Todo message", "[message]", "")
+			return send
+		},
+	},
+	{
+		name:         "settings",
+		handler:      (*Plugin).runSettingsCommand,
+		autocomplete: getSettingsAutocompleteData,
+	},
+	{
+		name: "help",
+		autocomplete: func() *model.AutocompleteData {
+			return model.NewAutocompleteData("help", "", "Display usage")
+		},
+	},
+}
+
+// findTodoCommand returns the /todo subcommand called name, or nil if there is none.
+func findTodoCommand(name string) *todoCommand {
+	for _, command := range todoCommands {
+		if command.name == name {
+			return command
+		}
+	}
+	return nil
+}
+
+// todoCommandsDescription lists the names of the /todo subcommands.
+func todoCommandsDescription() string {
+	names := []string{}
+	for _, command := range todoCommands {
+		names = append(names, command.name)
+	}
+	return "Available commands: " + strings.Join(names, ", ")
+}
+
 func getAutocompleteData() *model.AutocompleteData {
-	todo := model.NewAutocompleteData("todo", "[command]", "Available commands: list, add, pop, send, settings, help")
-
-	add := model.NewAutocompleteData("add", "[message]", "Adds a Todo")
-	add.AddTextArgument("E.g. be awesome", "[message]", "")
-	todo.AddCommand(add)
-
-	list := model.NewAutocompleteData("list", "[name]", "Lists your Todo issues")
-	items := []model.AutocompleteListItem{{
-		HelpText: "Received Todos",
-		Hint:     "(optional)",
-		Item:     "in",
-	}, {
-		HelpText: "Sent Todos",
-		Hint:     "(optional)",
-		Item:     "out",
-	}}
-	list.AddStaticListArgument("Lists your Todo issues", false, items)
-	todo.AddCommand(list)
-
-	pop := model.NewAutocompleteData("pop", "", "Removes the Todo issue at the top of the list")
-	todo.AddCommand(pop)
-
-	send := model.NewAutocompleteData("send", "[user] [todo]", "Sends a Todo to a specified user")
-	send.AddTextArgument("Whom to send", "[@awesomePerson]", "")
-	send.AddTextArgument("Todo message", "[message]", "")
-	todo.AddCommand(send)
+	todo := model.NewAutocompleteData("todo", "[command]", todoCommandsDescription())
+	for _, command := range todoCommands {
+		todo.AddCommand(command.autocomplete())
+	}
+	return todo
+}
 
+func getSettingsAutocompleteData() *model.AutocompleteData {
 	settings := model.NewAutocompleteData("settings", "[setting] [on] [off]", "Sets the user settings")
 	summary := model.NewAutocompleteData("summary", "[on] [off]", "Sets the summary settings")
 	summaryOn := model.NewAutocompleteData("on", "", "sets the daily reminder to enable")
 	summaryOff := model.NewAutocompleteData("off", "", "sets the daily reminder to disable")
+	summarySnooze := model.NewAutocompleteData("snooze", "[days]", "pauses the daily reminder for a number of days")
+	summarySnooze.AddTextArgument("Number of days", "[days]", "")
 	summary.AddCommand(summaryOn)
 	summary.AddCommand(summaryOff)
+	summary.AddCommand(summarySnooze)
+
+	summaryDescriptions := model.NewAutocompleteData("summary_descriptions", "[on] [off]", "Sets whether the daily reminder includes descriptions")
+	summaryDescriptionsOn := model.NewAutocompleteData("on", "", "include the description of each Todo in the daily reminder")
+	summaryDescriptionsOff := model.NewAutocompleteData("off", "", "only include the Todo messages in the daily reminder")
+	summaryDescriptions.AddCommand(summaryDescriptionsOn)
+	summaryDescriptions.AddCommand(summaryDescriptionsOff)
 
 	allowIncomingTask := model.NewAutocompleteData("allow_incoming_task_requests", "[on] [off]", "Allow other Mattermost users to send a task for you to accept/decline?")
 	allowIncomingTaskOn := model.NewAutocompleteData("on", "", "Allow others to send you a Task, you can accept/decline")
@@ -458,11 +2616,86 @@ func getAutocompleteData() *model.AutocompleteData {
 	allowIncomingTask.AddCommand(allowIncomingTaskOn)
 	allowIncomingTask.AddCommand(allowIncomingTaskOff)
 
+	inboxOrder := model.NewAutocompleteData("inbox_order", "[top] [bottom]", "Sets where new received Todos are placed in your incoming list")
+	inboxOrderTop := model.NewAutocompleteData("top", "", "Place new received Todos at the top of the incoming list")
+	inboxOrderBottom := model.NewAutocompleteData("bottom", "", "Place new received Todos at the bottom of the incoming list")
+	inboxOrder.AddCommand(inboxOrderTop)
+	inboxOrder.AddCommand(inboxOrderBottom)
+
 	settings.AddCommand(summary)
+	settings.AddCommand(summaryDescriptions)
 	settings.AddCommand(allowIncomingTask)
-	todo.AddCommand(settings)
+	settings.AddCommand(inboxOrder)
+
+	completedSent := model.NewAutocompleteData("completed_sent", "[keep] [remove]", "Sets what happens to the Todos you sent when their receivers complete them")
+	completedSentKeep := model.NewAutocompleteData("keep", "", "Keep them on the outgoing list, marked as completed")
+	completedSentRemove := model.NewAutocompleteData("remove", "", "Remove them from the outgoing list")
+	completedSent.AddCommand(completedSentKeep)
+	completedSent.AddCommand(completedSentRemove)
+	settings.AddCommand(completedSent)
+
+	selfSend := model.NewAutocompleteData("self_send", "[list] [inbox]", "Sets where the Todos you send to yourself go")
+	selfSendList := model.NewAutocompleteData("list", "", "Add them to your list")
+	selfSendInbox := model.NewAutocompleteData("inbox", "", "Place them on your incoming list to accept or decline")
+	selfSend.AddCommand(selfSendList)
+	selfSend.AddCommand(selfSendInbox)
+	settings.AddCommand(selfSend)
+
+	summaryHeader := model.NewAutocompleteData("summary_header", "[text] [default]", "Sets the text the daily reminders start with")
+	summaryHeader.AddTextArgument("Text like \"Good morning! Here's your list\", or default", "[text]", "")
+	settings.AddCommand(summaryHeader)
+
+	popOrder := model.NewAutocompleteData("pop_order", "[top] [urgent]", "Sets which Todo pop removes")
+	popOrderTop := model.NewAutocompleteData("top", "", "The one at the top of your list")
+	popOrderUrgent := model.NewAutocompleteData("urgent", "", "The one due the soonest")
+	popOrder.AddCommand(popOrderTop)
+	popOrder.AddCommand(popOrderUrgent)
+	settings.AddCommand(popOrder)
+
+	shareCounts := model.NewAutocompleteData("share_counts", "[on] [off]", "Sets whether channel integrations can see how many Todos you have")
+	shareCountsOn := model.NewAutocompleteData("on", "", "Share how many Todos are on your list")
+	shareCountsOff := model.NewAutocompleteData("off", "", "Keep it to yourself")
+	shareCounts.AddCommand(shareCountsOn)
+	shareCounts.AddCommand(shareCountsOff)
+	settings.AddCommand(shareCounts)
+
+	defaultPriority := model.NewAutocompleteData("default_priority", "[high] [normal] [low]", "Sets the priority of the Todos you add without choosing one")
+	for _, name := range []string{"high", "normal", "low"} {
+		defaultPriority.AddCommand(model.NewAutocompleteData(name, "", "Add them as "+name+" priority"))
+	}
+	settings.AddCommand(defaultPriority)
 
-	help := model.NewAutocompleteData("help", "", "Display usage")
-	todo.AddCommand(help)
-	return todo
+	priorityEmoji := model.NewAutocompleteData("priority_emoji", "[priority] [emoji]", "Sets the emoji shown before the Todos of a priority")
+	for _, name := range []string{"high", "normal", "low"} {
+		priority := model.NewAutocompleteData(name, "[emoji] [none] [default]", "Sets the emoji of the "+name+" priority Todos")
+		priority.AddTextArgument("Emoji like :red_circle:, none or default", "[emoji]", "")
+		priorityEmoji.AddCommand(priority)
+	}
+	settings.AddCommand(priorityEmoji)
+
+	triageReminder := model.NewAutocompleteData("triage_reminder", "[interval] [off]", "Sets how often you are reminded about the Todos you have not accepted yet")
+	triageReminder.AddTextArgument("Interval like 30m, 4h or 2d, or off", "[interval]", "")
+	settings.AddCommand(triageReminder)
+
+	notifications := model.NewAutocompleteData("notifications", "[dm] [here]", "Sets where you receive your Todo notifications")
+	notificationsDM := model.NewAutocompleteData("dm", "", "As a direct message from the bot")
+	notificationsHere := model.NewAutocompleteData("here", "", "In the current channel")
+	notifications.AddCommand(notificationsDM)
+	notifications.AddCommand(notificationsHere)
+	settings.AddCommand(notifications)
+
+	teamDefault := model.NewAutocompleteData("team_default", "[setting] [on] [off] [unset]", "Team admins only. Sets the defaults for the members of this team")
+	for _, name := range []string{"summary", "allow_incoming_task_requests"} {
+		setting := model.NewAutocompleteData(name, "[on] [off] [unset]", "Sets the team default of "+name)
+		setting.AddCommand(model.NewAutocompleteData("on", "", "Turn it on for members who did not choose"))
+		setting.AddCommand(model.NewAutocompleteData("off", "", "Turn it off for members who did not choose"))
+		setting.AddCommand(model.NewAutocompleteData("unset", "", "Remove the team default"))
+		teamDefault.AddCommand(setting)
+	}
+	settings.AddCommand(teamDefault)
+
+	reset := model.NewAutocompleteData("reset", "[confirm]", "Sets all your settings back to their defaults")
+	reset.AddCommand(model.NewAutocompleteData("confirm", "", "Confirm the reset of your settings"))
+	settings.AddCommand(reset)
+	return settings
 }
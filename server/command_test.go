@@ -1,18 +1,27 @@
 package main
 
 import (
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/mattermost/mattermost-server/v5/model"
 	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSetttingsCommand(t *testing.T) {
 	api := &plugintest.API{}
 	api.On("SendEphemeralPost", mock.AnythingOfType("string"), mock.Anything).Return(nil)
 	api.On("KVSet", mock.AnythingOfType("string"), mock.Anything).Return(nil)
+	api.On("KVGet", StoreTriageRemindersKey).Return(nil, nil)
+	api.On("KVGet", priorityEmojiKey("")).Return(nil, nil)
+	api.On("KVGet", defaultPriorityKey("")).Return(nil, nil)
 	api.On("KVGet", mock.AnythingOfType("string"), mock.Anything).Return([]byte("true"), nil)
+	api.On("GetChannel", mock.AnythingOfType("string")).Return(&model.Channel{Name: "town-square"}, nil)
 
 	apiKVSetFailed := &plugintest.API{}
 	apiKVSetFailed.On("SendEphemeralPost", mock.AnythingOfType("string"), mock.Anything).Return(nil)
@@ -122,3 +131,1265 @@ func TestSetttingsCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestWaitingCommand(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+
+	issue, err := p.listManager.AddIssue("alice", "review the design", "", "", IssueFields{})
+	require.NoError(t, err)
+
+	isUserError, err := p.runWaitingCommand([]string{"1", "@bob", "notify"}, &model.CommandArgs{UserId: "alice"})
+	require.NoError(t, err)
+	assert.False(t, isUserError)
+
+	stored, err := p.listManager.(*listManager).store.GetIssue(issue.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", stored.WaitingOn)
+
+	// The issue stays on alice's list and nothing lands on bob's
+	issues, err := p.listManager.GetIssueList("alice", MyListKey)
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	assert.Equal(t, "bob", issues[0].WaitingOnUser)
+	assert.Contains(t, issuesListToString(issues), "Waiting on @bob")
+	received, err := p.listManager.GetIssueList("bob", InListKey)
+	require.NoError(t, err)
+	assert.Empty(t, received)
+
+	notes := env.postsTo("dm_bob")
+	require.Len(t, notes, 1)
+	assert.Contains(t, notes[0].Message, "review the design")
+
+	_, err = p.runWaitingCommand([]string{"1"}, &model.CommandArgs{UserId: "alice"})
+	require.NoError(t, err)
+	issues, err = p.listManager.GetIssueList("alice", MyListKey)
+	require.NoError(t, err)
+	assert.NotContains(t, issuesListToString(issues), "Waiting on")
+
+	isUserError, err = p.runWaitingCommand([]string{"2", "@bob"}, &model.CommandArgs{UserId: "alice"})
+	assert.Error(t, err)
+	assert.True(t, isUserError)
+
+	isUserError, err = p.runWaitingCommand([]string{"1", "@nobody"}, &model.CommandArgs{UserId: "alice"})
+	assert.Error(t, err)
+	assert.True(t, isUserError)
+}
+
+func TestAcceptFromCommand(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	env.addUser("carol", "carol")
+	p := env.newPlugin()
+
+	for _, message := range []string{"first from bob", "second from bob"} {
+		_, err := p.listManager.SendIssue("bob", "alice", message, "", "", IssueFields{})
+		require.NoError(t, err)
+	}
+	_, err := p.listManager.SendIssue("carol", "alice", "from carol", "", "", IssueFields{})
+	require.NoError(t, err)
+	env.resetRecords()
+
+	_, err = p.runAcceptFromCommand([]string{"@bob"}, &model.CommandArgs{UserId: "alice"})
+	require.NoError(t, err)
+
+	mine, err := p.listManager.GetIssueList("alice", MyListKey)
+	require.NoError(t, err)
+	require.Len(t, mine, 2)
+	assert.Equal(t, "first from bob", mine[0].Message)
+	assert.Equal(t, "second from bob", mine[1].Message)
+
+	inbox, err := p.listManager.GetIssueList("alice", InListKey)
+	require.NoError(t, err)
+	require.Len(t, inbox, 1)
+	assert.Equal(t, "from carol", inbox[0].Message)
+
+	// A single summary DM and one refresh per affected user
+	summaries := env.postsTo("dm_bob")
+	require.Len(t, summaries, 1)
+	assert.Contains(t, summaries[0].Message, "accepted 2 Todos")
+	assert.Empty(t, env.postsTo("dm_carol"))
+	assert.Equal(t, []testRefreshEvent{
+		{"alice", []string{MyListKey, InListKey}},
+		{"bob", []string{OutListKey}},
+	}, env.refreshes())
+
+	env.resetRecords()
+	_, err = p.runAcceptFromCommand([]string{"@bob"}, &model.CommandArgs{UserId: "alice"})
+	require.NoError(t, err)
+	assert.Contains(t, env.lastEphemeral(), "no Todos from @bob")
+	assert.Empty(t, env.refreshes())
+
+	// A single Todo is not counted
+	_, err = p.runAcceptFromCommand([]string{"@carol"}, &model.CommandArgs{UserId: "alice"})
+	require.NoError(t, err)
+	summaries = env.postsTo("dm_carol")
+	require.Len(t, summaries, 1)
+	assert.Equal(t, "@alice accepted a Todo you sent: from carol", summaries[0].Message)
+	assert.Equal(t, "Accepted a Todo from @carol: from carol", env.lastEphemeral())
+}
+
+func TestShowCommand(t *testing.T) {
+	env := newTestEnv()
+	p := env.newPlugin()
+
+	_, err := p.listManager.AddIssue("alice", "buy milk", "two bottles", "", IssueFields{})
+	require.NoError(t, err)
+
+	_, err = p.runShowCommand([]string{"1"}, &model.CommandArgs{UserId: "alice"})
+	require.NoError(t, err)
+	assert.Contains(t, env.lastEphemeral(), "#### buy milk")
+	assert.Contains(t, env.lastEphemeral(), "two bottles")
+
+	for _, args := range [][]string{{"2"}, {"0"}, {"first"}, {"in", "1"}, {"nowhere", "1"}, {}} {
+		isUserError, err := p.runShowCommand(args, &model.CommandArgs{UserId: "alice"})
+		assert.Error(t, err, args)
+		assert.True(t, isUserError, args)
+	}
+}
+
+func TestSnoozeSummaryCommand(t *testing.T) {
+	env := newTestEnv()
+	p := env.newPlugin()
+
+	before := model.GetMillis()
+	isUserError, err := p.runSettingsCommand([]string{"summary", "snooze", "3"}, &model.CommandArgs{UserId: "alice"})
+	require.NoError(t, err)
+	assert.False(t, isUserError)
+
+	after := model.GetMillis()
+	snoozedUntil, err := p.getReminderSnoozedUntil("alice")
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, snoozedUntil, before+3*24*60*60*1000)
+	assert.LessOrEqual(t, snoozedUntil, after+3*24*60*60*1000)
+
+	for _, args := range [][]string{{"summary", "snooze"}, {"summary", "snooze", "0"}, {"summary", "snooze", "soon"}, {"summary", "snooze", "3", "4"}} {
+		isUserError, err = p.runSettingsCommand(args, &model.CommandArgs{UserId: "alice"})
+		assert.Error(t, err, args)
+		assert.True(t, isUserError, args)
+	}
+}
+
+func TestResendCommand(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+
+	_, err := p.listManager.SendIssue("bob", "alice", "first", "", "", IssueFields{})
+	require.NoError(t, err)
+	secondID, err := p.listManager.SendIssue("bob", "alice", "second", "", "", IssueFields{})
+	require.NoError(t, err)
+	env.resetRecords()
+
+	_, err = p.runResendCommand([]string{"2"}, &model.CommandArgs{UserId: "alice"})
+	require.NoError(t, err)
+
+	posts := env.postsTo("dm_alice")
+	require.Len(t, posts, 1)
+	assert.Equal(t, "custom_todo", posts[0].Type)
+	assert.Equal(t, secondID, posts[0].Props["issueId"])
+	assert.Equal(t, "second", posts[0].Props["todo"])
+	assert.Equal(t, "You have received a new Todo from @bob", posts[0].Props["message"])
+
+	isUserError, err := p.runResendCommand([]string{"3"}, &model.CommandArgs{UserId: "alice"})
+	assert.Error(t, err)
+	assert.True(t, isUserError)
+}
+
+func TestRedirectCommand(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	env.addUser("carol", "carol")
+	p := env.newPlugin()
+
+	_, err := p.listManager.SendIssue("alice", "bob", "review", "", "", IssueFields{})
+	require.NoError(t, err)
+	env.resetRecords()
+
+	_, err = p.runRedirectCommand([]string{"1", "@carol"}, &model.CommandArgs{UserId: "alice"})
+	require.NoError(t, err)
+
+	bobIssues, err := p.listManager.GetIssueList("bob", InListKey)
+	require.NoError(t, err)
+	assert.Empty(t, bobIssues)
+
+	carolIssues, err := p.listManager.GetIssueList("carol", InListKey)
+	require.NoError(t, err)
+	require.Len(t, carolIssues, 1)
+	assert.Equal(t, "review", carolIssues[0].Message)
+	assert.Equal(t, "alice", carolIssues[0].ForeignUser)
+
+	sent, err := p.listManager.GetIssueList("alice", OutListKey)
+	require.NoError(t, err)
+	require.Len(t, sent, 1)
+	assert.Equal(t, "carol", sent[0].ForeignUser)
+
+	// The new receiver gets the actions for their own copy of the todo
+	carolPosts := env.postsTo("dm_carol")
+	require.Len(t, carolPosts, 1)
+	assert.Equal(t, "custom_todo", carolPosts[0].Type)
+	assert.Equal(t, carolIssues[0].ID, carolPosts[0].Props["issueId"])
+
+	bobPosts := env.postsTo("dm_bob")
+	require.Len(t, bobPosts, 1)
+	assert.Equal(t, "@alice removed you from Todo:\nreview", bobPosts[0].Message)
+
+	assert.Equal(t, []testRefreshEvent{
+		{"alice", []string{MyListKey, OutListKey}},
+		{"carol", []string{InListKey}},
+		{"bob", []string{InListKey, MyListKey}},
+	}, env.refreshes())
+
+	isUserError, err := p.runRedirectCommand([]string{"2", "@carol"}, &model.CommandArgs{UserId: "alice"})
+	assert.Error(t, err)
+	assert.True(t, isUserError)
+
+	// Accepted Todos are the receiver's to do, they cannot be redirected anymore
+	_, _, err = p.listManager.AcceptIssue("carol", carolIssues[0].ID)
+	require.NoError(t, err)
+	isUserError, err = p.runRedirectCommand([]string{"1", "@bob"}, &model.CommandArgs{UserId: "alice"})
+	require.Error(t, err)
+	assert.True(t, isUserError)
+	assert.Contains(t, err.Error(), "@carol has already accepted that Todo")
+	carolIssues, err = p.listManager.GetIssueList("carol", MyListKey)
+	require.NoError(t, err)
+	assert.Len(t, carolIssues, 1)
+	bobIssues, err = p.listManager.GetIssueList("bob", InListKey)
+	require.NoError(t, err)
+	assert.Empty(t, bobIssues)
+}
+
+func TestChannelTag(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+
+	standup := &model.Channel{Id: "standup_id", Name: "team-standup", Type: model.CHANNEL_OPEN}
+	secret := &model.Channel{Id: "secret_id", Name: "secret", Type: model.CHANNEL_PRIVATE}
+	env.api.On("GetChannelByName", "team", "team-standup", false).Return(standup, nil)
+	env.api.On("GetChannelByName", "team", "secret", false).Return(secret, nil)
+	env.api.On("GetChannelByName", "team", "missing", false).Return(nil, &model.AppError{Message: "not found"})
+	env.api.On("GetChannelMember", "secret_id", "alice").Return(nil, &model.AppError{Message: "not a member"})
+	env.api.On("GetChannel", "standup_id").Return(standup, nil)
+	env.api.On("GetChannelMember", "standup_id", mock.AnythingOfType("string")).Return(&model.ChannelMember{ChannelId: "standup_id"}, nil)
+	extra := &model.CommandArgs{UserId: "alice", TeamId: "team"}
+
+	_, err := p.runAddCommand([]string{"Prepare", "channel:~team-standup", "the", "demo"}, extra)
+	require.NoError(t, err)
+
+	issues, err := p.listManager.GetIssueList("alice", MyListKey)
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	assert.Equal(t, "Prepare the demo", issues[0].Message)
+	assert.Equal(t, "standup_id", issues[0].ChannelID)
+	assert.Equal(t, "team-standup", issues[0].ChannelName)
+	assert.Contains(t, env.lastEphemeral(), "   * Channel: ~team-standup\n")
+
+	env.resetRecords()
+	_, err = p.runSendCommand([]string{"@bob", "Review", "channel:~team-standup"}, extra)
+	require.NoError(t, err)
+
+	// Both sides of the shared todo are linked with the channel
+	received, err := p.listManager.GetIssueList("bob", InListKey)
+	require.NoError(t, err)
+	require.Len(t, received, 1)
+	assert.Equal(t, "team-standup", received[0].ChannelName)
+	sent, err := p.listManager.GetIssueList("alice", OutListKey)
+	require.NoError(t, err)
+	require.Len(t, sent, 1)
+	assert.Equal(t, "team-standup", sent[0].ChannelName)
+
+	posts := env.postsTo("dm_bob")
+	require.Len(t, posts, 1)
+	assert.Equal(t, "You have received a new Todo from @alice about ~team-standup", posts[0].Props["message"])
+	assert.Equal(t, "Review", posts[0].Props["todo"])
+
+	for _, tag := range []string{"channel:~secret", "channel:~missing"} {
+		isUserError, err := p.runAddCommand([]string{"Something", tag}, extra)
+		assert.Error(t, err, tag)
+		assert.True(t, isUserError, tag)
+	}
+	isUserError, err := p.runAddCommand([]string{"Something", "channel:~team-standup", "channel:~secret"}, extra)
+	assert.Error(t, err)
+	assert.True(t, isUserError)
+}
+
+func TestChannelNameShownToMembers(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+
+	standup := &model.Channel{Id: "standup_id", Name: "team-standup", Type: model.CHANNEL_PRIVATE}
+	env.api.On("GetChannel", "standup_id").Return(standup, nil)
+	env.api.On("GetChannelMember", "standup_id", "alice").Return(&model.ChannelMember{ChannelId: "standup_id", UserId: "alice"}, nil)
+	env.api.On("GetChannelMember", "standup_id", "bob").Return(nil, &model.AppError{Message: "not a member"})
+
+	for _, message := range []string{"prepare the demo", "send the notes"} {
+		_, err := p.listManager.SendIssue("alice", "bob", message, "", "", IssueFields{ChannelID: "standup_id"})
+		require.NoError(t, err)
+	}
+
+	sent, err := p.listManager.GetIssueList("alice", OutListKey)
+	require.NoError(t, err)
+	require.Len(t, sent, 2)
+	for _, issue := range sent {
+		assert.Equal(t, "team-standup", issue.ChannelName)
+	}
+	env.api.AssertNumberOfCalls(t, "GetChannel", 1)
+
+	// The receiver does not learn about a channel they are not in
+	received, err := p.listManager.GetIssueList("bob", InListKey)
+	require.NoError(t, err)
+	require.Len(t, received, 2)
+	for _, issue := range received {
+		assert.Empty(t, issue.ChannelName)
+	}
+	env.api.AssertNumberOfCalls(t, "GetChannel", 1)
+}
+
+func TestCancelCommand(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+
+	_, err := p.listManager.SendIssue("alice", "bob", "pending", "", "", IssueFields{})
+	require.NoError(t, err)
+	acceptedID, err := p.listManager.SendIssue("alice", "bob", "accepted", "", "", IssueFields{})
+	require.NoError(t, err)
+	_, _, err = p.listManager.AcceptIssue("bob", acceptedID)
+	require.NoError(t, err)
+	env.resetRecords()
+
+	_, err = p.runCancelCommand([]string{"1"}, &model.CommandArgs{UserId: "alice"})
+	require.NoError(t, err)
+
+	received, err := p.listManager.GetIssueList("bob", InListKey)
+	require.NoError(t, err)
+	assert.Empty(t, received)
+	sent, err := p.listManager.GetIssueList("alice", OutListKey)
+	require.NoError(t, err)
+	require.Len(t, sent, 1)
+	assert.Equal(t, "accepted", sent[0].Message)
+
+	posts := env.postsTo("dm_bob")
+	require.Len(t, posts, 1)
+	assert.Equal(t, "@alice withdrew a Todo they sent you: pending", posts[0].Message)
+	assert.Equal(t, []testRefreshEvent{
+		{"alice", []string{OutListKey}},
+		{"bob", []string{InListKey}},
+	}, env.refreshes())
+
+	// Accepted and out of range todos cannot be cancelled
+	for _, index := range []string{"1", "2"} {
+		isUserError, err := p.runCancelCommand([]string{index}, &model.CommandArgs{UserId: "alice"})
+		assert.Error(t, err, index)
+		assert.True(t, isUserError, index)
+	}
+}
+
+func TestListByChannelCommand(t *testing.T) {
+	env := newTestEnv()
+	p := env.newPlugin()
+
+	env.api.On("GetPost", "standup_post").Return(&model.Post{Id: "standup_post", ChannelId: "standup_id"}, nil)
+	env.api.On("GetPost", "other_standup_post").Return(&model.Post{Id: "other_standup_post", ChannelId: "standup_id"}, nil)
+	env.api.On("GetPost", "dm_post").Return(&model.Post{Id: "dm_post", ChannelId: "dm_id"}, nil)
+	env.api.On("GetPost", "deleted_post").Return(nil, &model.AppError{Message: "not found"})
+	env.api.On("GetChannel", "standup_id").Return(&model.Channel{Id: "standup_id", Name: "team-standup", Type: model.CHANNEL_OPEN}, nil)
+	env.api.On("GetChannel", "dm_id").Return(&model.Channel{Id: "dm_id", Name: "alice__bob", Type: model.CHANNEL_DIRECT}, nil)
+
+	for _, issue := range []struct{ message, postID string }{
+		{"no post", ""},
+		{"standup", "standup_post"},
+		{"dm", "dm_post"},
+		{"deleted", "deleted_post"},
+		{"standup again", "other_standup_post"},
+	} {
+		_, err := p.listManager.AddIssue("alice", issue.message, "", issue.postID, IssueFields{})
+		require.NoError(t, err)
+	}
+
+	issues, err := p.listManager.GetIssueList("alice", MyListKey)
+	require.NoError(t, err)
+	groups := p.groupIssuesByChannel(issues)
+
+	type group struct {
+		name      string
+		messages  []string
+		positions []int
+	}
+	got := []group{}
+	for _, g := range groups {
+		messages := []string{}
+		for _, issue := range g.Issues {
+			messages = append(messages, issue.Message)
+		}
+		got = append(got, group{g.Name, messages, g.Positions})
+	}
+	assert.Equal(t, []group{
+		{"~team-standup", []string{"standup", "standup again"}, []int{2, 5}},
+		{"Direct and group messages", []string{"dm"}, []int{3}},
+		{"No channel", []string{"no post", "deleted"}, []int{1, 4}},
+	}, got)
+
+	_, err = p.runListCommand([]string{ByChannelFlag}, &model.CommandArgs{UserId: "alice"})
+	require.NoError(t, err)
+	list := env.lastEphemeral()
+	assert.Contains(t, list, "#### ~team-standup\n\n2. standup\n")
+	assert.Contains(t, list, "#### No channel\n\n1. no post\n")
+}
+
+func TestSendRestrictedToChannelMembers(t *testing.T) {
+	tests := []struct {
+		name     string
+		restrict bool
+		receiver string
+		wantSent bool
+	}{
+		{name: "Anyone can be sent Todos without the restriction", restrict: false, receiver: "carol", wantSent: true},
+		{name: "Channel members can be sent Todos", restrict: true, receiver: "bob", wantSent: true},
+		{name: "Users outside the channel cannot be sent Todos", restrict: true, receiver: "carol", wantSent: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv()
+			env.addUser("alice", "alice")
+			env.addUser("bob", "bob")
+			env.addUser("carol", "carol")
+			env.api.On("GetChannelMember", "channel", "bob").Return(&model.ChannelMember{ChannelId: "channel", UserId: "bob"}, nil)
+			env.api.On("GetChannelMember", "channel", "carol").Return(nil, &model.AppError{Message: "not a member"})
+			p := env.newPlugin()
+			p.setConfiguration(&configuration{EnableSendToOthers: true, RestrictSendToChannelMembers: tt.restrict})
+
+			_, err := p.runSendCommand([]string{"@" + tt.receiver, "review"}, &model.CommandArgs{UserId: "alice", ChannelId: "channel"})
+			require.NoError(t, err)
+
+			received, err := p.listManager.GetIssueList(tt.receiver, InListKey)
+			require.NoError(t, err)
+			if tt.wantSent {
+				assert.Len(t, received, 1)
+				return
+			}
+			assert.Empty(t, received)
+			assert.Equal(t, "@carol is not a member of this channel. Todos can only be sent to members of the channel.", env.lastEphemeral())
+		})
+	}
+}
+
+func TestTeamDefaultSettings(t *testing.T) {
+	env := newTestEnv()
+	env.addTeamMember("team", "alice")
+	env.addTeamMember("team", "bob")
+	env.addTeamMember("team", "carol")
+	env.api.On("HasPermissionToTeam", "admin", "team", model.PERMISSION_MANAGE_TEAM).Return(true)
+	env.api.On("HasPermissionToTeam", "alice", "team", model.PERMISSION_MANAGE_TEAM).Return(false)
+	p := env.newPlugin()
+	admin := &model.CommandArgs{UserId: "admin", TeamId: "team"}
+
+	// Without team defaults, the plugin defaults apply without looking up the teams of the user
+	assert.True(t, p.getReminderPreference("alice"))
+	env.api.AssertNotCalled(t, "GetTeamsForUser", "alice")
+
+	_, err := p.runSettingsCommand([]string{"team_default", "summary", "off"}, admin)
+	require.NoError(t, err)
+	_, err = p.runSettingsCommand([]string{"team_default", "allow_incoming_task_requests", "off"}, admin)
+	require.NoError(t, err)
+
+	// Members without their own setting inherit the team default
+	assert.False(t, p.getReminderPreference("alice"))
+	allowIncoming, err := p.getAllowIncomingTaskRequestsPreference("alice")
+	require.NoError(t, err)
+	assert.False(t, allowIncoming)
+
+	// Members who chose their own setting keep it
+	require.NoError(t, p.saveReminderPreference("bob", true))
+	assert.True(t, p.getReminderPreference("bob"))
+
+	// Users outside the team are not affected
+	assert.True(t, p.getReminderPreference("dave"))
+
+	_, err = p.runSettingsCommand([]string{"team_default", "summary", "unset"}, admin)
+	require.NoError(t, err)
+	assert.True(t, p.getReminderPreference("carol"))
+
+	isUserError, err := p.runSettingsCommand([]string{"team_default", "summary", "on"}, &model.CommandArgs{UserId: "alice", TeamId: "team"})
+	assert.Error(t, err)
+	assert.True(t, isUserError)
+}
+
+func TestResetSettings(t *testing.T) {
+	env := newTestEnv()
+	p := env.newPlugin()
+	extra := &model.CommandArgs{UserId: "alice"}
+
+	require.NoError(t, p.saveReminderPreference("alice", false))
+	require.NoError(t, p.saveReminderSnoozedUntil("alice", model.GetMillis()+1000000))
+	require.NoError(t, p.saveReminderDescriptionsPreference("alice", true))
+	require.NoError(t, p.saveAllowIncomingTaskRequestsPreference("alice", false))
+	require.NoError(t, p.saveReceivedOnTopPreference("alice", true))
+	require.NoError(t, p.saveKeepCompletedSentPreference("alice", true))
+
+	// Nothing is reset without confirmation
+	_, err := p.runSettingsCommand([]string{"reset"}, extra)
+	require.NoError(t, err)
+	assert.Contains(t, env.lastEphemeral(), "/todo settings reset confirm")
+	assert.False(t, p.getReminderPreference("alice"))
+
+	isUserError, err := p.runSettingsCommand([]string{"reset", "yes"}, extra)
+	assert.Error(t, err)
+	assert.True(t, isUserError)
+
+	_, err = p.runSettingsCommand([]string{"reset", "confirm"}, extra)
+	require.NoError(t, err)
+	assert.Contains(t, env.lastEphemeral(), "Your settings were reset to their defaults.\n\nCurrent Settings:")
+	assert.Contains(t, env.lastEphemeral(), getSummarySetting(true))
+
+	assert.True(t, p.getReminderPreference("alice"))
+	snoozedUntil, err := p.getReminderSnoozedUntil("alice")
+	require.NoError(t, err)
+	assert.Zero(t, snoozedUntil)
+	assert.False(t, p.getReminderDescriptionsPreference("alice"))
+	allowIncoming, err := p.getAllowIncomingTaskRequestsPreference("alice")
+	require.NoError(t, err)
+	assert.True(t, allowIncoming)
+	assert.False(t, getReceivedOnTopPreference(p.API, "alice"))
+	assert.False(t, getKeepCompletedSentPreference(p.API, "alice"))
+}
+
+func TestInboxBySenderCommand(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	env.addUser("carol", "carol")
+	p := env.newPlugin()
+
+	for _, sent := range []struct{ sender, message string }{
+		{"bob", "first from bob"},
+		{"carol", "from carol"},
+		{"bob", "second from bob"},
+	} {
+		_, err := p.listManager.SendIssue(sent.sender, "alice", sent.message, "", "", IssueFields{})
+		require.NoError(t, err)
+	}
+
+	issues, err := p.listManager.GetIssueList("alice", InListKey)
+	require.NoError(t, err)
+	groups := groupIssuesBySender(issues)
+	require.Len(t, groups, 2)
+	assert.Equal(t, "@bob (2)", groups[0].Name)
+	assert.Equal(t, []int{1, 3}, groups[0].Positions)
+	assert.Equal(t, "second from bob", groups[0].Issues[1].Message)
+	assert.Equal(t, "@carol (1)", groups[1].Name)
+
+	_, err = p.runInboxCommand([]string{BySenderFlag}, &model.CommandArgs{UserId: "alice"})
+	require.NoError(t, err)
+	assert.Contains(t, env.lastEphemeral(), "#### @bob (2)\n\n1. first from bob\n")
+	assert.Contains(t, env.lastEphemeral(), "3. second from bob\n")
+
+	isUserError, err := p.runInboxCommand([]string{"by-channel"}, &model.CommandArgs{UserId: "alice"})
+	assert.Error(t, err)
+	assert.True(t, isUserError)
+}
+
+func TestExtractPointsTag(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantRest   []string
+		wantPoints int
+		wantErr    bool
+	}{
+		{name: "No points", args: []string{"write", "docs"}, wantRest: []string{"write", "docs"}},
+		{name: "Points anywhere in the message", args: []string{"write", "points:3", "docs"}, wantRest: []string{"write", "docs"}, wantPoints: 3},
+		{name: "Zero points", args: []string{"write", "points:0"}, wantRest: []string{"write"}},
+		{name: "Negative points", args: []string{"write", "points:-1"}, wantErr: true},
+		{name: "Not a number", args: []string{"write", "points:lots"}, wantErr: true},
+		{name: "Empty points", args: []string{"write", "points:"}, wantErr: true},
+		{name: "Points given twice", args: []string{"points:1", "write", "points:2"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rest, points, err := extractPointsTag(tt.args)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRest, rest)
+			assert.Equal(t, tt.wantPoints, points)
+		})
+	}
+}
+
+func TestListRoleCommand(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+	alice := &model.CommandArgs{UserId: "alice"}
+
+	_, err := p.runAddCommand([]string{"write", "the", "spec"}, alice)
+	require.NoError(t, err)
+	_, err = p.runAddCommand([]string{"test", "the", "upgrade", "role:QA"}, alice)
+	require.NoError(t, err)
+	_, err = p.runSendCommand([]string{"@alice", "verify", "the", "fix", "role:qa"}, &model.CommandArgs{UserId: "bob"})
+	require.NoError(t, err)
+
+	_, err = p.runListCommand([]string{"role:qa"}, alice)
+	require.NoError(t, err)
+	listed := env.lastEphemeral()
+	assert.Contains(t, listed, "#### Role: qa")
+	assert.Contains(t, listed, "2. test the upgrade", "the Todos keep their position on the list")
+	assert.NotContains(t, listed, "write the spec")
+
+	_, err = p.runListCommand([]string{InFlag, "role:qa"}, alice)
+	require.NoError(t, err)
+	assert.Contains(t, env.lastEphemeral(), "1. verify the fix")
+
+	// The role is on both sides of a sent todo
+	sent, err := p.listManager.GetIssueList("bob", OutListKey)
+	require.NoError(t, err)
+	require.Len(t, sent, 1)
+	assert.Equal(t, "qa", sent[0].Role)
+
+	_, err = p.runListCommand([]string{"role:design"}, alice)
+	require.NoError(t, err)
+	assert.Equal(t, "Todo List:\n\nNo Todos require the role design.", env.lastEphemeral())
+
+	for _, args := range [][]string{{"role:q/a"}, {"role:"}, {"role:qa", "role:dev"}, {StarredFlag, "role:qa"}, {OutFlag, PendingFlag, "role:qa"}} {
+		isUserError, err := p.runListCommand(args, alice)
+		assert.Error(t, err, args)
+		assert.True(t, isUserError, args)
+	}
+}
+
+func TestAddCommandPriority(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	p := env.newPlugin()
+	alice := &model.CommandArgs{UserId: "alice"}
+
+	_, err := p.runAddCommand([]string{"water", "the", "plants"}, alice)
+	require.NoError(t, err)
+	_, err = p.runAddCommand([]string{"!low", "sort", "the", "photos"}, alice)
+	require.NoError(t, err)
+	_, err = p.runAddCommand([]string{"!HIGH", "renew", "the", "certificates"}, alice)
+	require.NoError(t, err)
+	assert.Contains(t, env.lastEphemeral(), "1. :exclamation: renew the certificates")
+	_, err = p.runAddCommand([]string{"buy", "!high", "milk"}, alice)
+	require.NoError(t, err)
+
+	issues, err := p.listManager.GetIssueList("alice", MyListKey)
+	require.NoError(t, err)
+	require.Len(t, issues, 4)
+	assert.Equal(t, "renew the certificates", issues[0].Message)
+	assert.Equal(t, PriorityHigh, issues[0].Priority)
+	assert.Equal(t, "water the plants", issues[1].Message)
+	assert.Equal(t, "buy !high milk", issues[2].Message, "only a leading token sets the priority")
+	assert.Equal(t, PriorityNormal, issues[2].Priority)
+	assert.Equal(t, "sort the photos", issues[3].Message)
+	assert.Equal(t, PriorityLow, issues[3].Priority)
+}
+
+func TestPriorityEmojiSetting(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	p := env.newPlugin()
+	alice := &model.CommandArgs{UserId: "alice"}
+
+	for _, args := range [][]string{{"!high", "renew", "the", "certificates"}, {"water", "the", "plants"}, {"!low", "sort", "the", "photos"}} {
+		_, err := p.runAddCommand(args, alice)
+		require.NoError(t, err)
+	}
+
+	for _, args := range [][]string{{"high", ":red_circle:"}, {"normal", ":large_blue_circle:"}, {"low", "⚪"}} {
+		isUserError, err := p.runSettingsCommand(append([]string{"priority_emoji"}, args...), alice)
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+	}
+	assert.Equal(t, "Priority emoji setting is set to high :red_circle:, normal :large_blue_circle: and low ⚪. **Todos are listed with the emoji of their priority.**", env.lastEphemeral())
+
+	_, err := p.runListCommand(nil, alice)
+	require.NoError(t, err)
+	list := env.lastEphemeral()
+	assert.Contains(t, list, "1. :red_circle: renew the certificates")
+	assert.Contains(t, list, "2. :large_blue_circle: water the plants")
+	assert.Contains(t, list, "3. ⚪ sort the photos")
+
+	// none shows nothing and default shows the default emoji again
+	_, err = p.runSettingsCommand([]string{"priority_emoji", "normal", "none"}, alice)
+	require.NoError(t, err)
+	_, err = p.runSettingsCommand([]string{"priority_emoji", "high", "default"}, alice)
+	require.NoError(t, err)
+	_, err = p.runListCommand(nil, alice)
+	require.NoError(t, err)
+	list = env.lastEphemeral()
+	assert.Contains(t, list, "1. :exclamation: renew the certificates")
+	assert.Contains(t, list, "2. water the plants")
+
+	for _, args := range [][]string{{"priority_emoji", "urgent", ":fire:"}, {"priority_emoji", "high", "fire"}, {"priority_emoji", "high"}} {
+		isUserError, err := p.runSettingsCommand(args, alice)
+		assert.Error(t, err, args)
+		assert.True(t, isUserError, args)
+	}
+}
+
+func TestDefaultPrioritySetting(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+	alice := &model.CommandArgs{UserId: "alice"}
+
+	_, err := p.runSettingsCommand([]string{"default_priority"}, alice)
+	require.NoError(t, err)
+	assert.Equal(t, "Default priority setting is set to `normal`. **The Todos you add without choosing a priority are normal priority.**", env.lastEphemeral())
+
+	isUserError, err := p.runSettingsCommand([]string{"default_priority", "high"}, alice)
+	require.NoError(t, err)
+	assert.False(t, isUserError)
+	assert.Equal(t, "The Todos you add without choosing a priority will be high priority.", env.lastEphemeral())
+
+	_, err = p.runAddCommand([]string{"renew", "the", "certificates"}, alice)
+	require.NoError(t, err)
+	_, err = p.runAddCommand([]string{"!normal", "water", "the", "plants"}, alice)
+	require.NoError(t, err)
+	_, err = p.runAddCommand([]string{"!low", "sort", "the", "photos"}, alice)
+	require.NoError(t, err)
+	w := env.serve(p, "alice", http.MethodPost, "/add", addAPIRequest{Message: "from the webapp"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = env.serve(p, "alice", http.MethodPost, "/add", addAPIRequest{Message: "normal from the webapp", Priority: "normal"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	issues, err := p.listManager.GetIssueList("alice", MyListKey)
+	require.NoError(t, err)
+	priorities := map[string]int{}
+	for _, issue := range issues {
+		priorities[issue.Message] = issue.Priority
+	}
+	assert.Equal(t, map[string]int{
+		"renew the certificates": PriorityHigh,
+		"water the plants":       PriorityNormal,
+		"sort the photos":        PriorityLow,
+		"from the webapp":        PriorityHigh,
+		"normal from the webapp": PriorityNormal,
+	}, priorities)
+
+	// The default is only for the Todos added to one's own list
+	_, err = p.runSendCommand([]string{"@bob", "review", "the", "spec"}, alice)
+	require.NoError(t, err)
+	received, err := p.listManager.GetIssueList("bob", InListKey)
+	require.NoError(t, err)
+	require.Len(t, received, 1)
+	assert.Equal(t, PriorityNormal, received[0].Priority)
+
+	for _, args := range [][]string{{"default_priority", "urgent"}, {"default_priority", "high", "low"}} {
+		isUserError, err := p.runSettingsCommand(args, alice)
+		assert.Error(t, err, args)
+		assert.True(t, isUserError, args)
+	}
+}
+
+func TestStatsCommand(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+	alice := &model.CommandArgs{UserId: "alice"}
+
+	_, err := p.runAddCommand([]string{"write", "the", "spec", "points:5"}, alice)
+	require.NoError(t, err)
+	_, err = p.runAddCommand([]string{"review", "points:3"}, alice)
+	require.NoError(t, err)
+	_, err = p.runAddCommand([]string{"unestimated"}, alice)
+	require.NoError(t, err)
+	_, err = p.runSendCommand([]string{"@alice", "deploy", "points:2"}, &model.CommandArgs{UserId: "bob"})
+	require.NoError(t, err)
+
+	mine, err := p.listManager.GetIssueList("alice", MyListKey)
+	require.NoError(t, err)
+	require.Len(t, mine, 3)
+	assert.Equal(t, "write the spec", mine[0].Message)
+	assert.Equal(t, 5, mine[0].Points)
+	assert.Equal(t, 8, totalPoints(mine))
+
+	// The points are on both sides of a sent todo
+	sent, err := p.listManager.GetIssueList("bob", OutListKey)
+	require.NoError(t, err)
+	require.Len(t, sent, 1)
+	assert.Equal(t, 2, sent[0].Points)
+
+	_, err = p.runStatsCommand(nil, alice)
+	require.NoError(t, err)
+	assert.Equal(t, "Your Todos:\n* Your list: 3 open, 8 points\n* Incoming: 1 open, 2 points\n\nTotal: 10 points", env.lastEphemeral())
+
+	isUserError, err := p.runAddCommand([]string{"review", "points:-2"}, alice)
+	assert.Error(t, err)
+	assert.True(t, isUserError)
+}
+
+func TestSendUntracked(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+
+	_, err := p.runSendCommand([]string{"@bob", "water", "the", "plants", "track:off"}, &model.CommandArgs{UserId: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, "Todo sent to @bob. It is not kept on your outgoing list, and you will not be notified about it.", env.lastEphemeral())
+
+	sent, err := p.listManager.GetIssueList("alice", OutListKey)
+	require.NoError(t, err)
+	assert.Empty(t, sent)
+
+	received, err := p.listManager.GetIssueList("bob", InListKey)
+	require.NoError(t, err)
+	require.Len(t, received, 1)
+	assert.Equal(t, "water the plants", received[0].Message)
+	assert.Equal(t, "alice", received[0].SentByUser)
+	assert.Contains(t, issueToString(received[0]), "* From: @alice (not tracked by them)\n")
+	assert.Len(t, env.postsTo("dm_bob"), 1)
+
+	// Accepting and completing it does not notify the sender
+	w := env.serve(p, "bob", http.MethodPost, "/accept", acceptAPIRequest{ID: received[0].ID})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = env.serve(p, "bob", http.MethodPost, "/complete", completeAPIRequest{ID: received[0].ID})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Empty(t, env.postsTo("dm_alice"))
+
+	mine, err := p.listManager.GetIssueList("bob", MyListKey)
+	require.NoError(t, err)
+	assert.Empty(t, mine)
+
+	isUserError, err := p.runSendCommand([]string{"@bob", "review", "track:maybe"}, &model.CommandArgs{UserId: "alice"})
+	assert.Error(t, err)
+	assert.True(t, isUserError)
+}
+
+func TestListOutByAcceptance(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+	alice := &model.CommandArgs{UserId: "alice"}
+
+	for _, message := range []string{"first", "second", "third"} {
+		_, err := p.runSendCommand([]string{"@bob", message}, alice)
+		require.NoError(t, err)
+	}
+	received, err := p.listManager.GetIssueList("bob", InListKey)
+	require.NoError(t, err)
+	require.Len(t, received, 3)
+	_, _, err = p.listManager.AcceptIssue("bob", received[1].ID)
+	require.NoError(t, err)
+
+	_, err = p.runListCommand([]string{OutFlag, PendingFlag}, alice)
+	require.NoError(t, err)
+	assert.Contains(t, env.lastEphemeral(), "#### Not accepted yet\n\n1. first\n")
+	assert.Contains(t, env.lastEphemeral(), "3. third\n")
+	assert.NotContains(t, env.lastEphemeral(), "second")
+
+	_, err = p.runListCommand([]string{OutFlag, AcceptedFlag}, alice)
+	require.NoError(t, err)
+	assert.Contains(t, env.lastEphemeral(), "#### Accepted\n\n2. second\n")
+	assert.NotContains(t, env.lastEphemeral(), "first")
+
+	isUserError, err := p.runListCommand([]string{OutFlag, "done"}, alice)
+	assert.Error(t, err)
+	assert.True(t, isUserError)
+}
+
+func TestThreadCommand(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+
+	postID := model.NewId()
+	_, err := p.listManager.AddIssue("alice", "unrelated", "", "", IssueFields{})
+	require.NoError(t, err)
+	_, err = p.listManager.AddIssue("alice", "follow up", "", postID, IssueFields{})
+	require.NoError(t, err)
+	_, err = p.listManager.SendIssue("alice", "bob", "review it", "", postID, IssueFields{})
+	require.NoError(t, err)
+	_, err = p.listManager.AddIssue("bob", "bob's own", "", postID, IssueFields{})
+	require.NoError(t, err)
+
+	_, err = p.runThreadCommand([]string{"https://example.com/team/pl/" + postID}, &model.CommandArgs{UserId: "alice"})
+	require.NoError(t, err)
+	response := env.lastEphemeral()
+	assert.Contains(t, response, "#### Your list\n\n2. follow up")
+	assert.Contains(t, response, "#### Sent\n\n1. review it")
+	assert.NotContains(t, response, "unrelated")
+	assert.NotContains(t, response, "bob's own", "only the caller's todos are listed")
+
+	_, err = p.runThreadCommand([]string{model.NewId()}, &model.CommandArgs{UserId: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, "You have no Todos attached to that post.", env.lastEphemeral())
+
+	isUserError, err := p.runThreadCommand([]string{"not a permalink"}, &model.CommandArgs{UserId: "alice"})
+	assert.Error(t, err)
+	assert.True(t, isUserError)
+}
+
+func TestMentionsCommand(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	env.addUser("bobby", "bobby")
+	p := env.newPlugin()
+
+	_, err := p.listManager.AddIssue("alice", "send the draft to @bob for review", "", "", IssueFields{})
+	require.NoError(t, err)
+	_, err = p.listManager.AddIssue("alice", "lunch with @bobby", "", "", IssueFields{})
+	require.NoError(t, err)
+	_, err = p.listManager.SendIssue("alice", "bob", "update the docs", "ask @Bob.", "", IssueFields{})
+	require.NoError(t, err)
+
+	_, err = p.runMentionsCommand([]string{"@bob"}, &model.CommandArgs{UserId: "alice"})
+	require.NoError(t, err)
+	response := env.lastEphemeral()
+	assert.Contains(t, response, "Todos mentioning @bob:")
+	assert.Contains(t, response, "#### Your list\n\n1. send the draft to @bob for review")
+	assert.Contains(t, response, "#### Sent\n\n1. update the docs")
+	assert.NotContains(t, response, "lunch")
+
+	_, err = p.runMentionsCommand([]string{"bob"}, &model.CommandArgs{UserId: "bob"})
+	require.NoError(t, err)
+	assert.Contains(t, env.lastEphemeral(), "#### Received\n\n1. update the docs")
+
+	_, err = p.runMentionsCommand([]string{"@alice"}, &model.CommandArgs{UserId: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, "None of your Todos mention @alice.", env.lastEphemeral())
+
+	isUserError, err := p.runMentionsCommand(nil, &model.CommandArgs{UserId: "alice"})
+	assert.Error(t, err)
+	assert.True(t, isUserError)
+}
+
+func TestPopOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		order     string
+		withDue   bool
+		want      string
+		wantReply string
+	}{
+		{name: "Top", order: "top", withDue: true, want: "first", wantReply: "Removed top Todo."},
+		{name: "Most urgent", order: "urgent", withDue: true, want: "overdue", wantReply: "Removed most urgent Todo."},
+		{name: "Most urgent without due dates", order: "urgent", want: "first", wantReply: "Removed most urgent Todo."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv()
+			env.addUser("alice", "alice")
+			p := env.newPlugin()
+			extra := &model.CommandArgs{UserId: "alice"}
+
+			now := model.GetMillis()
+			for _, todo := range []struct {
+				message string
+				dueAt   int64
+			}{
+				{"first", 0},
+				{"later", now + 48*time.Hour.Milliseconds()},
+				{"overdue", now - time.Hour.Milliseconds()},
+				{"tomorrow", now + 24*time.Hour.Milliseconds()},
+			} {
+				var dueAt int64
+				if tt.withDue {
+					dueAt = todo.dueAt
+				}
+				_, err := p.listManager.AddIssue("alice", todo.message, "", "", IssueFields{DueAt: dueAt})
+				require.NoError(t, err)
+			}
+
+			_, err := p.runSettingsCommand([]string{"pop_order", tt.order}, extra)
+			require.NoError(t, err)
+			_, err = p.runPopCommand(nil, extra)
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(env.lastEphemeral(), tt.wantReply), env.lastEphemeral())
+
+			issues, err := p.listManager.GetIssueList("alice", MyListKey)
+			require.NoError(t, err)
+			require.Len(t, issues, 3)
+			for _, issue := range issues {
+				assert.NotEqual(t, tt.want, issue.Message)
+			}
+		})
+	}
+}
+
+func TestPopFollowsPriorityOrder(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+	extra := &model.CommandArgs{UserId: "alice"}
+
+	_, err := p.listManager.AddIssue("alice", "normal first", "", "", IssueFields{})
+	require.NoError(t, err)
+	_, err = p.listManager.AddIssue("alice", "high added later", "", "", IssueFields{Priority: PriorityHigh})
+	require.NoError(t, err)
+
+	_, err = p.runListCommand(nil, extra)
+	require.NoError(t, err)
+	assert.Contains(t, env.lastEphemeral(), "1. :exclamation: high added later")
+
+	_, err = p.runPopCommand(nil, extra)
+	require.NoError(t, err)
+	issues, err := p.listManager.GetIssueList("alice", MyListKey)
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	assert.Equal(t, "normal first", issues[0].Message, "pop removes the Todo shown at the top")
+
+	// The most urgent Todo goes first among the ones of the same priority too
+	now := model.GetMillis()
+	_, err = p.listManager.AddIssue("alice", "high later", "", "", IssueFields{DueAt: now + 2*time.Hour.Milliseconds(), Priority: PriorityHigh})
+	require.NoError(t, err)
+	_, err = p.listManager.AddIssue("alice", "high sooner", "", "", IssueFields{DueAt: now + time.Hour.Milliseconds(), Priority: PriorityHigh})
+	require.NoError(t, err)
+	_, err = p.runSettingsCommand([]string{"pop_order", "urgent"}, extra)
+	require.NoError(t, err)
+	_, err = p.runPopCommand(nil, extra)
+	require.NoError(t, err)
+	issues, err = p.listManager.GetIssueList("alice", MyListKey)
+	require.NoError(t, err)
+	messages := []string{}
+	for _, issue := range issues {
+		messages = append(messages, issue.Message)
+	}
+	assert.Equal(t, []string{"high later", "normal first"}, messages)
+}
+
+func TestListCommandRefreshesOnlyTheViewedList(t *testing.T) {
+	env := newTestEnv()
+	p := env.newPlugin()
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{args: nil, want: []string{MyListKey}},
+		{args: []string{InFlag}, want: []string{InListKey}},
+		{args: []string{OutFlag}, want: []string{OutListKey}},
+		{args: []string{SomedayFlag}, want: []string{SomedayListKey}},
+		{args: []string{StarredFlag}, want: []string{MyListKey, OutListKey, InListKey}},
+		{args: []string{ByChannelFlag}, want: []string{MyListKey}},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			env.resetRecords()
+			_, err := p.runListCommand(tt.args, &model.CommandArgs{UserId: "alice"})
+			require.NoError(t, err)
+			assert.Equal(t, []testRefreshEvent{{"alice", tt.want}}, env.refreshes())
+		})
+	}
+
+	env.resetRecords()
+	_, err := p.runInboxCommand([]string{BySenderFlag}, &model.CommandArgs{UserId: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, []testRefreshEvent{{"alice", []string{InListKey}}}, env.refreshes())
+}
+
+func TestSelfSendModes(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	p := env.newPlugin()
+	extra := &model.CommandArgs{UserId: "alice"}
+
+	_, err := p.runSendCommand([]string{"@alice", "straight", "to", "my", "list"}, extra)
+	require.NoError(t, err)
+	w := env.serve(p, "alice", http.MethodPost, "/add", addAPIRequest{Message: "from the webapp", SendTo: "alice"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	myList, err := p.listManager.GetIssueList("alice", MyListKey)
+	require.NoError(t, err)
+	require.Len(t, myList, 2)
+	assert.Equal(t, "straight to my list", myList[0].Message)
+	assert.Equal(t, "from the webapp", myList[1].Message)
+
+	_, err = p.runSettingsCommand([]string{"self_send", "inbox"}, extra)
+	require.NoError(t, err)
+	env.resetRecords()
+
+	_, err = p.runSendCommand([]string{"@alice", "triage", "this"}, extra)
+	require.NoError(t, err)
+	assert.Equal(t, "Todo placed on your incoming list.", env.lastEphemeral())
+	w = env.serve(p, "alice", http.MethodPost, "/add", addAPIRequest{Message: "and this", SendTo: "alice"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Empty(t, env.postsTo("dm_alice"), "self sends are not announced with a DM")
+
+	inbox, err := p.listManager.GetIssueList("alice", InListKey)
+	require.NoError(t, err)
+	require.Len(t, inbox, 2)
+	assert.Equal(t, "triage this", inbox[0].Message)
+	assert.Empty(t, inbox[0].SentByUser)
+	outList, err := p.listManager.GetIssueList("alice", OutListKey)
+	require.NoError(t, err)
+	assert.Empty(t, outList, "self sends are not tracked as sent")
+
+	// Accepting a self sent todo moves it to the list like any other
+	_, _, err = p.listManager.AcceptIssue("alice", inbox[0].ID)
+	require.NoError(t, err)
+	myList, err = p.listManager.GetIssueList("alice", MyListKey)
+	require.NoError(t, err)
+	assert.Len(t, myList, 3)
+}
+
+func TestSendCommandInfersRecipientFromDM(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	env.addUser("carol", "carol")
+	p := env.newPlugin()
+	env.api.On("GetChannel", "alice_bob").Return(&model.Channel{Id: "alice_bob", Name: model.GetDMNameFromIds("alice", "bob"), Type: model.CHANNEL_DIRECT}, nil)
+	env.api.On("GetChannel", "town").Return(&model.Channel{Id: "town", Name: "town-square", Type: model.CHANNEL_OPEN}, nil)
+	inDM := &model.CommandArgs{UserId: "alice", ChannelId: "alice_bob"}
+
+	_, err := p.runSendCommand([]string{"review", "the", "plan"}, inDM)
+	require.NoError(t, err)
+	assert.Equal(t, "Todo sent to @bob.", env.lastEphemeral())
+
+	// Explicit recipients still override the DM participant
+	_, err = p.runSendCommand([]string{"@carol", "book", "a", "room"}, inDM)
+	require.NoError(t, err)
+	assert.Equal(t, "Todo sent to @carol.", env.lastEphemeral())
+
+	_, err = p.runSendCommand([]string{"@nobody", "lost"}, inDM)
+	require.NoError(t, err)
+	assert.Contains(t, env.lastEphemeral(), "Please, provide a valid user.")
+
+	// Outside of a DM a recipient is still needed
+	_, err = p.runSendCommand([]string{"review", "the", "plan"}, &model.CommandArgs{UserId: "alice", ChannelId: "town"})
+	require.NoError(t, err)
+	assert.Contains(t, env.lastEphemeral(), "Please, provide a valid user.")
+
+	bobs, err := p.listManager.GetIssueList("bob", InListKey)
+	require.NoError(t, err)
+	require.Len(t, bobs, 1)
+	assert.Equal(t, "review the plan", bobs[0].Message)
+	carols, err := p.listManager.GetIssueList("carol", InListKey)
+	require.NoError(t, err)
+	require.Len(t, carols, 1)
+	assert.Equal(t, "book a room", carols[0].Message)
+}
+
+func TestExtractNoteTag(t *testing.T) {
+	tests := []struct {
+		args     []string
+		wantRest []string
+		wantNote string
+	}{
+		{args: []string{"2"}, wantRest: []string{"2"}, wantNote: ""},
+		{args: []string{"2", "note:", "deployed"}, wantRest: []string{"2"}, wantNote: "deployed"},
+		{args: []string{"in", "1", "note:all", "done", "note: too"}, wantRest: []string{"in", "1"}, wantNote: "all done note: too"},
+		{args: []string{"1", "note:"}, wantRest: []string{"1"}, wantNote: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			rest, note := extractNoteTag(tt.args)
+			assert.Equal(t, tt.wantRest, rest)
+			assert.Equal(t, tt.wantNote, note)
+		})
+	}
+}
+
+func TestCompleteCommandByMessage(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	p := env.newPlugin()
+	alice := &model.CommandArgs{UserId: "alice"}
+
+	for _, message := range []string{"water the plants", "Water", "water the lawn", "call the bank", "water the lawn"} {
+		_, err := p.listManager.AddIssue("alice", message, "", "", IssueFields{})
+		require.NoError(t, err)
+	}
+
+	// The whole message is matched, ignoring case
+	_, err := p.runCompleteCommand([]string{`"CALL`, `THE`, `BANK"`}, alice)
+	require.NoError(t, err)
+	assert.Equal(t, "Completed Todo: call the bank", env.lastEphemeral())
+
+	_, err = p.runCompleteCommand([]string{`"water"`}, alice)
+	require.NoError(t, err)
+	assert.Equal(t, "Completed Todo: Water", env.lastEphemeral())
+
+	// Part of a message matches nothing, even when only one Todo contains it
+	isUserError, err := p.runCompleteCommand([]string{`"plants"`}, alice)
+	require.Error(t, err)
+	assert.True(t, isUserError)
+	assert.Contains(t, err.Error(), "there is no Todo matching `plants`")
+
+	isUserError, err = p.runCompleteCommand([]string{`"water`, `the`, `lawn"`}, alice)
+	require.Error(t, err)
+	assert.True(t, isUserError)
+	assert.Equal(t, "`water the lawn` matches 2 Todos, use the position of one of them instead: 2. water the lawn, 3. water the lawn", err.Error())
+
+	isUserError, err = p.runCompleteCommand([]string{OutFlag, `"water the plants"`}, alice)
+	assert.Error(t, err)
+	assert.True(t, isUserError)
+
+	_, err = p.runCompleteCommand([]string{MyFlag, `"water`, `the`, `plants"`}, alice)
+	require.NoError(t, err)
+	issues, err := p.listManager.GetIssueList("alice", MyListKey)
+	require.NoError(t, err)
+	require.Len(t, issues, 2)
+	assert.Equal(t, "water the lawn", issues[0].Message)
+}
+
+func TestTodoCommandsAutocomplete(t *testing.T) {
+	autocomplete := getAutocompleteData()
+	require.Len(t, autocomplete.SubCommands, len(todoCommands))
+
+	seen := map[string]bool{}
+	for i, command := range todoCommands {
+		assert.False(t, seen[command.name], "%s is registered twice", command.name)
+		seen[command.name] = true
+
+		require.NotNil(t, command.autocomplete, command.name)
+		assert.Equal(t, command.name, command.autocomplete().Trigger, "the autocomplete of %s suggests another command", command.name)
+		assert.Equal(t, command.name, autocomplete.SubCommands[i].Trigger, "the commands are suggested in the order they are registered")
+		assert.Contains(t, getHelp(), "\n"+command.name, "%s is missing from the help", command.name)
+		assert.Contains(t, autocomplete.HelpText, command.name)
+		assert.Contains(t, getCommand().AutoCompleteDesc, command.name)
+	}
+
+	for _, name := range []string{"add", "list", "complete", "search", "settings", "focus"} {
+		command := findTodoCommand(name)
+		require.NotNil(t, command, name)
+		assert.NotNil(t, command.handler, "%s should be dispatched", name)
+	}
+	assert.Nil(t, findTodoCommand("help").handler, "help has nothing to run but the help")
+	assert.Nil(t, findTodoCommand("remove"))
+}
+
+func TestExecuteCommandDispatch(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	p := env.newPlugin()
+
+	_, appErr := p.ExecuteCommand(nil, &model.CommandArgs{UserId: "alice", Command: "/todo add buy milk"})
+	require.Nil(t, appErr)
+	_, appErr = p.ExecuteCommand(nil, &model.CommandArgs{UserId: "alice", Command: "/todo   search  milk"})
+	require.Nil(t, appErr)
+	assert.Contains(t, env.lastEphemeral(), "Todos matching `milk`:")
+
+	for _, command := range []string{"/todo help", "/todo unknown"} {
+		_, appErr = p.ExecuteCommand(nil, &model.CommandArgs{UserId: "alice", Command: command})
+		require.Nil(t, appErr)
+		assert.Equal(t, getHelp(), env.lastEphemeral(), command)
+	}
+}
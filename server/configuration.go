@@ -2,6 +2,7 @@ package main
 
 import (
 	"reflect"
+	"strings"
 
 	"github.com/mattermost/mattermost-plugin-api/experimental/bot/logger"
 	"github.com/mattermost/mattermost-plugin-api/experimental/telemetry"
@@ -20,7 +21,14 @@ import (
 // If you add non-reference types to your configuration struct, be sure to rewrite Clone as a deep
 // copy appropriate for your types.
 type configuration struct {
-	HideTeamSidebar bool `json:"hide_team_sidebar"`
+	HideTeamSidebar              bool   `json:"hide_team_sidebar"`
+	EnableSendToOthers           bool   `json:"enable_send_to_others"`
+	RestrictSendToChannelMembers bool   `json:"restrict_send_to_channel_members"`
+	ReplyOnlyOnCreateAndComplete bool   `json:"reply_only_on_create_and_complete"`
+	QuietThreadChannels          string `json:"quiet_thread_channels"`
+	MaxReminderItems             int    `json:"max_reminder_items"`
+	AnnouncementChannelID        string `json:"announcement_channel_id"`
+	AuditChannelID               string `json:"audit_channel_id"`
 }
 
 // Clone shallow copies the configuration. Your implementation may require a deep copy if
@@ -31,9 +39,30 @@ func (c *configuration) Clone() *configuration {
 }
 
 func (c *configuration) IsValid() error {
+	if c.MaxReminderItems < 0 {
+		return errors.New("the maximum number of Todos on the daily reminder cannot be negative")
+	}
 	return nil
 }
 
+// getMaxReminderItems returns the number of todos listed on the daily reminder, DefaultMaxReminderItems if it is not set
+func (c *configuration) getMaxReminderItems() int {
+	if c.MaxReminderItems <= 0 {
+		return DefaultMaxReminderItems
+	}
+	return c.MaxReminderItems
+}
+
+// isQuietThreadChannel checks whether channelID is one of the QuietThreadChannels, where threads are not replied on
+func (c *configuration) isQuietThreadChannel(channelID string) bool {
+	for _, quietID := range strings.Split(c.QuietThreadChannels, ",") {
+		if quietID = strings.TrimSpace(quietID); quietID != "" && quietID == channelID {
+			return true
+		}
+	}
+	return false
+}
+
 // getConfiguration retrieves the active configuration under lock, making it safe to use
 // concurrently. The active configuration may change underneath the client of this method, but
 // the struct returned by this API call is considered immutable.
@@ -77,7 +106,9 @@ func (p *Plugin) setConfiguration(configuration *configuration) {
 
 // Check whether client configuration are different
 func (p *Plugin) hasClientConfigChanged(prev *configuration, current *configuration) bool {
-	return prev == nil || prev.HideTeamSidebar != current.HideTeamSidebar
+	return prev == nil ||
+		prev.HideTeamSidebar != current.HideTeamSidebar ||
+		prev.EnableSendToOthers != current.EnableSendToOthers
 }
 
 // OnConfigurationChange is invoked when configuration changes may have been made.
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ChannelMembersPerPage is the number of channel members fetched at once when counting their todos
+	ChannelMembersPerPage = 200
+	// MaxChannelCountsMembers is the number of channel members whose todos are counted at most, the members past it
+	// are left out of the counts of large channels
+	MaxChannelCountsMembers = 1000
+)
+
+// channelMemberCount is how many todos a channel member has on their list
+type channelMemberCount struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	Count    int    `json:"count"`
+}
+
+// API endpoint to get how many todos each member of a channel has on their list, for the members who chose to share it
+func (p *Plugin) handleChannelCounts(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	channelID := r.URL.Query().Get("channel_id")
+	if _, appErr := p.API.GetChannelMember(channelID, userID); appErr != nil {
+		p.handleErrorWithCode(w, http.StatusForbidden, "Unable to get the counts of the channel", errors.Wrap(appErr, "not a member of the channel"))
+		return
+	}
+
+	counts := []*channelMemberCount{}
+	for page := 0; page*ChannelMembersPerPage < MaxChannelCountsMembers; page++ {
+		members, appErr := p.API.GetChannelMembers(channelID, page, ChannelMembersPerPage)
+		if appErr != nil {
+			p.API.LogError("Unable to get channel members err=" + appErr.Error())
+			p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to get the counts of the channel", appErr)
+			return
+		}
+
+		for _, member := range *members {
+			if !p.getShareCountsPreference(member.UserId) {
+				continue
+			}
+
+			count, err := p.listManager.CountIssues(member.UserId, MyListKey)
+			if err != nil {
+				p.API.LogError("Unable to count issues for user err=" + err.Error())
+				p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to get the counts of the channel", err)
+				return
+			}
+			counts = append(counts, &channelMemberCount{
+				UserID:   member.UserId,
+				Username: p.listManager.GetUserName(member.UserId),
+				Count:    count,
+			})
+		}
+
+		if len(*members) < ChannelMembersPerPage {
+			break
+		}
+	}
+
+	countsJSON, err := json.Marshal(counts)
+	if err != nil {
+		p.API.LogError("Unable marhsal channel counts to json err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable marhsal channel counts to json", err)
+		return
+	}
+
+	_, err = w.Write(countsJSON)
+	if err != nil {
+		p.API.LogError("Unable to write json response err=" + err.Error())
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleChannelCounts(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	env.addUser("carol", "carol")
+	p := env.newPlugin()
+	env.api.On("GetChannelMember", "standup", "alice").Return(&model.ChannelMember{ChannelId: "standup", UserId: "alice"}, nil)
+	env.api.On("GetChannelMember", "standup", "dave").Return(nil, model.NewAppError("GetChannelMember", "not_found", nil, "", http.StatusNotFound))
+	env.api.On("GetChannelMembers", "standup", 0, ChannelMembersPerPage).Return(&model.ChannelMembers{
+		{ChannelId: "standup", UserId: "alice"},
+		{ChannelId: "standup", UserId: "bob"},
+		{ChannelId: "standup", UserId: "carol"},
+	}, nil)
+
+	for _, userID := range []string{"bob", "bob", "carol"} {
+		_, err := p.listManager.AddIssue(userID, "work", "", "", IssueFields{})
+		require.NoError(t, err)
+	}
+	_, err := p.runSettingsCommand([]string{"share_counts", "on"}, &model.CommandArgs{UserId: "bob"})
+	require.NoError(t, err)
+	_, err = p.runSettingsCommand([]string{"share_counts", "on"}, &model.CommandArgs{UserId: "alice"})
+	require.NoError(t, err)
+
+	w := env.serve(p, "alice", http.MethodGet, "/channel/counts?channel_id=standup", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var counts []*channelMemberCount
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &counts))
+	assert.Equal(t, []*channelMemberCount{
+		{UserID: "alice", Username: "alice", Count: 0},
+		{UserID: "bob", Username: "bob", Count: 2},
+	}, counts, "members who did not share their counts are left out")
+
+	w = env.serve(p, "dave", http.MethodGet, "/channel/counts?channel_id=standup", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestHandleChannelCountsIsCapped(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	p := env.newPlugin()
+	env.api.On("GetChannelMember", "town-square", "alice").Return(&model.ChannelMember{ChannelId: "town-square", UserId: "alice"}, nil)
+	members := model.ChannelMembers{}
+	for i := 0; i < ChannelMembersPerPage; i++ {
+		members = append(members, model.ChannelMember{ChannelId: "town-square", UserId: fmt.Sprintf("user%d", i)})
+	}
+	env.api.On("GetChannelMembers", "town-square", mock.AnythingOfType("int"), ChannelMembersPerPage).Return(&members, nil)
+
+	w := env.serve(p, "alice", http.MethodGet, "/channel/counts?channel_id=town-square", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	env.api.AssertNumberOfCalls(t, "GetChannelMembers", MaxChannelCountsMembers/ChannelMembersPerPage)
+}
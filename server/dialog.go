@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+const (
+	// MaxDialogMessageLength is the longest message a Todo added through the dialog can have
+	MaxDialogMessageLength = 200
+	// MaxDialogDescriptionLength is the longest description a Todo added through the dialog can have
+	MaxDialogDescriptionLength = 3000
+
+	addDialogCallbackID = "add_todo"
+)
+
+type dialogAddAPIRequest struct {
+	TriggerID string `json:"trigger_id"`
+}
+
+func getAddDialog(enableSendToOthers bool) model.Dialog {
+	elements := []model.DialogElement{{
+		DisplayName: "Todo",
+		Name:        "message",
+		Type:        "text",
+		Placeholder: "What needs to be done?",
+		MaxLength:   MaxDialogMessageLength,
+	}, {
+		DisplayName: "Description",
+		Name:        "description",
+		Type:        "textarea",
+		Optional:    true,
+		MaxLength:   MaxDialogDescriptionLength,
+	}}
+
+	if enableSendToOthers {
+		elements = append(elements, model.DialogElement{
+			DisplayName: "Send to",
+			Name:        "send_to",
+			Type:        "select",
+			DataSource:  "users",
+			Optional:    true,
+			HelpText:    "Leave it empty to add the Todo to your own list.",
+		})
+	}
+
+	return model.Dialog{
+		CallbackId:  addDialogCallbackID,
+		Title:       "Add a Todo",
+		SubmitLabel: "Add",
+		Elements:    elements,
+	}
+}
+
+// API endpoint opening the dialog to add a Todo on the client that generated the trigger ID
+func (p *Plugin) handleDialogAdd(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	var dialogRequest *dialogAddAPIRequest
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&dialogRequest); err != nil {
+		p.API.LogError("Unable to decode JSON err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to decode JSON", err)
+		return
+	}
+
+	appErr := p.API.OpenInteractiveDialog(model.OpenDialogRequest{
+		TriggerId: dialogRequest.TriggerID,
+		URL:       fmt.Sprintf("/plugins/%s/dialog/add/submit", manifest.Id),
+		Dialog:    getAddDialog(p.getConfiguration().EnableSendToOthers),
+	})
+	if appErr != nil {
+		p.API.LogError("Unable to open the add dialog err=" + appErr.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to open the add dialog", appErr)
+		return
+	}
+}
+
+// API endpoint handling the submission of the dialog to add a Todo
+func (p *Plugin) handleDialogAddSubmit(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	var submitRequest *model.SubmitDialogRequest
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&submitRequest); err != nil {
+		p.API.LogError("Unable to decode JSON err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to decode JSON", err)
+		return
+	}
+
+	if submitRequest.Cancelled {
+		return
+	}
+
+	message, description, receiver, fieldErrors := p.validateAddDialogSubmission(userID, submitRequest.Submission)
+	if len(fieldErrors) > 0 {
+		p.writeDialogResponse(w, &model.SubmitDialogResponse{Errors: fieldErrors})
+		return
+	}
+
+	senderName := p.listManager.GetUserName(userID)
+
+	if receiver == nil {
+		if _, err := p.listManager.AddIssue(userID, message, description, "", IssueFields{}); err != nil {
+			p.API.LogError("Unable to add issue err=" + err.Error())
+			p.writeDialogResponse(w, &model.SubmitDialogResponse{Error: "Unable to add the Todo"})
+			return
+		}
+
+		p.trackAddIssue(userID, sourceWebapp, false)
+
+		p.sendRefreshEvent(userID, []string{MyListKey})
+		return
+	}
+
+	issueID, err := p.listManager.SendIssue(userID, receiver.Id, message, description, "", IssueFields{})
+	if err != nil {
+		p.API.LogError("Unable to send issue err=" + err.Error())
+		p.writeDialogResponse(w, &model.SubmitDialogResponse{Error: "Unable to send the Todo"})
+		return
+	}
+
+	p.trackSendIssue(userID, sourceWebapp, false)
+	p.rememberRecipient(userID, receiver.Id)
+
+	p.sendRefreshEvent(userID, []string{OutListKey})
+	p.sendRefreshEvent(receiver.Id, []string{InListKey})
+
+	receiverMessage := fmt.Sprintf("You have received a new Todo from @%s", senderName)
+	p.PostBotCustomDM(receiver.Id, receiverMessage, message, issueID)
+}
+
+// validateAddDialogSubmission checks the fields submitted on the add dialog, returning the fieldErrors by field name.
+// The receiver is nil when the Todo is for the user's own list.
+func (p *Plugin) validateAddDialogSubmission(userID string, submission map[string]interface{}) (message, description string, receiver *model.User, fieldErrors map[string]string) {
+	fieldErrors = map[string]string{}
+
+	message, _ = submission["message"].(string)
+	description, _ = submission["description"].(string)
+	message, description, err := sanitizeIssueText(message, description)
+	switch {
+	case err != nil:
+		fieldErrors["message"] = "Please add a Todo."
+	case len([]rune(message)) > MaxDialogMessageLength:
+		fieldErrors["message"] = fmt.Sprintf("The Todo cannot be longer than %d characters.", MaxDialogMessageLength)
+	}
+
+	if len([]rune(description)) > MaxDialogDescriptionLength {
+		fieldErrors["description"] = fmt.Sprintf("The description cannot be longer than %d characters.", MaxDialogDescriptionLength)
+	}
+
+	sendTo, _ := submission["send_to"].(string)
+	if sendTo == "" || sendTo == userID {
+		return message, description, nil, fieldErrors
+	}
+
+	if !p.getConfiguration().EnableSendToOthers {
+		fieldErrors["send_to"] = "Sending Todos to other users is disabled on this server."
+		return message, description, nil, fieldErrors
+	}
+
+	receiver, appErr := p.API.GetUser(sendTo)
+	if appErr != nil {
+		fieldErrors["send_to"] = "Unable to find the user."
+		return message, description, nil, fieldErrors
+	}
+
+	allowIncoming, err := p.getAllowIncomingTaskRequestsPreference(receiver.Id)
+	if err != nil {
+		p.API.LogError("Error when getting allow incoming task request preference, err=", err)
+		allowIncoming = true
+	}
+	if !allowIncoming {
+		fieldErrors["send_to"] = fmt.Sprintf("@%s has blocked Todo requests.", receiver.Username)
+	}
+	if _, ok := fieldErrors["description"]; !ok && len([]rune(description)) > MaxSharedDescriptionLength {
+		fieldErrors["description"] = fmt.Sprintf("The description of a Todo sent to someone else cannot be longer than %d characters.", MaxSharedDescriptionLength)
+	}
+
+	return message, description, receiver, fieldErrors
+}
+
+func (p *Plugin) writeDialogResponse(w http.ResponseWriter, response *model.SubmitDialogResponse) {
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		p.API.LogError("Unable to marshal dialog response to json err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to marshal dialog response to json", err)
+		return
+	}
+
+	_, err = w.Write(responseJSON)
+	if err != nil {
+		p.API.LogError("Unable to write json response err=" + err.Error())
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleDialogAddSubmit(t *testing.T) {
+	tests := []struct {
+		name       string
+		submission map[string]interface{}
+		wantErrors map[string]string
+		wantList   string
+		wantUser   string
+	}{
+		{
+			name:       "Adds to the user's own list",
+			submission: map[string]interface{}{"message": " Write the report ", "description": "Quarterly numbers"},
+			wantUser:   "alice",
+			wantList:   MyListKey,
+		},
+		{
+			name:       "Sends to another user",
+			submission: map[string]interface{}{"message": "Review the report", "send_to": "bob"},
+			wantUser:   "bob",
+			wantList:   InListKey,
+		},
+		{
+			name:       "Message is required",
+			submission: map[string]interface{}{"message": "   ", "description": "Quarterly numbers"},
+			wantErrors: map[string]string{"message": "Please add a Todo."},
+		},
+		{
+			name: "Fields have a maximum length",
+			submission: map[string]interface{}{
+				"message":     strings.Repeat("a", MaxDialogMessageLength+1),
+				"description": strings.Repeat("a", MaxDialogDescriptionLength+1),
+			},
+			wantErrors: map[string]string{
+				"message":     "The Todo cannot be longer than 200 characters.",
+				"description": "The description cannot be longer than 3000 characters.",
+			},
+		},
+		{
+			name:       "Receiver must exist",
+			submission: map[string]interface{}{"message": "Review the report", "send_to": "nobody"},
+			wantErrors: map[string]string{"send_to": "Unable to find the user."},
+		},
+		{
+			name:       "Receiver must accept Todo requests",
+			submission: map[string]interface{}{"message": "Review the report", "send_to": "carol"},
+			wantErrors: map[string]string{"send_to": "@carol has blocked Todo requests."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv()
+			env.addUser("alice", "alice")
+			env.addUser("bob", "bob")
+			env.addUser("carol", "carol")
+			p := env.newPlugin()
+			require.NoError(t, p.saveAllowIncomingTaskRequestsPreference("carol", false))
+
+			w := env.serve(p, "alice", http.MethodPost, "/dialog/add/submit", &model.SubmitDialogRequest{
+				UserId:     "alice",
+				CallbackId: addDialogCallbackID,
+				Submission: tt.submission,
+			})
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+			if tt.wantErrors != nil {
+				var response model.SubmitDialogResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.wantErrors, response.Errors)
+				for _, user := range []string{"alice", "bob", "carol"} {
+					for _, list := range []string{MyListKey, InListKey, OutListKey} {
+						issues, err := p.listManager.GetIssueList(user, list)
+						require.NoError(t, err)
+						assert.Empty(t, issues)
+					}
+				}
+				return
+			}
+
+			assert.Empty(t, w.Body.String())
+			issues, err := p.listManager.GetIssueList(tt.wantUser, tt.wantList)
+			require.NoError(t, err)
+			require.Len(t, issues, 1)
+			assert.Equal(t, strings.TrimSpace(tt.submission["message"].(string)), issues[0].Message)
+		})
+	}
+}
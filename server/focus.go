@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+// MaxQueuedFocusNotifications is the number of notifications held back in focus mode that are listed on the summary
+const MaxQueuedFocusNotifications = 20
+
+// focusSummary renders the notifications held back while a user was in focus mode.
+func focusSummary(focus *focusState) string {
+	if focus.Count == 0 {
+		return "Focus mode is off. Nothing happened while you were focused."
+	}
+
+	str := fmt.Sprintf("Focus mode is off. You got %d notifications while you were focused:\n\n", focus.Count)
+	for _, message := range focus.Queued {
+		str += "* " + strings.ReplaceAll(message, "\n", "\n  ") + "\n"
+	}
+	if more := focus.Count - len(focus.Queued); more > 0 {
+		str += fmt.Sprintf("\nAnd %d more. Check your lists to see everything.", more)
+	}
+	return str
+}
+
+func (p *Plugin) runFocusCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	if len(args) != 1 {
+		return true, errors.New("you must specify on or off")
+	}
+
+	switch args[0] {
+	case "on":
+		started, err := p.startFocus(extra.UserId)
+		if err != nil {
+			return false, err
+		}
+		if !started {
+			p.postCommandResponse(extra, "Focus mode is already on.")
+			return false, nil
+		}
+		p.postCommandResponse(extra, "Focus mode is on. The notifications about your Todos are held back until you turn it off.")
+	case "off":
+		focus, err := p.endFocus(extra.UserId)
+		if err != nil {
+			return false, err
+		}
+		if focus == nil {
+			p.postCommandResponse(extra, "Focus mode is not on.")
+			return false, nil
+		}
+		p.PostBotDM(extra.UserId, focusSummary(focus))
+		p.postCommandResponse(extra, "Focus mode is off.")
+	default:
+		return true, fmt.Errorf("`%s` is not valid, use on or off", args[0])
+	}
+
+	return false, nil
+}
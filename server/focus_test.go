@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFocusSummary(t *testing.T) {
+	assert.Equal(t, "Focus mode is off. Nothing happened while you were focused.", focusSummary(&focusState{}))
+
+	summary := focusSummary(&focusState{Queued: []string{"first", "two\nlines"}, Count: 5})
+	assert.Contains(t, summary, "You got 5 notifications")
+	assert.Contains(t, summary, "* first\n* two\n  lines\n")
+	assert.Contains(t, summary, "And 3 more.")
+}
+
+func TestFocusCommandQueuesSummary(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+	p.setConfiguration(&configuration{EnableSendToOthers: true})
+	extra := &model.CommandArgs{UserId: "alice"}
+
+	_, err := p.runFocusCommand([]string{"on"}, extra)
+	require.NoError(t, err)
+	_, err = p.runFocusCommand([]string{"on"}, extra)
+	require.NoError(t, err)
+	assert.Equal(t, "Focus mode is already on.", env.lastEphemeral())
+
+	for _, message := range []string{"review the design", "fix the build"} {
+		w := env.serve(p, "bob", http.MethodPost, "/add", &addAPIRequest{Message: message, SendTo: "alice"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+	assert.Empty(t, env.postsTo("dm_alice"), "notifications are held back in focus mode")
+
+	_, err = p.runFocusCommand([]string{"off"}, extra)
+	require.NoError(t, err)
+	posts := env.postsTo("dm_alice")
+	require.Len(t, posts, 1)
+	assert.Contains(t, posts[0].Message, "You got 2 notifications")
+	assert.Contains(t, posts[0].Message, "review the design")
+	assert.Contains(t, posts[0].Message, "fix the build")
+
+	w := env.serve(p, "bob", http.MethodPost, "/add", &addAPIRequest{Message: "ship it", SendTo: "alice"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Len(t, env.postsTo("dm_alice"), 2, "notifications are sent again once focus mode is off")
+
+	_, err = p.runFocusCommand([]string{"off"}, extra)
+	require.NoError(t, err)
+	assert.Equal(t, "Focus mode is not on.", env.lastEphemeral())
+
+	isUserError, err := p.runFocusCommand([]string{"maybe"}, extra)
+	assert.Error(t, err)
+	assert.True(t, isUserError)
+}
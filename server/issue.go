@@ -2,18 +2,148 @@ package main
 
 import (
 	"fmt"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
 )
 
+// MaxIssueHistory is the number of changes kept on the history of an issue
+const MaxIssueHistory = 20
+
+// MaxReminderDescriptionLength is the number of characters of a description shown on the daily reminder
+const MaxReminderDescriptionLength = 100
+
+// DefaultMaxReminderItems is the number of todos listed on the daily reminder when it is not configured
+const DefaultMaxReminderItems = 50
+
+// MaxIssueTextLength is the longest a Todo message and its description can be together
+const MaxIssueTextLength = MaxDialogMessageLength + MaxDialogDescriptionLength
+
+// MaxSharedDescriptionLength is the longest description a Todo sent to someone else can have, since it is stored for both users
+const MaxSharedDescriptionLength = 1000
+
+// MaxExternalRefLength is the longest reference an integration can attach to a Todo
+const MaxExternalRefLength = 100
+
+// errEmptyMessage is returned when a Todo is added, sent or edited without a message
+var errEmptyMessage = errors.New("the Todo message cannot be empty")
+
+// errSharedDescriptionTooLong is returned when a Todo is sent with a description longer than MaxSharedDescriptionLength
+var errSharedDescriptionTooLong = errors.Errorf("the description of a sent Todo cannot be longer than %d characters", MaxSharedDescriptionLength)
+
+// The priorities of a Todo. Zero is normal, so the Todos stored before priorities existed are normal.
+const (
+	PriorityLow    = -1
+	PriorityNormal = 0
+	PriorityHigh   = 1
+)
+
+// errInvalidPriority is returned when a priority other than high, normal or low is asked for
+var errInvalidPriority = errors.New("the priority must be high, normal or low")
+
+// parsePriority parses a priority named high, normal or low. An empty name is normal.
+func parsePriority(name string) (int, error) {
+	switch strings.ToLower(name) {
+	case "high":
+		return PriorityHigh, nil
+	case "", "normal":
+		return PriorityNormal, nil
+	case "low":
+		return PriorityLow, nil
+	}
+	return PriorityNormal, errInvalidPriority
+}
+
+// priorityName names priority the way parsePriority parses it.
+func priorityName(priority int) string {
+	switch {
+	case priority > PriorityNormal:
+		return "high"
+	case priority < PriorityNormal:
+		return "low"
+	}
+	return "normal"
+}
+
+// sortByPriority sorts issues from high to low priority, keeping the order of the issues of the same priority.
+func sortByPriority(issues []*ExtendedIssue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Priority > issues[j].Priority
+	})
+}
+
+// errIssueTextTooLong is returned when a Todo is edited past MaxIssueTextLength
+var errIssueTextTooLong = errors.Errorf("the Todo message and description cannot be longer than %d characters together", MaxIssueTextLength)
+
+// sanitizeIssueText trims the message and description of a Todo, and rejects messages left empty.
+func sanitizeIssueText(message, description string) (string, string, error) {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return "", "", errEmptyMessage
+	}
+	return message, strings.TrimSpace(description), nil
+}
+
+var mentionRegexp = regexp.MustCompile(`@[a-zA-Z0-9._-]+`)
+
+// mentionsUser checks whether text has an @mention of username. A period ending the sentence after
+// the mention is not part of the username.
+func mentionsUser(text, username string) bool {
+	for _, mention := range mentionRegexp.FindAllString(text, -1) {
+		if strings.EqualFold(strings.TrimRight(mention[1:], "."), username) {
+			return true
+		}
+	}
+	return false
+}
+
+// mostUrgentPosition returns the position of the issue due the soonest, so overdue issues go first. Issues
+// without a due date are not urgent, and the first issue is returned when none of them has one. Ties go to
+// the issue higher on the list.
+func mostUrgentPosition(issues []*Issue) int {
+	best := 0
+	for i, issue := range issues {
+		if issue.DueAt > 0 && (issues[best].DueAt == 0 || issue.DueAt < issues[best].DueAt) {
+			best = i
+		}
+	}
+	return best
+}
+
+// IssueChange is an entry of the history of an issue, recording who changed what and when
+type IssueChange struct {
+	UserID   string `json:"user_id"`
+	CreateAt int64  `json:"create_at"`
+	Action   string `json:"action"`
+	Details  string `json:"details,omitempty"`
+}
+
 // Issue represents a Todo issue
 type Issue struct {
-	ID          string `json:"id"`
-	Message     string `json:"message"`
-	Description string `json:"description,omitempty"`
-	CreateAt    int64  `json:"create_at"`
-	PostID      string `json:"post_id"`
+	ID          string         `json:"id"`
+	Message     string         `json:"message"`
+	Description string         `json:"description,omitempty"`
+	CreateAt    int64          `json:"create_at"`
+	PostID      string         `json:"post_id"`
+	Starred     bool           `json:"starred,omitempty"`
+	WaitingOn   string         `json:"waiting_on,omitempty"`
+	ChannelID   string         `json:"channel_id,omitempty"`
+	NagInterval int64          `json:"nag_interval,omitempty"`
+	LastNagAt   int64          `json:"last_nag_at,omitempty"`
+	CompletedBy string         `json:"completed_by,omitempty"`
+	CompletedAt int64          `json:"completed_at,omitempty"`
+	Related     []string       `json:"related,omitempty"`
+	Points      int            `json:"points,omitempty"`
+	DueAt       int64          `json:"due_at,omitempty"`
+	ExternalRef string         `json:"external_ref,omitempty"`
+	Role        string         `json:"role,omitempty"`
+	Priority    int            `json:"priority,omitempty"`
+	SentBy      string         `json:"sent_by,omitempty"`
+	History     []*IssueChange `json:"history,omitempty"`
 }
 
 // ExtendedIssue extends the information on Issue to be used on the front-end
@@ -22,28 +152,380 @@ type ExtendedIssue struct {
 	ForeignUser     string `json:"user"`
 	ForeignList     string `json:"list"`
 	ForeignPosition int    `json:"position"`
+	WaitingOnUser   string `json:"waiting_on_user,omitempty"`
+	ChannelName     string `json:"channel_name,omitempty"`
+	CompletedByUser string `json:"completed_by_user,omitempty"`
+	// SentByUser is who sent an issue without tracking it, so it is not linked to them
+	SentByUser string `json:"sent_by_user,omitempty"`
+	// RelatedIssues are the messages of the related issues. They are only filled for a single issue.
+	RelatedIssues []string `json:"related_issues,omitempty"`
+	// PriorityEmoji is the emoji the user shows before the issues of its priority
+	PriorityEmoji string `json:"priority_emoji,omitempty"`
+}
+
+// IssueFields are the optional fields of an issue set when it is created
+type IssueFields struct {
+	ChannelID   string
+	Points      int
+	DueAt       int64
+	ExternalRef string
+	Role        string
+	Priority    int
+	// HasPriority is whether a normal Priority was chosen. The todos added as normal without it get the default
+	// priority of the user.
+	HasPriority bool
 }
 
-func newIssue(message string, description, postID string) *Issue {
+func newIssue(message string, description, postID string, fields IssueFields) *Issue {
 	return &Issue{
 		ID:          model.NewId(),
 		CreateAt:    model.GetMillis(),
 		Message:     message,
 		Description: description,
 		PostID:      postID,
+		ChannelID:   fields.ChannelID,
+		Points:      fields.Points,
+		DueAt:       fields.DueAt,
+		ExternalRef: fields.ExternalRef,
+		Role:        fields.Role,
+		Priority:    fields.Priority,
+	}
+}
+
+// fields returns the optional fields of the issue, to create a copy of it
+func (i *Issue) fields() IssueFields {
+	return IssueFields{
+		ChannelID:   i.ChannelID,
+		Points:      i.Points,
+		DueAt:       i.DueAt,
+		ExternalRef: i.ExternalRef,
+		Role:        i.Role,
+		Priority:    i.Priority,
+		HasPriority: true,
+	}
+}
+
+// addChange appends a change made by userID to the history of the issue, dropping the oldest
+// changes beyond MaxIssueHistory.
+func (i *Issue) addChange(userID, action, details string) {
+	i.History = append(i.History, &IssueChange{
+		UserID:   userID,
+		CreateAt: model.GetMillis(),
+		Action:   action,
+		Details:  details,
+	})
+	if len(i.History) > MaxIssueHistory {
+		i.History = i.History[len(i.History)-MaxIssueHistory:]
+	}
+}
+
+// editDetails describes the changes an edit to newMessage and newDescription makes on the issue.
+func (i *Issue) editDetails(newMessage, newDescription string) string {
+	changes := []string{}
+	if i.Message != newMessage {
+		changes = append(changes, fmt.Sprintf("message changed from %q to %q", i.Message, newMessage))
+	}
+	if i.Description != newDescription {
+		changes = append(changes, "description changed")
+	}
+	return strings.Join(changes, ", ")
+}
+
+// IssueDiff holds the message and description of an issue before and after an edit, for clients to render the changes
+type IssueDiff struct {
+	OldMessage     string `json:"old_message"`
+	NewMessage     string `json:"new_message"`
+	OldDescription string `json:"old_description"`
+	NewDescription string `json:"new_description"`
+}
+
+// diff compares the issue with its edit to newMessage and newDescription.
+func (i *Issue) diff(newMessage, newDescription string) *IssueDiff {
+	return &IssueDiff{
+		OldMessage:     i.Message,
+		NewMessage:     newMessage,
+		OldDescription: i.Description,
+		NewDescription: newDescription,
 	}
 }
 
 func issuesListToString(issues []*ExtendedIssue) string {
+	return formatIssuesList(issues, false)
+}
+
+// reminderToString renders the issues of the daily reminder, optionally with a truncated description below each one.
+// At most maxItems issues are listed, fewer if needed to stay within maxLength characters, and the rest are counted at the end.
+func reminderToString(issues []*ExtendedIssue, includeDescriptions bool, maxItems, maxLength int) string {
 	if len(issues) == 0 {
 		return "Nothing to do!"
 	}
 
+	const moreFormat = "\n...and %d more, run `/todo list` to see them all.\n"
+	reserved := len([]rune(fmt.Sprintf(moreFormat, len(issues))))
+
 	str := "\n\n"
+	length := len([]rune(str))
+	shown := 0
+	for i, issue := range issues {
+		if i >= maxItems {
+			break
+		}
+
+		item := issueListItemToString(i+1, issue, includeDescriptions)
+		needed := length + len([]rune(item))
+		if i < len(issues)-1 {
+			needed += reserved
+		}
+		if needed > maxLength {
+			break
+		}
+
+		str += item
+		length += len([]rune(item))
+		shown++
+	}
+
+	if shown < len(issues) {
+		str += fmt.Sprintf(moreFormat, len(issues)-shown)
+	}
+
+	return str
+}
+
+func formatIssuesList(issues []*ExtendedIssue, includeDescriptions bool) string {
+	if len(issues) == 0 {
+		return "Nothing to do!"
+	}
+
+	str := "\n\n"
+
+	for i, issue := range issues {
+		str += issueListItemToString(i+1, issue, includeDescriptions)
+	}
+
+	return str
+}
+
+// formatDueAt renders the due date dueAt in UTC, labelled as such since the reader may be in another time zone.
+func formatDueAt(dueAt int64) string {
+	return time.Unix(dueAt/1000, 0).UTC().Format("January 2, 2006 at 15:04 UTC")
+}
+
+// issueListItemToString renders an issue as the item at position of a numbered list.
+func issueListItemToString(position int, issue *ExtendedIssue, includeDescriptions bool) string {
+	createAt := time.Unix(issue.CreateAt/1000, 0)
+	star := ""
+	if issue.PriorityEmoji != "" {
+		star = issue.PriorityEmoji + " "
+	}
+	if issue.Starred {
+		star += ":star: "
+	}
+	str := fmt.Sprintf("%d. %s%s\n   * (%s)\n", position, star, issue.Message, createAt.Format("January 2, 2006 at 15:04"))
+	if issue.WaitingOnUser != "" {
+		str += fmt.Sprintf("   * Waiting on @%s\n", issue.WaitingOnUser)
+	}
+	if issue.ChannelName != "" {
+		str += fmt.Sprintf("   * Channel: ~%s\n", issue.ChannelName)
+	}
+	if issue.Points > 0 {
+		str += fmt.Sprintf("   * Points: %d\n", issue.Points)
+	}
+	if issue.Role != "" {
+		str += fmt.Sprintf("   * Role: %s\n", issue.Role)
+	}
+	if issue.DueAt > 0 {
+		str += fmt.Sprintf("   * Due: %s\n", formatDueAt(issue.DueAt))
+	}
+	if issue.CompletedByUser != "" {
+		str += fmt.Sprintf("   * Completed by @%s\n", issue.CompletedByUser)
+	}
+	if includeDescriptions && issue.Description != "" {
+		str += fmt.Sprintf("   * %s\n", truncateDescription(issue.Description, MaxReminderDescriptionLength))
+	}
+	return str
+}
+
+// issueGroup is a section of a list rendered under a common heading. Positions holds the
+// position of each issue on the original list, so it can still be used with other commands.
+type issueGroup struct {
+	Name      string
+	Issues    []*ExtendedIssue
+	Positions []int
+}
+
+// groupIssues groups issues by the name groupName gives them, in the order each name first appears.
+// The group named lastGroup, if any, goes at the end.
+func groupIssues(issues []*ExtendedIssue, groupName func(issue *ExtendedIssue) string, lastGroup string) []*issueGroup {
+	groups := []*issueGroup{}
+	groupsByName := map[string]*issueGroup{}
+
+	for i, issue := range issues {
+		name := groupName(issue)
+		group, ok := groupsByName[name]
+		if !ok {
+			group = &issueGroup{Name: name}
+			groupsByName[name] = group
+			if name != lastGroup {
+				groups = append(groups, group)
+			}
+		}
+		group.Issues = append(group.Issues, issue)
+		group.Positions = append(group.Positions, i+1)
+	}
+
+	if group, ok := groupsByName[lastGroup]; ok {
+		groups = append(groups, group)
+	}
+
+	return groups
+}
+
+// filterIssueGroup keeps the issues for which keep is true in a group named name, or returns nil if there are none.
+func filterIssueGroup(issues []*ExtendedIssue, name string, keep func(issue *ExtendedIssue) bool) *issueGroup {
+	group := &issueGroup{Name: name}
+	for i, issue := range issues {
+		if !keep(issue) {
+			continue
+		}
+		group.Issues = append(group.Issues, issue)
+		group.Positions = append(group.Positions, i+1)
+	}
+
+	if len(group.Issues) == 0 {
+		return nil
+	}
+	return group
+}
+
+// groupIssuesByAcceptance keeps the sent issues that were accepted, or the ones still pending
+// if accepted is false, in a group named name. A sent issue is pending while it is on the in list
+// of the receiver.
+func groupIssuesByAcceptance(issues []*ExtendedIssue, accepted bool, name string) []*issueGroup {
+	group := filterIssueGroup(issues, name, func(issue *ExtendedIssue) bool {
+		return (issue.ForeignList != InFlag) == accepted
+	})
+	if group == nil {
+		return nil
+	}
+	return []*issueGroup{group}
+}
+
+func issueGroupsToString(groups []*issueGroup) string {
+	if len(groups) == 0 {
+		return "Nothing to do!"
+	}
 
+	str := ""
+	for _, group := range groups {
+		str += fmt.Sprintf("\n#### %s\n\n", group.Name)
+		for i, issue := range group.Issues {
+			str += issueListItemToString(group.Positions[i], issue, false)
+		}
+	}
+
+	return str
+}
+
+func issueToString(issue *ExtendedIssue) string {
+	createAt := time.Unix(issue.CreateAt/1000, 0)
+
+	str := fmt.Sprintf("#### %s\n", issue.Message)
+	if issue.Description != "" {
+		str += fmt.Sprintf("\n%s\n", issue.Description)
+	}
+	str += "\n"
+	str += fmt.Sprintf("* Created: %s\n", createAt.Format("January 2, 2006 at 15:04"))
+
+	if issue.ForeignUser != "" {
+		switch issue.ForeignList {
+		case OutFlag:
+			str += fmt.Sprintf("* From: @%s\n", issue.ForeignUser)
+		case InFlag:
+			str += fmt.Sprintf("* Assigned to: @%s (not accepted yet)\n", issue.ForeignUser)
+		default:
+			str += fmt.Sprintf("* Assigned to: @%s\n", issue.ForeignUser)
+		}
+	}
+	if issue.SentByUser != "" {
+		str += fmt.Sprintf("* From: @%s (not tracked by them)\n", issue.SentByUser)
+	}
+	if issue.WaitingOnUser != "" {
+		str += fmt.Sprintf("* Waiting on: @%s\n", issue.WaitingOnUser)
+	}
+	if issue.ChannelName != "" {
+		str += fmt.Sprintf("* Channel: ~%s\n", issue.ChannelName)
+	}
+	if issue.Points > 0 {
+		str += fmt.Sprintf("* Points: %d\n", issue.Points)
+	}
+	if issue.Role != "" {
+		str += fmt.Sprintf("* Role: %s\n", issue.Role)
+	}
+	if issue.Priority == PriorityHigh {
+		str += "* Priority: high\n"
+	} else if issue.Priority == PriorityLow {
+		str += "* Priority: low\n"
+	}
+	if issue.DueAt > 0 {
+		str += fmt.Sprintf("* Due: %s\n", formatDueAt(issue.DueAt))
+	}
+	if issue.CompletedByUser != "" {
+		completedAt := time.Unix(issue.CompletedAt/1000, 0)
+		str += fmt.Sprintf("* Completed by: @%s on %s\n", issue.CompletedByUser, completedAt.Format("January 2, 2006 at 15:04"))
+	}
+	if issue.Starred {
+		str += "* Starred\n"
+	}
+	if issue.PostID != "" {
+		str += "* Attached to a post\n"
+	}
+	for _, related := range issue.RelatedIssues {
+		str += fmt.Sprintf("* See also: %s\n", related)
+	}
+
+	return str
+}
+
+// ageDays returns how many whole days old the issue is at now
+func (i *Issue) ageDays(now int64) int {
+	return int((now - i.CreateAt) / int64(24*time.Hour/time.Millisecond))
+}
+
+// totalPoints adds up the points of issues
+func totalPoints(issues []*ExtendedIssue) int {
+	total := 0
 	for _, issue := range issues {
-		createAt := time.Unix(issue.CreateAt/1000, 0)
-		str += fmt.Sprintf("* %s\n  * (%s)\n", issue.Message, createAt.Format("January 2, 2006 at 15:04"))
+		total += issue.Points
+	}
+	return total
+}
+
+// truncateDescription flattens description into a single line of at most maxLength characters.
+func truncateDescription(description string, maxLength int) string {
+	description = strings.Join(strings.Fields(description), " ")
+
+	runes := []rune(description)
+	if len(runes) <= maxLength {
+		return description
+	}
+	return strings.TrimSpace(string(runes[:maxLength])) + "…"
+}
+
+// issueHistoryToString renders the history of an issue, using userNames to resolve who made each change.
+func issueHistoryToString(history []*IssueChange, userNames map[string]string) string {
+	if len(history) == 0 {
+		return ""
+	}
+
+	str := "\n##### History\n"
+	for _, change := range history {
+		changeAt := time.Unix(change.CreateAt/1000, 0)
+		str += fmt.Sprintf("* %s: @%s %s", changeAt.Format("January 2, 2006 at 15:04"), userNames[change.UserID], change.Action)
+		if change.Details != "" {
+			str += fmt.Sprintf(" (%s)", change.Details)
+		}
+		str += "\n"
 	}
 
 	return str
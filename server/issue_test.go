@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssueToString(t *testing.T) {
+	tests := []struct {
+		name       string
+		issue      *ExtendedIssue
+		contains   []string
+		notContain []string
+	}{
+		{
+			name: "Plain issue",
+			issue: &ExtendedIssue{
+				Issue: Issue{Message: "buy milk"},
+			},
+			contains:   []string{"#### buy milk", "* Created:"},
+			notContain: []string{"Assigned to", "From:", "Waiting on", "Starred", "Attached"},
+		},
+		{
+			name: "Issue with every detail",
+			issue: &ExtendedIssue{
+				Issue: Issue{
+					Message:     "review the design",
+					Description: "focus on the API",
+					PostID:      "post_id",
+					Starred:     true,
+				},
+				ForeignUser:   "bob",
+				ForeignList:   MyListKey,
+				WaitingOnUser: "carol",
+			},
+			contains: []string{
+				"#### review the design",
+				"focus on the API",
+				"* Assigned to: @bob\n",
+				"* Waiting on: @carol",
+				"* Starred",
+				"* Attached to a post",
+			},
+		},
+		{
+			name: "Received issue",
+			issue: &ExtendedIssue{
+				Issue:       Issue{Message: "task"},
+				ForeignUser: "bob",
+				ForeignList: OutFlag,
+			},
+			contains: []string{"* From: @bob"},
+		},
+		{
+			name: "Sent issue not accepted yet",
+			issue: &ExtendedIssue{
+				Issue:       Issue{Message: "task"},
+				ForeignUser: "bob",
+				ForeignList: InFlag,
+			},
+			contains: []string{"* Assigned to: @bob (not accepted yet)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			str := issueToString(tt.issue)
+			for _, s := range tt.contains {
+				assert.Contains(t, str, s)
+			}
+			for _, s := range tt.notContain {
+				assert.NotContains(t, str, s)
+			}
+		})
+	}
+}
+
+func TestMentionsUser(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{text: "ping @bob", want: true},
+		{text: "@bob, have a look", want: true},
+		{text: "ask @BOB.", want: true},
+		{text: "(cc @bob)", want: true},
+		{text: "lunch with @bobby", want: false},
+		{text: "mail bob@example.com", want: false},
+		{text: "@bob.smith knows", want: false},
+		{text: "bob", want: false},
+		{text: "", want: false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, mentionsUser(tt.text, "bob"), tt.text)
+	}
+}
+
+func TestIssueHistoryToString(t *testing.T) {
+	assert.Empty(t, issueHistoryToString(nil, nil))
+
+	str := issueHistoryToString([]*IssueChange{
+		{UserID: "bob_id", Action: "edited", Details: "description changed"},
+		{UserID: "alice_id", Action: "accepted"},
+	}, map[string]string{"bob_id": "bob", "alice_id": "alice"})
+
+	assert.Contains(t, str, "@bob edited (description changed)\n")
+	assert.Contains(t, str, "@alice accepted\n")
+}
+
+func TestReminderToString(t *testing.T) {
+	long := strings.Repeat("a", MaxReminderDescriptionLength+20)
+	issues := []*ExtendedIssue{
+		{Issue: Issue{Message: "first", Description: "line one\nline two"}},
+		{Issue: Issue{Message: "second"}},
+		{Issue: Issue{Message: "third", Description: long}},
+	}
+
+	withoutDescriptions := reminderToString(issues, false, DefaultMaxReminderItems, model.POST_MESSAGE_MAX_RUNES_V1)
+	assert.Contains(t, withoutDescriptions, "1. first")
+	assert.NotContains(t, withoutDescriptions, "line one")
+	assert.Equal(t, issuesListToString(issues), withoutDescriptions)
+
+	withDescriptions := reminderToString(issues, true, DefaultMaxReminderItems, model.POST_MESSAGE_MAX_RUNES_V1)
+	assert.Contains(t, withDescriptions, "   * line one line two\n")
+	assert.Contains(t, withDescriptions, "   * "+strings.Repeat("a", MaxReminderDescriptionLength)+"…\n")
+	assert.NotContains(t, withDescriptions, strings.Repeat("a", MaxReminderDescriptionLength+1))
+}
+
+func TestReminderToStringTruncation(t *testing.T) {
+	issues := []*ExtendedIssue{}
+	for i := 0; i < 10; i++ {
+		issues = append(issues, &ExtendedIssue{Issue: Issue{Message: fmt.Sprintf("todo %d", i+1)}})
+	}
+
+	t.Run("Lists every issue up to the cap", func(t *testing.T) {
+		reminder := reminderToString(issues, false, 10, model.POST_MESSAGE_MAX_RUNES_V1)
+		assert.Contains(t, reminder, "10. todo 10")
+		assert.NotContains(t, reminder, "more, run")
+	})
+
+	t.Run("Counts the issues beyond the cap", func(t *testing.T) {
+		reminder := reminderToString(issues, false, 3, model.POST_MESSAGE_MAX_RUNES_V1)
+		assert.Contains(t, reminder, "3. todo 3\n")
+		assert.NotContains(t, reminder, "4. todo 4")
+		assert.True(t, strings.HasSuffix(reminder, "\n...and 7 more, run `/todo list` to see them all.\n"), reminder)
+	})
+
+	t.Run("Stays within the maximum length", func(t *testing.T) {
+		full := reminderToString(issues, false, 10, model.POST_MESSAGE_MAX_RUNES_V1)
+		maxLength := len([]rune(full)) - 1
+		reminder := reminderToString(issues, false, 10, maxLength)
+		assert.LessOrEqual(t, len([]rune(reminder)), maxLength)
+		assert.Contains(t, reminder, "more, run `/todo list`")
+	})
+
+	t.Run("Empty list", func(t *testing.T) {
+		assert.Equal(t, "Nothing to do!", reminderToString(nil, false, 3, model.POST_MESSAGE_MAX_RUNES_V1))
+	})
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
-	"fmt"
+	"sort"
+	"strings"
 
+	"github.com/mattermost/mattermost-server/v5/model"
 	"github.com/mattermost/mattermost-server/v5/plugin"
 	"github.com/pkg/errors"
 )
@@ -14,8 +16,23 @@ const (
 	InListKey = "_in"
 	// OutListKey is the key used to store the list of sent todos
 	OutListKey = "_out"
+	// SomedayListKey is the key used to store the list of owned todos put aside for some day. They are not part of the reminders.
+	SomedayListKey = "_someday"
+	// StarredListKey is the key used to request the starred todos across all lists. It is not stored.
+	StarredListKey = "_starred"
+	// OverdueListKey is the key used to request the todos past their due date across all lists. It is not stored.
+	OverdueListKey = "overdue"
 )
 
+// errIssueNotFound is returned when a todo is not on any of the lists of the user acting on it
+var errIssueNotFound = errors.New("todo not found")
+
+// errAlreadyCompleted is returned when acting on the receiver's copy of a sent todo the receiver already completed
+var errAlreadyCompleted = errors.New("the todo was already completed")
+
+// errInvalidMove is returned when a todo cannot be moved from its list to the one asked for
+var errInvalidMove = errors.New("the todo cannot be moved to that list")
+
 // ListStore represents the KVStore operations for lists
 type ListStore interface {
 	// Issue related function
@@ -29,12 +46,14 @@ type ListStore interface {
 	// AddReference creates a new IssueRef with the issueID, foreignUSerID and foreignIssueID, and stores it
 	// on the listID for userID.
 	AddReference(userID, issueID, listID, foreignUserID, foreignIssueID string) error
+	// PrependReference creates a new IssueRef like AddReference, but stores it at the beginning of the list
+	PrependReference(userID, issueID, listID, foreignUserID, foreignIssueID string) error
 	// RemoveReference removes the IssueRef for issueID in listID for userID
 	RemoveReference(userID, issueID, listID string) error
-	// PopReference removes the first IssueRef in listID for userID and returns it
-	PopReference(userID, listID string) (*IssueRef, error)
 	// BumpReference moves the Issue reference for issueID in listID for userID to the beginning of the list
 	BumpReference(userID, issueID, listID string) error
+	// MuteReference sets whether the IssueRef for issueID in listID for userID is muted, keeping its position
+	MuteReference(userID, issueID, listID string, muted bool) error
 
 	// GetIssueReference gets the IssueRef and position of the issue issueID on user userID's list listID
 	GetIssueReference(userID, issueID, listID string) (*IssueRef, int, error)
@@ -58,8 +77,11 @@ func NewListManager(api plugin.API) ListManager {
 	}
 }
 
-func (l *listManager) AddIssue(userID, message, description, postID string) (*Issue, error) {
-	issue := newIssue(message, description, postID)
+func (l *listManager) AddIssue(userID, message, description, postID string, fields IssueFields) (*Issue, error) {
+	if !fields.HasPriority && fields.Priority == PriorityNormal {
+		fields.Priority = getDefaultPriorityPreference(l.api, userID)
+	}
+	issue := newIssue(message, description, postID, fields)
 
 	if err := l.store.SaveIssue(issue); err != nil {
 		return nil, err
@@ -72,16 +94,21 @@ func (l *listManager) AddIssue(userID, message, description, postID string) (*Is
 		return nil, err
 	}
 
+	l.addReminderUser(userID)
 	return issue, nil
 }
 
-func (l *listManager) SendIssue(senderID, receiverID, message, description, postID string) (string, error) {
-	senderIssue := newIssue(message, description, postID)
+func (l *listManager) SendIssue(senderID, receiverID, message, description, postID string, fields IssueFields) (string, error) {
+	if len([]rune(description)) > MaxSharedDescriptionLength {
+		return "", errSharedDescriptionTooLong
+	}
+
+	senderIssue := newIssue(message, description, postID, fields)
 	if err := l.store.SaveIssue(senderIssue); err != nil {
 		return "", err
 	}
 
-	receiverIssue := newIssue(message, description, postID)
+	receiverIssue := newIssue(message, description, postID, fields)
 	if err := l.store.SaveIssue(receiverIssue); err != nil {
 		if rollbackError := l.store.RemoveIssue(senderIssue.ID); rollbackError != nil {
 			l.api.LogError("cannot rollback sender issue after send error, Err=", err.Error())
@@ -99,7 +126,12 @@ func (l *listManager) SendIssue(senderID, receiverID, message, description, post
 		return "", err
 	}
 
-	if err := l.store.AddReference(receiverID, receiverIssue.ID, InListKey, senderID, senderIssue.ID); err != nil {
+	addReceiverReference := l.store.AddReference
+	if getReceivedOnTopPreference(l.api, receiverID) {
+		addReceiverReference = l.store.PrependReference
+	}
+
+	if err := addReceiverReference(receiverID, receiverIssue.ID, InListKey, senderID, senderIssue.ID); err != nil {
 		if rollbackError := l.store.RemoveIssue(senderIssue.ID); rollbackError != nil {
 			l.api.LogError("cannot rollback sender issue after send error, Err=", err.Error())
 		}
@@ -112,33 +144,454 @@ func (l *listManager) SendIssue(senderID, receiverID, message, description, post
 		return "", err
 	}
 
+	l.addReminderUser(receiverID)
 	return receiverIssue.ID, nil
 }
 
+func (l *listManager) SendUntrackedIssue(senderID, receiverID, message, description, postID string, fields IssueFields) (string, error) {
+	if len([]rune(description)) > MaxSharedDescriptionLength {
+		return "", errSharedDescriptionTooLong
+	}
+
+	receiverIssue := newIssue(message, description, postID, fields)
+	if senderID != receiverID {
+		receiverIssue.SentBy = senderID
+	}
+	if err := l.store.SaveIssue(receiverIssue); err != nil {
+		return "", err
+	}
+
+	addReceiverReference := l.store.AddReference
+	if getReceivedOnTopPreference(l.api, receiverID) {
+		addReceiverReference = l.store.PrependReference
+	}
+
+	if err := addReceiverReference(receiverID, receiverIssue.ID, InListKey, "", ""); err != nil {
+		if rollbackError := l.store.RemoveIssue(receiverIssue.ID); rollbackError != nil {
+			l.api.LogError("cannot rollback receiver issue after send error, Err=", err.Error())
+		}
+		return "", err
+	}
+
+	l.addReminderUser(receiverID)
+	return receiverIssue.ID, nil
+}
+
+// addReminderUser lets the reminder job send the daily reminders of userID, who just got a todo.
+func (l *listManager) addReminderUser(userID string) {
+	if err := registerReminderUser(l.api, userID); err != nil {
+		l.api.LogError("cannot register the user for the daily reminder", "error", err.Error())
+	}
+}
+
 func (l *listManager) GetIssueList(userID, listID string) ([]*ExtendedIssue, error) {
+	if listID == StarredListKey {
+		return l.getStarredIssueList(userID)
+	}
+	if listID == OverdueListKey {
+		return l.getOverdueIssueList(userID, model.GetMillis())
+	}
+
 	irs, err := l.store.GetList(userID, listID)
 	if err != nil {
 		return nil, err
 	}
 
 	extendedIssues := []*ExtendedIssue{}
+	names := channelNames{}
+	emoji := getPriorityEmojiPreference(l.api, userID)
 	for _, ir := range irs {
 		issue, err := l.store.GetIssue(ir.IssueID)
 		if err != nil {
 			continue
 		}
 
-		extendedIssue := l.extendIssueInfo(issue, ir)
+		extendedIssue := l.extendIssueInfo(userID, issue, ir, names)
+		extendedIssue.PriorityEmoji = emoji[priorityName(issue.Priority)]
 		extendedIssues = append(extendedIssues, extendedIssue)
 	}
+	sortByPriority(extendedIssues)
 
 	return extendedIssues, nil
 }
 
+func (l *listManager) GetStoredIssues(userID, listID string) ([]*Issue, error) {
+	irs, err := l.store.GetList(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := []*Issue{}
+	for _, ir := range irs {
+		issue, err := l.store.GetIssue(ir.IssueID)
+		if err != nil {
+			continue
+		}
+		issues = append(issues, issue)
+	}
+
+	return issues, nil
+}
+
+func (l *listManager) CountIssues(userID, listID string) (int, error) {
+	irs, err := l.store.GetList(userID, listID)
+	if err != nil {
+		return 0, err
+	}
+	return len(irs), nil
+}
+
+func (l *listManager) GetIssue(userID, issueID string) (*ExtendedIssue, error) {
+	_, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
+	if ir == nil {
+		return nil, errIssueNotFound
+	}
+
+	issue, err := l.store.GetIssue(issueID)
+	if err != nil {
+		return nil, err
+	}
+
+	feIssue := l.extendIssueInfo(userID, issue, ir, channelNames{})
+	feIssue.PriorityEmoji = getPriorityEmojiPreference(l.api, userID)[priorityName(issue.Priority)]
+	for _, relatedID := range issue.Related {
+		related, err := l.store.GetIssue(relatedID)
+		if err != nil {
+			continue
+		}
+		feIssue.RelatedIssues = append(feIssue.RelatedIssues, related.Message)
+	}
+
+	return feIssue, nil
+}
+
+func (l *listManager) getStarredIssueList(userID string) ([]*ExtendedIssue, error) {
+	starredIssues := []*ExtendedIssue{}
+	for _, listID := range []string{MyListKey, InListKey, OutListKey} {
+		issues, err := l.GetIssueList(userID, listID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, issue := range issues {
+			if issue.Starred {
+				starredIssues = append(starredIssues, issue)
+			}
+		}
+	}
+
+	return starredIssues, nil
+}
+
+// getOverdueIssueList returns the issues of the my, in and out lists due before now. The issues without a due date
+// are never overdue.
+func (l *listManager) getOverdueIssueList(userID string, now int64) ([]*ExtendedIssue, error) {
+	overdueIssues := []*ExtendedIssue{}
+	for _, listID := range []string{MyListKey, InListKey, OutListKey} {
+		issues, err := l.GetIssueList(userID, listID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, issue := range issues {
+			if issue.DueAt > 0 && issue.DueAt < now {
+				overdueIssues = append(overdueIssues, issue)
+			}
+		}
+	}
+
+	return overdueIssues, nil
+}
+
+func (l *listManager) StarIssue(userID, issueID string) (bool, error) {
+	_, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
+	if ir == nil {
+		return false, errIssueNotFound
+	}
+
+	issue, err := l.store.GetIssue(issueID)
+	if err != nil {
+		return false, err
+	}
+
+	issue.Starred = !issue.Starred
+	if err := l.store.SaveIssue(issue); err != nil {
+		return false, err
+	}
+
+	return issue.Starred, nil
+}
+
+func (l *listManager) MuteIssue(userID, issueID string) (bool, error) {
+	issueList, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
+	if ir == nil {
+		return false, errIssueNotFound
+	}
+	if ir.ForeignUserID == "" {
+		return false, errors.New("only todos shared with someone else can be muted")
+	}
+
+	if err := l.store.MuteReference(userID, issueID, issueList, !ir.Muted); err != nil {
+		return false, err
+	}
+
+	return !ir.Muted, nil
+}
+
+func (l *listManager) IsIssueMuted(userID, issueID string) bool {
+	_, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
+	return ir != nil && ir.Muted
+}
+
+func (l *listManager) IsMutedByForeignUser(userID, issueID string) bool {
+	_, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
+	if ir == nil || ir.ForeignUserID == "" {
+		return false
+	}
+
+	return l.IsIssueMuted(ir.ForeignUserID, ir.ForeignIssueID)
+}
+
+func (l *listManager) SetSomeday(userID, issueID string, someday bool) error {
+	fromList, toList := MyListKey, SomedayListKey
+	if !someday {
+		fromList, toList = SomedayListKey, MyListKey
+	}
+
+	ir, _, err := l.store.GetIssueReference(userID, issueID, fromList)
+	if err != nil {
+		return err
+	}
+	if ir == nil {
+		return errIssueNotFound
+	}
+	if ir.ForeignIssueID != "" {
+		return errors.New("only your own todos can be put aside for some day")
+	}
+
+	if err := l.store.AddReference(userID, issueID, toList, "", ""); err != nil {
+		return err
+	}
+
+	if err := l.store.RemoveReference(userID, issueID, fromList); err != nil {
+		if rollbackError := l.store.RemoveReference(userID, issueID, toList); rollbackError != nil {
+			l.api.LogError("cannot rollback someday operation, Err=", rollbackError.Error())
+		}
+		return err
+	}
+
+	// Users with only someday Todos are dropped from the reminders
+	if toList == MyListKey {
+		l.addReminderUser(userID)
+	}
+	return nil
+}
+
+func (l *listManager) MoveIssue(userID, issueID, toList string) (fromList, foreignUserID, todoMessage string, err error) {
+	fromList, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
+	if ir == nil {
+		return "", "", "", errIssueNotFound
+	}
+
+	switch {
+	case fromList == MyListKey && toList == SomedayListKey:
+		return fromList, "", "", l.SetSomeday(userID, issueID, true)
+	case fromList == SomedayListKey && toList == MyListKey:
+		return fromList, "", "", l.SetSomeday(userID, issueID, false)
+	case fromList == InListKey && toList == MyListKey:
+		todoMessage, foreignUserID, err = l.AcceptIssue(userID, issueID)
+		return fromList, foreignUserID, todoMessage, err
+	}
+
+	return fromList, "", "", errInvalidMove
+}
+
+func (l *listManager) RelateIssues(userID, issueID, otherIssueID string, related bool) error {
+	if issueID == otherIssueID {
+		return errors.New("a todo cannot be related to itself")
+	}
+
+	issues := []*Issue{}
+	for _, id := range []string{issueID, otherIssueID} {
+		_, ir, _ := l.store.GetIssueListAndReference(userID, id)
+		if ir == nil {
+			return errIssueNotFound
+		}
+
+		issue, err := l.store.GetIssue(id)
+		if err != nil {
+			return err
+		}
+		issues = append(issues, issue)
+	}
+
+	for i, issue := range issues {
+		otherID := issues[1-i].ID
+		isRelated := len(removeRelated(issue.Related, otherID)) != len(issue.Related)
+		if isRelated == related {
+			continue
+		}
+
+		if related {
+			issue.Related = append(issue.Related, otherID)
+		} else {
+			issue.Related = removeRelated(issue.Related, otherID)
+		}
+
+		if err := l.store.SaveIssue(issue); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unrelateRemovedIssue drops the removed issue from the todos it was related to.
+func (l *listManager) unrelateRemovedIssue(issue *Issue) {
+	if issue == nil {
+		return
+	}
+
+	for _, relatedID := range issue.Related {
+		related, err := l.store.GetIssue(relatedID)
+		if err != nil {
+			continue
+		}
+
+		related.Related = removeRelated(related.Related, issue.ID)
+		if err := l.store.SaveIssue(related); err != nil {
+			l.api.LogError("cannot unrelate issue after remove, Err=", err.Error())
+		}
+	}
+}
+
+func removeRelated(related []string, issueID string) []string {
+	kept := []string{}
+	for _, id := range related {
+		if id != issueID {
+			kept = append(kept, id)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return kept
+}
+
+func (l *listManager) SetWaitingOn(userID, issueID, waitingOnUserID string) (*Issue, error) {
+	_, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
+	if ir == nil {
+		return nil, errIssueNotFound
+	}
+
+	issue, err := l.store.GetIssue(issueID)
+	if err != nil {
+		return nil, err
+	}
+
+	issue.WaitingOn = waitingOnUserID
+	if err := l.store.SaveIssue(issue); err != nil {
+		return nil, err
+	}
+
+	return issue, nil
+}
+
+func (l *listManager) UpdateIssue(userID, issueID string, update func(issue *Issue)) error {
+	_, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
+	if ir == nil {
+		return errIssueNotFound
+	}
+
+	issue, err := l.store.GetIssue(issueID)
+	if err != nil {
+		return err
+	}
+
+	update(issue)
+	if err := l.store.SaveIssue(issue); err != nil {
+		return err
+	}
+
+	if ir.ForeignIssueID != "" {
+		foreignIssue, foreignErr := l.store.GetIssue(ir.ForeignIssueID)
+		if foreignErr == nil {
+			update(foreignIssue)
+			foreignErr = l.store.SaveIssue(foreignIssue)
+		}
+		if foreignErr != nil {
+			l.api.LogError("cannot update the foreign issue", "error", foreignErr.Error())
+		}
+	}
+
+	return nil
+}
+
+func (l *listManager) SetNagInterval(userID, issueID string, interval int64) (*Issue, error) {
+	list, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
+	if ir == nil {
+		return nil, errIssueNotFound
+	}
+	if list != MyListKey {
+		return nil, errors.New("only todos on your own list can nag you")
+	}
+
+	issue, err := l.store.GetIssue(issueID)
+	if err != nil {
+		return nil, err
+	}
+
+	issue.NagInterval = interval
+	issue.LastNagAt = 0
+	if interval > 0 {
+		issue.LastNagAt = model.GetMillis()
+	}
+	if err := l.store.SaveIssue(issue); err != nil {
+		return nil, err
+	}
+
+	return issue, nil
+}
+
+func (l *listManager) NagIssue(userID, issueID string, now int64) (*Issue, bool, error) {
+	list, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
+	if ir == nil {
+		return nil, false, errIssueNotFound
+	}
+	if list != MyListKey && list != SomedayListKey {
+		return nil, false, nil
+	}
+
+	issue, err := l.store.GetIssue(issueID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if issue.NagInterval <= 0 {
+		return nil, false, nil
+	}
+
+	// The nag waits for the todo to be back on the my list
+	if list == SomedayListKey {
+		return issue, false, nil
+	}
+
+	if now-issue.LastNagAt < issue.NagInterval {
+		return issue, false, nil
+	}
+
+	issue.LastNagAt = now
+	if err := l.store.SaveIssue(issue); err != nil {
+		return nil, false, err
+	}
+
+	return issue, true, nil
+}
+
 func (l *listManager) CompleteIssue(userID, issueID string) (issue *Issue, foreignID string, listToUpdate string, err error) {
 	issueList, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
 	if ir == nil {
-		return nil, "", issueList, fmt.Errorf("cannot find element")
+		return nil, "", issueList, errIssueNotFound
 	}
 
 	if err = l.store.RemoveReference(userID, issueID, issueList); err != nil {
@@ -149,39 +602,78 @@ func (l *listManager) CompleteIssue(userID, issueID string) (issue *Issue, forei
 	if err != nil {
 		l.api.LogError("cannot remove issue, Err=", err.Error())
 	}
+	l.unrelateRemovedIssue(issue)
 
-	if ir.ForeignUserID == "" {
+	// The sender's copy of a completed todo that was kept has no receiver's copy left to clean
+	if ir.ForeignUserID == "" || (issue != nil && issue.CompletedBy != "") {
 		return issue, "", issueList, nil
 	}
 
-	err = l.store.RemoveReference(ir.ForeignUserID, ir.ForeignIssueID, OutListKey)
-	if err != nil {
-		l.api.LogError("cannot clean foreigner list after complete, Err=", err.Error())
+	return l.completeSentIssue(userID, ir, issue), ir.ForeignUserID, issueList, nil
+}
+
+// completeSentIssue handles the sender's copy of the received todo of ir, done by userID: it is kept on the
+// outgoing list marked as completed when the sender prefers so, and removed otherwise. It returns the sender's
+// copy, or issue if it cannot be found.
+func (l *listManager) completeSentIssue(userID string, ir *IssueRef, issue *Issue) *Issue {
+	if !getKeepCompletedSentPreference(l.api, ir.ForeignUserID) {
+		if err := l.store.RemoveReference(ir.ForeignUserID, ir.ForeignIssueID, OutListKey); err != nil {
+			l.api.LogError("cannot clean foreigner list after complete, Err=", err.Error())
+		}
+
+		sentIssue, err := l.store.GetAndRemoveIssue(ir.ForeignIssueID)
+		if err != nil {
+			l.api.LogError("cannot clean foreigner issue after complete, Err=", err.Error())
+			return issue
+		}
+		return sentIssue
 	}
 
-	issue, err = l.store.GetAndRemoveIssue(ir.ForeignIssueID)
+	sentIssue, err := l.store.GetIssue(ir.ForeignIssueID)
 	if err != nil {
-		l.api.LogError("cannot clean foreigner issue after complete, Err=", err.Error())
+		l.api.LogError("cannot get foreigner issue after complete, Err=", err.Error())
+		return issue
 	}
 
-	return issue, ir.ForeignUserID, issueList, nil
+	// The reference keeps its foreign user and issue, so the sender still sees who completed it
+	sentIssue.CompletedBy = userID
+	sentIssue.CompletedAt = model.GetMillis()
+	sentIssue.addChange(userID, "completed", "")
+	if err = l.store.SaveIssue(sentIssue); err != nil {
+		l.api.LogError("cannot save foreigner issue after complete, Err=", err.Error())
+	}
+
+	return sentIssue
 }
 
-func (l *listManager) EditIssue(userID, issueID, newMessage, newDescription string) (foreignUserID, list, oldMessage string, err error) {
+func (l *listManager) EditIssue(userID, issueID, newMessage, newDescription string) (foreignUserID, list string, diff *IssueDiff, err error) {
+	if strings.TrimSpace(newMessage) == "" {
+		return "", "", nil, errEmptyMessage
+	}
+	if len([]rune(newMessage))+len([]rune(newDescription)) > MaxIssueTextLength {
+		return "", "", nil, errIssueTextTooLong
+	}
+
 	issue, err := l.store.GetIssue(issueID)
 	if err != nil {
-		return "", "", "", err
+		return "", "", nil, err
 	}
 
 	list, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
 	if ir == nil {
-		return "", "", "", errors.New("reference not found")
+		return "", "", nil, errIssueNotFound
 	}
+	if ir.ForeignIssueID != "" && len([]rune(newDescription)) > MaxSharedDescriptionLength {
+		return "", "", nil, errSharedDescriptionTooLong
+	}
+
+	details := issue.editDetails(newMessage, newDescription)
+	diff = issue.diff(newMessage, newDescription)
 
 	if ir.ForeignIssueID != "" {
 		foreignIssue, foreignErr := l.store.GetIssue(ir.ForeignIssueID)
 		if foreignErr == nil {
-			oldMessage = foreignIssue.Message
+			foreignIssue.addChange(userID, "edited", details)
 			foreignIssue.Message = newMessage
 			foreignIssue.Description = newDescription
 			foreignErr = l.store.SaveIssue(foreignIssue)
@@ -191,40 +683,41 @@ func (l *listManager) EditIssue(userID, issueID, newMessage, newDescription stri
 		}
 	}
 
+	issue.addChange(userID, "edited", details)
 	issue.Message = newMessage
 	issue.Description = newDescription
 	err = l.store.SaveIssue(issue)
 	if err != nil {
-		return "", "", "", err
+		return "", "", nil, err
 	}
 
-	return ir.ForeignUserID, list, oldMessage, nil
+	return ir.ForeignUserID, list, diff, nil
 }
 
-func (l *listManager) ChangeAssignment(issueID string, userID string, sendTo string) (issueMessage, oldOwner string, err error) {
+func (l *listManager) ChangeAssignment(issueID string, userID string, sendTo string, track bool) (issueMessage, receiverIssueID, oldOwner string, err error) {
 	issue, err := l.store.GetIssue(issueID)
 	if err != nil {
-		return "", "", err
+		return "", "", "", err
 	}
 
 	list, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
 	if ir == nil {
-		return "", "", errors.New("reference not found")
+		return "", "", "", errIssueNotFound
 	}
 
 	if (list == InListKey) || (ir.ForeignIssueID != "" && list == MyListKey) {
-		return "", "", errors.New("trying to change the assignment of a todo not owned")
+		return "", "", "", errors.New("trying to change the assignment of a todo not owned")
 	}
 
 	if ir.ForeignUserID != "" {
 		// Remove reference from foreign user
 		foreignList, foreignIR, _ := l.store.GetIssueListAndReference(ir.ForeignUserID, ir.ForeignIssueID)
 		if foreignIR == nil {
-			return "", "", errors.New("reference not found")
+			return "", "", "", errIssueNotFound
 		}
 
 		if err := l.store.RemoveReference(ir.ForeignUserID, ir.ForeignIssueID, foreignList); err != nil {
-			return "", "", err
+			return "", "", "", err
 		}
 
 		_, err := l.store.GetAndRemoveIssue(ir.ForeignIssueID)
@@ -233,36 +726,60 @@ func (l *listManager) ChangeAssignment(issueID string, userID string, sendTo str
 		}
 	}
 
+	issue.addChange(userID, "reassigned", "to @"+l.GetUserName(sendTo))
+	if err := l.store.SaveIssue(issue); err != nil {
+		return "", "", "", err
+	}
+
 	if userID == sendTo && list == OutListKey {
 		if err := l.store.RemoveReference(userID, issueID, OutListKey); err != nil {
-			return "", "", err
+			return "", "", "", err
 		}
 
 		if err := l.store.AddReference(userID, issueID, MyListKey, "", ""); err != nil {
-			return "", "", err
+			return "", "", "", err
 		}
 
-		return issue.Message, ir.ForeignUserID, nil
+		return issue.Message, issue.ID, ir.ForeignUserID, nil
 	}
 
 	if err := l.store.RemoveReference(userID, issueID, list); err != nil {
-		return "", "", err
+		return "", "", "", err
 	}
 
-	receiverIssue := newIssue(issue.Message, issue.Description, issue.PostID)
+	receiverIssue := newIssue(issue.Message, issue.Description, issue.PostID, issue.fields())
+	receiverIssue.History = issue.History
+	if !track {
+		receiverIssue.SentBy = userID
+	}
 	if err := l.store.SaveIssue(receiverIssue); err != nil {
-		return "", "", err
+		return "", "", "", err
+	}
+
+	if !track {
+		if err := l.store.AddReference(sendTo, receiverIssue.ID, InListKey, "", ""); err != nil {
+			return "", "", "", err
+		}
+
+		if err := l.store.RemoveIssue(issueID); err != nil {
+			l.api.LogError("cannot remove issue after untracked reassignment", "err", err.Error())
+		}
+		l.unrelateRemovedIssue(issue)
+
+		l.addReminderUser(sendTo)
+		return issue.Message, receiverIssue.ID, ir.ForeignUserID, nil
 	}
 
 	if err := l.store.AddReference(userID, issueID, OutListKey, sendTo, receiverIssue.ID); err != nil {
-		return "", "", err
+		return "", "", "", err
 	}
 
-	if err := l.store.AddReference(ir.ForeignUserID, receiverIssue.ID, InListKey, userID, issue.ID); err != nil {
-		return "", "", err
+	if err := l.store.AddReference(sendTo, receiverIssue.ID, InListKey, userID, issue.ID); err != nil {
+		return "", "", "", err
 	}
 
-	return issue.Message, ir.ForeignUserID, nil
+	l.addReminderUser(sendTo)
+	return issue.Message, receiverIssue.ID, ir.ForeignUserID, nil
 }
 
 func (l *listManager) AcceptIssue(userID, issueID string) (todoMessage string, foreignUserID string, outErr error) {
@@ -276,7 +793,7 @@ func (l *listManager) AcceptIssue(userID, issueID string) (todoMessage string, f
 		return "", "", err
 	}
 	if ir == nil {
-		return "", "", fmt.Errorf("element reference not found")
+		return "", "", errIssueNotFound
 	}
 
 	err = l.store.AddReference(userID, issueID, MyListKey, ir.ForeignUserID, ir.ForeignIssueID)
@@ -284,6 +801,12 @@ func (l *listManager) AcceptIssue(userID, issueID string) (todoMessage string, f
 		return "", "", err
 	}
 
+	if ir.Muted {
+		if err = l.store.MuteReference(userID, issueID, MyListKey, true); err != nil {
+			l.api.LogError("cannot keep the issue muted after accept", "error", err.Error())
+		}
+	}
+
 	err = l.store.RemoveReference(userID, issueID, InListKey)
 	if err != nil {
 		if rollbackError := l.store.RemoveReference(userID, issueID, MyListKey); rollbackError != nil {
@@ -292,13 +815,54 @@ func (l *listManager) AcceptIssue(userID, issueID string) (todoMessage string, f
 		return "", "", err
 	}
 
+	issue.addChange(userID, "accepted", "")
+	if err = l.store.SaveIssue(issue); err != nil {
+		l.api.LogError("cannot save history after accept", "error", err.Error())
+	}
+
+	if foreignIssue, foreignErr := l.store.GetIssue(ir.ForeignIssueID); foreignErr == nil {
+		foreignIssue.addChange(userID, "accepted", "")
+		if foreignErr = l.store.SaveIssue(foreignIssue); foreignErr != nil {
+			l.api.LogError("cannot save foreign history after accept", "error", foreignErr.Error())
+		}
+	}
+
+	l.addReminderUser(userID)
 	return issue.Message, ir.ForeignUserID, nil
 }
 
+func (l *listManager) AcceptIssuesFrom(userID, senderID string) ([]string, []string, error) {
+	irs, err := l.store.GetList(userID, InListKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	todoMessages := []string{}
+	unmutedMessages := []string{}
+	for _, ir := range irs {
+		if ir.ForeignUserID != senderID {
+			continue
+		}
+
+		muted := l.IsIssueMuted(senderID, ir.ForeignIssueID)
+		todoMessage, _, err := l.AcceptIssue(userID, ir.IssueID)
+		if err != nil {
+			l.api.LogError("cannot accept issue from sender", "issueID", ir.IssueID, "error", err.Error())
+			continue
+		}
+		todoMessages = append(todoMessages, todoMessage)
+		if !muted {
+			unmutedMessages = append(unmutedMessages, todoMessage)
+		}
+	}
+
+	return todoMessages, unmutedMessages, nil
+}
+
 func (l *listManager) RemoveIssue(userID, issueID string) (outIssue *Issue, foreignID string, isSender bool, listToUpdate string, outErr error) {
 	issueList, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
 	if ir == nil {
-		return nil, "", false, issueList, fmt.Errorf("cannot find element")
+		return nil, "", false, issueList, errIssueNotFound
 	}
 
 	if err := l.store.RemoveReference(userID, issueID, issueList); err != nil {
@@ -309,8 +873,10 @@ func (l *listManager) RemoveIssue(userID, issueID string) (outIssue *Issue, fore
 	if err != nil {
 		l.api.LogError("cannot remove issue, Err=", err.Error())
 	}
+	l.unrelateRemovedIssue(issue)
 
-	if ir.ForeignUserID == "" {
+	// The sender's copy of a completed todo that was kept has no receiver's copy left to clean
+	if ir.ForeignUserID == "" || (issue != nil && issue.CompletedBy != "") {
 		return issue, "", false, issueList, nil
 	}
 
@@ -329,8 +895,8 @@ func (l *listManager) RemoveIssue(userID, issueID string) (outIssue *Issue, fore
 	return issue, ir.ForeignUserID, list == OutListKey, issueList, nil
 }
 
-func (l *listManager) PopIssue(userID string) (issue *Issue, foreignID string, err error) {
-	ir, err := l.store.PopReference(userID, MyListKey)
+func (l *listManager) PopIssue(userID string, mostUrgent bool) (issue *Issue, foreignID string, err error) {
+	ir, err := l.popReference(userID, mostUrgent)
 	if err != nil {
 		return nil, "", err
 	}
@@ -343,21 +909,59 @@ func (l *listManager) PopIssue(userID string) (issue *Issue, foreignID string, e
 	if err != nil {
 		l.api.LogError("cannot remove issue after pop, Err=", err.Error())
 	}
+	l.unrelateRemovedIssue(issue)
 
 	if ir.ForeignUserID == "" {
 		return issue, "", nil
 	}
 
-	err = l.store.RemoveReference(ir.ForeignUserID, ir.ForeignIssueID, OutListKey)
+	return l.completeSentIssue(userID, ir, issue), ir.ForeignUserID, nil
+}
+
+// popReference removes the reference of the issue of the my list of userID that pop removes, and returns it.
+// The issue is picked in the order GetIssueList shows the list: the first one, or the one picked by
+// mostUrgentPosition when mostUrgent is set.
+func (l *listManager) popReference(userID string, mostUrgent bool) (*IssueRef, error) {
+	storedIRs, err := l.store.GetList(userID, MyListKey)
 	if err != nil {
-		l.api.LogError("cannot clean foreigner list after pop, Err=", err.Error())
+		return nil, err
 	}
-	issue, err = l.store.GetAndRemoveIssue(ir.ForeignIssueID)
-	if err != nil {
-		l.api.LogError("cannot clean foreigner issue after pop, Err=", err.Error())
+
+	irs := []*IssueRef{}
+	issues := []*Issue{}
+	for _, ir := range storedIRs {
+		issue, getErr := l.store.GetIssue(ir.IssueID)
+		if getErr != nil {
+			continue
+		}
+		irs = append(irs, ir)
+		issues = append(issues, issue)
+	}
+	if len(irs) == 0 {
+		return nil, errors.New("cannot find issue")
+	}
+
+	positions := make([]int, len(issues))
+	for i := range positions {
+		positions[i] = i
+	}
+	sort.SliceStable(positions, func(i, j int) bool {
+		return issues[positions[i]].Priority > issues[positions[j]].Priority
+	})
+	shown := make([]*Issue, len(issues))
+	for i, position := range positions {
+		shown[i] = issues[position]
 	}
 
-	return issue, ir.ForeignUserID, nil
+	position := 0
+	if mostUrgent {
+		position = mostUrgentPosition(shown)
+	}
+	ir := irs[positions[position]]
+	if err = l.store.RemoveReference(userID, ir.IssueID, MyListKey); err != nil {
+		return nil, err
+	}
+	return ir, nil
 }
 
 func (l *listManager) BumpIssue(userID, issueID string) (todoMessage string, receiver string, foreignIssueID string, outErr error) {
@@ -367,7 +971,10 @@ func (l *listManager) BumpIssue(userID, issueID string) (todoMessage string, rec
 	}
 
 	if ir == nil {
-		return "", "", "", fmt.Errorf("cannot find sender issue")
+		return "", "", "", errors.Wrap(errIssueNotFound, "cannot find sender issue")
+	}
+	if l.isCompletedIssue(issueID) {
+		return "", "", "", errAlreadyCompleted
 	}
 
 	err = l.store.BumpReference(ir.ForeignUserID, ir.ForeignIssueID, InListKey)
@@ -384,6 +991,47 @@ func (l *listManager) BumpIssue(userID, issueID string) (todoMessage string, rec
 	return issue.Message, ir.ForeignUserID, ir.ForeignIssueID, nil
 }
 
+// isCompletedIssue checks whether issueID is the sender's copy of a todo its receiver completed.
+func (l *listManager) isCompletedIssue(issueID string) bool {
+	issue, err := l.store.GetIssue(issueID)
+	return err == nil && issue.CompletedBy != ""
+}
+
+func (l *listManager) BumpIssues(userID string, issueIDs []string) (map[string][]*Issue, error) {
+	refs := []*IssueRef{}
+	for _, issueID := range issueIDs {
+		ir, _, err := l.store.GetIssueReference(userID, issueID, OutListKey)
+		if err != nil {
+			return nil, err
+		}
+		if ir == nil || ir.ForeignUserID == "" {
+			return nil, errors.Wrapf(errIssueNotFound, "cannot find sender issue %s", issueID)
+		}
+		if l.isCompletedIssue(issueID) {
+			return nil, errors.Wrapf(errAlreadyCompleted, "cannot bump sender issue %s", issueID)
+		}
+		refs = append(refs, ir)
+	}
+
+	// Bump in reverse so the received issues end up in the order they were given
+	bumped := map[string][]*Issue{}
+	for i := len(refs) - 1; i >= 0; i-- {
+		ir := refs[i]
+		if err := l.store.BumpReference(ir.ForeignUserID, ir.ForeignIssueID, InListKey); err != nil {
+			return nil, err
+		}
+
+		issue, err := l.store.GetIssue(ir.ForeignIssueID)
+		if err != nil {
+			l.api.LogError("cannot find foreigner issue after bump, Err=", err.Error())
+			continue
+		}
+		bumped[ir.ForeignUserID] = append([]*Issue{issue}, bumped[ir.ForeignUserID]...)
+	}
+
+	return bumped, nil
+}
+
 func (l *listManager) GetUserName(userID string) string {
 	user, err := l.api.GetUser(userID)
 	if err != nil {
@@ -392,7 +1040,34 @@ func (l *listManager) GetUserName(userID string) string {
 	return user.Username
 }
 
-func (l *listManager) extendIssueInfo(issue *Issue, ir *IssueRef) *ExtendedIssue {
+// channelNames caches the names of the channels of the issues extended for a user, empty for the channels they
+// are not a member of
+type channelNames map[string]string
+
+// getChannelName returns the name of channelID if userID is a member of it, caching it in names.
+func (l *listManager) getChannelName(userID, channelID string, names channelNames) string {
+	if name, ok := names[channelID]; ok {
+		return name
+	}
+
+	names[channelID] = ""
+	if _, appErr := l.api.GetChannelMember(channelID, userID); appErr != nil {
+		return ""
+	}
+
+	channel, appErr := l.api.GetChannel(channelID)
+	if appErr != nil {
+		l.api.LogDebug("cannot get the channel of an issue", "error", appErr.Error())
+		return ""
+	}
+
+	names[channelID] = channel.Name
+	return channel.Name
+}
+
+// extendIssueInfo extends issue on the list of userID with the information for the front-end. names caches the
+// channel names for the issues extended within the same request.
+func (l *listManager) extendIssueInfo(userID string, issue *Issue, ir *IssueRef, names channelNames) *ExtendedIssue {
 	if issue == nil || ir == nil {
 		return nil
 	}
@@ -401,6 +1076,22 @@ func (l *listManager) extendIssueInfo(issue *Issue, ir *IssueRef) *ExtendedIssue
 		Issue: *issue,
 	}
 
+	if issue.WaitingOn != "" {
+		feIssue.WaitingOnUser = l.GetUserName(issue.WaitingOn)
+	}
+
+	if issue.CompletedBy != "" {
+		feIssue.CompletedByUser = l.GetUserName(issue.CompletedBy)
+	}
+
+	if issue.SentBy != "" {
+		feIssue.SentByUser = l.GetUserName(issue.SentBy)
+	}
+
+	if issue.ChannelID != "" {
+		feIssue.ChannelName = l.getChannelName(userID, issue.ChannelID, names)
+	}
+
 	if ir.ForeignUserID == "" {
 		return feIssue
 	}
@@ -0,0 +1,498 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendIssueInsertionOrder(t *testing.T) {
+	tests := []struct {
+		name          string
+		receivedOnTop bool
+		want          []string
+	}{
+		{
+			name:          "New received issues go to the bottom by default",
+			receivedOnTop: false,
+			want:          []string{"first", "second"},
+		},
+		{
+			name:          "New received issues go to the top when preferred",
+			receivedOnTop: true,
+			want:          []string{"second", "first"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv()
+			p := env.newPlugin()
+			require.NoError(t, p.saveReceivedOnTopPreference("receiver", tt.receivedOnTop))
+
+			_, err := p.listManager.SendIssue("sender", "receiver", "first", "", "", IssueFields{})
+			require.NoError(t, err)
+			_, err = p.listManager.SendIssue("sender", "receiver", "second", "", "", IssueFields{})
+			require.NoError(t, err)
+
+			issues, err := p.listManager.GetIssueList("receiver", InListKey)
+			require.NoError(t, err)
+			require.Len(t, issues, len(tt.want))
+			for i, message := range tt.want {
+				assert.Equal(t, message, issues[i].Message)
+			}
+
+			// The sender's list keeps the order the issues were sent in
+			sent, err := p.listManager.GetIssueList("sender", OutListKey)
+			require.NoError(t, err)
+			require.Len(t, sent, 2)
+			assert.Equal(t, "first", sent[0].Message)
+		})
+	}
+}
+
+func TestGetStarredIssueList(t *testing.T) {
+	env := newTestEnv()
+	p := env.newPlugin()
+
+	mine, err := p.listManager.AddIssue("user", "mine", "", "", IssueFields{})
+	require.NoError(t, err)
+	_, err = p.listManager.AddIssue("user", "not starred", "", "", IssueFields{})
+	require.NoError(t, err)
+	receivedID, err := p.listManager.SendIssue("other", "user", "received", "", "", IssueFields{})
+	require.NoError(t, err)
+	_, err = p.listManager.SendIssue("user", "other", "sent", "", "", IssueFields{})
+	require.NoError(t, err)
+	sent, err := p.listManager.GetIssueList("user", OutListKey)
+	require.NoError(t, err)
+	require.Len(t, sent, 1)
+
+	for _, issueID := range []string{mine.ID, receivedID, sent[0].ID} {
+		starred, starErr := p.listManager.StarIssue("user", issueID)
+		require.NoError(t, starErr)
+		assert.True(t, starred)
+	}
+
+	issues, err := p.listManager.GetIssueList("user", StarredListKey)
+	require.NoError(t, err)
+	messages := []string{}
+	for _, issue := range issues {
+		messages = append(messages, issue.Message)
+	}
+	assert.Equal(t, []string{"mine", "received", "sent"}, messages)
+
+	// Stars are personal, the other side of a shared todo is not starred
+	otherIssues, err := p.listManager.GetIssueList("other", StarredListKey)
+	require.NoError(t, err)
+	assert.Empty(t, otherIssues)
+
+	// Starring again toggles the star off
+	starred, err := p.listManager.StarIssue("user", mine.ID)
+	require.NoError(t, err)
+	assert.False(t, starred)
+	issues, err = p.listManager.GetIssueList("user", StarredListKey)
+	require.NoError(t, err)
+	assert.Len(t, issues, 2)
+
+	_, err = p.listManager.StarIssue("other", mine.ID)
+	assert.Error(t, err)
+}
+
+func TestIssueListSortedByPriority(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("user", "user")
+	p := env.newPlugin()
+
+	for _, todo := range []struct {
+		message  string
+		priority int
+	}{
+		{"low", PriorityLow},
+		{"first normal", PriorityNormal},
+		{"first high", PriorityHigh},
+		{"second normal", PriorityNormal},
+		{"second high", PriorityHigh},
+	} {
+		_, err := p.listManager.AddIssue("user", todo.message, "", "", IssueFields{Priority: todo.priority})
+		require.NoError(t, err)
+	}
+
+	// Todos stored before priorities existed have none, and are normal
+	legacy := []byte(`{"id":"legacy","message":"legacy","create_at":1}`)
+	require.Nil(t, env.api.KVSet(issueKey("legacy"), legacy))
+	require.NoError(t, p.listManager.(*listManager).store.AddReference("user", "legacy", MyListKey, "", ""))
+
+	issues, err := p.listManager.GetIssueList("user", MyListKey)
+	require.NoError(t, err)
+	messages := []string{}
+	for _, issue := range issues {
+		messages = append(messages, issue.Message)
+	}
+	assert.Equal(t, []string{"first high", "second high", "first normal", "second normal", "legacy", "low"}, messages)
+	assert.Equal(t, PriorityNormal, issues[4].Priority)
+
+	list := issuesListToString(issues)
+	assert.Contains(t, list, "1. :exclamation: first high")
+	assert.Contains(t, list, "3. first normal")
+	assert.Contains(t, list, "6. low")
+}
+
+func TestOverdueIssueList(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("user", "user")
+	env.addUser("other", "other")
+	p := env.newPlugin()
+
+	now := model.GetMillis()
+	hour := time.Hour.Milliseconds()
+	_, err := p.listManager.AddIssue("user", "late", "", "", IssueFields{DueAt: now - hour})
+	require.NoError(t, err)
+	_, err = p.listManager.AddIssue("user", "upcoming", "", "", IssueFields{DueAt: now + hour})
+	require.NoError(t, err)
+	_, err = p.listManager.AddIssue("user", "no due date", "", "", IssueFields{})
+	require.NoError(t, err)
+	_, err = p.listManager.SendIssue("other", "user", "received late", "", "", IssueFields{DueAt: now - 2*hour})
+	require.NoError(t, err)
+
+	issues, err := p.listManager.GetIssueList("user", OverdueListKey)
+	require.NoError(t, err)
+	messages := []string{}
+	for _, issue := range issues {
+		messages = append(messages, issue.Message)
+	}
+	assert.Equal(t, []string{"late", "received late"}, messages)
+
+	// The sender sees the deadline of the todo it sent too
+	otherIssues, err := p.listManager.GetIssueList("other", OverdueListKey)
+	require.NoError(t, err)
+	require.Len(t, otherIssues, 1)
+	assert.Equal(t, "received late", otherIssues[0].Message)
+}
+
+func TestEditIssueValidatesText(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+
+	receivedID, err := p.listManager.SendIssue("alice", "bob", "keep me", "as is", "", IssueFields{})
+	require.NoError(t, err)
+
+	_, _, _, err = p.listManager.EditIssue("bob", receivedID, " \n", "details")
+	assert.Equal(t, errEmptyMessage, err)
+	_, _, _, err = p.listManager.EditIssue("bob", receivedID, "short", strings.Repeat("a", MaxIssueTextLength))
+	assert.Equal(t, errIssueTextTooLong, err)
+
+	// Neither side of the shared todo changes
+	received, err := p.listManager.GetIssue("bob", receivedID)
+	require.NoError(t, err)
+	assert.Equal(t, "keep me", received.Message)
+	assert.Equal(t, "as is", received.Description)
+	sent, err := p.listManager.GetIssueList("alice", OutListKey)
+	require.NoError(t, err)
+	require.Len(t, sent, 1)
+	assert.Equal(t, "keep me", sent[0].Message)
+
+	_, _, _, err = p.listManager.EditIssue("bob", receivedID, strings.Repeat("a", MaxIssueTextLength-len("short")), "short")
+	assert.NoError(t, err)
+
+	w := env.serve(p, "bob", http.MethodPost, "/edit", editAPIRequest{ID: receivedID, Message: "long", Description: strings.Repeat("a", MaxIssueTextLength)})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSendIssueLimitsSharedDescription(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+	p.setConfiguration(&configuration{EnableSendToOthers: true})
+
+	_, err := p.listManager.SendIssue("alice", "bob", "too long", strings.Repeat("a", MaxSharedDescriptionLength+1), "", IssueFields{})
+	assert.Equal(t, errSharedDescriptionTooLong, err)
+	sent, err := p.listManager.GetIssueList("alice", OutListKey)
+	require.NoError(t, err)
+	assert.Empty(t, sent, "nothing is stored for either user")
+	received, err := p.listManager.GetIssueList("bob", InListKey)
+	require.NoError(t, err)
+	assert.Empty(t, received)
+
+	_, err = p.listManager.SendIssue("alice", "bob", "fits", strings.Repeat("a", MaxSharedDescriptionLength), "", IssueFields{})
+	assert.NoError(t, err)
+
+	// Todos kept to oneself can still have the longer descriptions
+	_, err = p.listManager.AddIssue("alice", "mine", strings.Repeat("a", MaxSharedDescriptionLength+1), "", IssueFields{})
+	assert.NoError(t, err)
+
+	w := env.serve(p, "alice", http.MethodPost, "/add", &addAPIRequest{Message: "too long", SendTo: "bob", Description: strings.Repeat("a", MaxSharedDescriptionLength+1)})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSendUntrackedIssueLimitsSharedDescription(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+
+	_, err := p.listManager.SendUntrackedIssue("alice", "bob", "too long", strings.Repeat("a", MaxSharedDescriptionLength+1), "", IssueFields{})
+	assert.Equal(t, errSharedDescriptionTooLong, err)
+	received, err := p.listManager.GetIssueList("bob", InListKey)
+	require.NoError(t, err)
+	assert.Empty(t, received)
+
+	_, err = p.listManager.SendUntrackedIssue("alice", "bob", "fits", strings.Repeat("a", MaxSharedDescriptionLength), "", IssueFields{})
+	assert.NoError(t, err)
+}
+
+func TestEditIssueLimitsSharedDescription(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+
+	receivedID, err := p.listManager.SendIssue("alice", "bob", "review", "", "", IssueFields{})
+	require.NoError(t, err)
+	_, _, _, err = p.listManager.EditIssue("bob", receivedID, "review", strings.Repeat("a", MaxSharedDescriptionLength+1))
+	assert.Equal(t, errSharedDescriptionTooLong, err)
+	received, err := p.listManager.GetIssue("bob", receivedID)
+	require.NoError(t, err)
+	assert.Empty(t, received.Description)
+
+	w := env.serve(p, "bob", http.MethodPost, "/edit", editAPIRequest{ID: receivedID, Message: "review", Description: strings.Repeat("a", MaxSharedDescriptionLength+1)})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Todos kept to oneself can still get the longer descriptions
+	issue, err := p.listManager.AddIssue("alice", "mine", "", "", IssueFields{})
+	require.NoError(t, err)
+	_, _, _, err = p.listManager.EditIssue("alice", issue.ID, "mine", strings.Repeat("a", MaxSharedDescriptionLength+1))
+	assert.NoError(t, err)
+}
+
+func TestIssueHistory(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	env.addUser("carol", "carol")
+	p := env.newPlugin()
+	store := p.listManager.(*listManager).store
+
+	receivedID, err := p.listManager.SendIssue("alice", "bob", "draft", "", "", IssueFields{})
+	require.NoError(t, err)
+	sent, err := p.listManager.GetIssueList("alice", OutListKey)
+	require.NoError(t, err)
+	sentID := sent[0].ID
+
+	_, _, _, err = p.listManager.EditIssue("bob", receivedID, "final", "details")
+	require.NoError(t, err)
+
+	// The edit is recorded on both sides of the shared todo
+	for _, issueID := range []string{receivedID, sentID} {
+		issue, getErr := store.GetIssue(issueID)
+		require.NoError(t, getErr)
+		require.Len(t, issue.History, 1)
+		assert.Equal(t, "bob", issue.History[0].UserID)
+		assert.Equal(t, "edited", issue.History[0].Action)
+		assert.Equal(t, `message changed from "draft" to "final", description changed`, issue.History[0].Details)
+	}
+
+	_, _, _, err = p.listManager.ChangeAssignment(sentID, "alice", "carol", true)
+	require.NoError(t, err)
+
+	issue, err := store.GetIssue(sentID)
+	require.NoError(t, err)
+	require.Len(t, issue.History, 2)
+	assert.Equal(t, "reassigned", issue.History[1].Action)
+	assert.Equal(t, "to @carol", issue.History[1].Details)
+
+	// The new receiver gets the history so far
+	received, err := p.listManager.GetIssueList("carol", InListKey)
+	require.NoError(t, err)
+	require.Len(t, received, 1)
+	assert.Len(t, received[0].History, 2)
+}
+
+func TestIssueHistoryIsBounded(t *testing.T) {
+	issue := newIssue("message", "", "", IssueFields{})
+	for i := 0; i < MaxIssueHistory+5; i++ {
+		issue.addChange("user", "edited", fmt.Sprintf("%d", i))
+	}
+
+	require.Len(t, issue.History, MaxIssueHistory)
+	assert.Equal(t, "5", issue.History[0].Details)
+	assert.Equal(t, fmt.Sprintf("%d", MaxIssueHistory+4), issue.History[MaxIssueHistory-1].Details)
+}
+
+func TestCompleteReceivedIssueSenderCopy(t *testing.T) {
+	tests := []struct {
+		name string
+		keep bool
+	}{
+		{name: "Sent todo is removed by default", keep: false},
+		{name: "Sent todo is kept when preferred", keep: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv()
+			env.addUser("alice", "alice")
+			env.addUser("bob", "bob")
+			p := env.newPlugin()
+			require.NoError(t, p.saveKeepCompletedSentPreference("alice", tt.keep))
+
+			receivedID, err := p.listManager.SendIssue("alice", "bob", "review", "", "", IssueFields{})
+			require.NoError(t, err)
+			_, _, err = p.listManager.AcceptIssue("bob", receivedID)
+			require.NoError(t, err)
+
+			issue, foreignID, _, err := p.listManager.CompleteIssue("bob", receivedID)
+			require.NoError(t, err)
+			assert.Equal(t, "alice", foreignID)
+			assert.Equal(t, "review", issue.Message)
+
+			mine, err := p.listManager.GetIssueList("bob", MyListKey)
+			require.NoError(t, err)
+			assert.Empty(t, mine)
+
+			sent, err := p.listManager.GetIssueList("alice", OutListKey)
+			require.NoError(t, err)
+			if !tt.keep {
+				assert.Empty(t, sent)
+				return
+			}
+
+			require.Len(t, sent, 1)
+			assert.Equal(t, "review", sent[0].Message)
+			assert.Equal(t, "bob", sent[0].CompletedBy)
+			assert.NotZero(t, sent[0].CompletedAt)
+			assert.Equal(t, "bob", sent[0].CompletedByUser)
+			assert.Equal(t, "bob", sent[0].ForeignUser, "the sent todo stays linked to its receiver")
+			assert.Contains(t, issuesListToString(sent), "   * Completed by @bob\n")
+
+			_, _, _, err = p.listManager.BumpIssue("alice", sent[0].ID)
+			assert.Equal(t, errAlreadyCompleted, err)
+			_, err = p.runCancelCommand([]string{"1"}, &model.CommandArgs{UserId: "alice"})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "@bob has already completed that Todo")
+
+			// The sender clears it by completing it themselves
+			_, foreignID, _, err = p.listManager.CompleteIssue("alice", sent[0].ID)
+			require.NoError(t, err)
+			assert.Empty(t, foreignID)
+			sent, err = p.listManager.GetIssueList("alice", OutListKey)
+			require.NoError(t, err)
+			assert.Empty(t, sent)
+		})
+	}
+}
+
+func TestPopReceivedIssueSenderCopy(t *testing.T) {
+	for _, keep := range []bool{false, true} {
+		env := newTestEnv()
+		env.addUser("alice", "alice")
+		env.addUser("bob", "bob")
+		p := env.newPlugin()
+		require.NoError(t, p.saveKeepCompletedSentPreference("alice", keep))
+
+		receivedID, err := p.listManager.SendIssue("alice", "bob", "review", "", "", IssueFields{})
+		require.NoError(t, err)
+		_, _, err = p.listManager.AcceptIssue("bob", receivedID)
+		require.NoError(t, err)
+
+		_, foreignID, err := p.listManager.PopIssue("bob", false)
+		require.NoError(t, err)
+		assert.Equal(t, "alice", foreignID)
+
+		sent, err := p.listManager.GetIssueList("alice", OutListKey)
+		require.NoError(t, err)
+		if !keep {
+			assert.Empty(t, sent)
+			continue
+		}
+		require.Len(t, sent, 1)
+		assert.Equal(t, "bob", sent[0].CompletedBy)
+
+		// Removing the kept todo does not reach for the receiver's copy anymore
+		_, foreignID, _, _, err = p.listManager.RemoveIssue("alice", sent[0].ID)
+		require.NoError(t, err)
+		assert.Empty(t, foreignID)
+	}
+}
+
+func TestRelateIssues(t *testing.T) {
+	env := newTestEnv()
+	p := env.newPlugin()
+
+	first, err := p.listManager.AddIssue("user", "write the proposal", "", "", IssueFields{})
+	require.NoError(t, err)
+	second, err := p.listManager.AddIssue("user", "book the meeting", "", "", IssueFields{})
+	require.NoError(t, err)
+	third, err := p.listManager.AddIssue("user", "order lunch", "", "", IssueFields{})
+	require.NoError(t, err)
+
+	require.NoError(t, p.listManager.RelateIssues("user", first.ID, second.ID, true))
+	require.NoError(t, p.listManager.RelateIssues("user", first.ID, third.ID, true))
+	// Relating again does not duplicate the link
+	require.NoError(t, p.listManager.RelateIssues("user", second.ID, first.ID, true))
+
+	issue, err := p.listManager.GetIssue("user", first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"book the meeting", "order lunch"}, issue.RelatedIssues)
+	assert.Contains(t, issueToString(issue), "* See also: book the meeting\n* See also: order lunch\n")
+
+	issue, err = p.listManager.GetIssue("user", second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"write the proposal"}, issue.RelatedIssues)
+
+	// Unrelating from either side removes the link on both
+	require.NoError(t, p.listManager.RelateIssues("user", second.ID, first.ID, false))
+	for id, want := range map[string][]string{first.ID: {"order lunch"}, second.ID: nil} {
+		issue, err = p.listManager.GetIssue("user", id)
+		require.NoError(t, err)
+		assert.Equal(t, want, issue.RelatedIssues)
+	}
+
+	// Removing a todo removes it from the todos it was related to
+	_, _, _, _, err = p.listManager.RemoveIssue("user", third.ID)
+	require.NoError(t, err)
+	issue, err = p.listManager.GetIssue("user", first.ID)
+	require.NoError(t, err)
+	assert.Empty(t, issue.Related)
+
+	assert.Error(t, p.listManager.RelateIssues("user", first.ID, first.ID, true))
+	assert.Error(t, p.listManager.RelateIssues("other", first.ID, second.ID, true))
+}
+
+func TestAcceptIssueMarksSentIssueAccepted(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	env.addUser("carol", "carol")
+	p := env.newPlugin()
+
+	receivedID, err := p.listManager.SendIssue("alice", "bob", "review", "", "", IssueFields{})
+	require.NoError(t, err)
+	sent, err := p.listManager.GetIssueList("alice", OutListKey)
+	require.NoError(t, err)
+	require.Len(t, sent, 1)
+	assert.Equal(t, InFlag, sent[0].ForeignList)
+
+	_, _, err = p.listManager.AcceptIssue("bob", receivedID)
+	require.NoError(t, err)
+	sent, err = p.listManager.GetIssueList("alice", OutListKey)
+	require.NoError(t, err)
+	require.Len(t, sent, 1)
+	assert.Equal(t, MyListKey, sent[0].ForeignList)
+
+	// Reassigning the todo makes it pending again for the new receiver
+	_, _, _, err = p.listManager.ChangeAssignment(sent[0].ID, "alice", "carol", true)
+	require.NoError(t, err)
+	sent, err = p.listManager.GetIssueList("alice", OutListKey)
+	require.NoError(t, err)
+	require.Len(t, sent, 1)
+	assert.Equal(t, InFlag, sent[0].ForeignList)
+}
@@ -42,6 +42,62 @@ const manifestStr = `
         "help_text": "When true, the buttons in the team sidebar on the left toolbar will be hidden.",
         "placeholder": "",
         "default": null
+      },
+      {
+        "key": "enable_send_to_others",
+        "display_name": "Enable sending Todos to other users:",
+        "type": "bool",
+        "help_text": "When false, users can only add Todos to their own lists. Sending a Todo to someone else or reassigning it is disabled.",
+        "placeholder": "",
+        "default": true
+      },
+      {
+        "key": "restrict_send_to_channel_members",
+        "display_name": "Restrict sending Todos to channel members:",
+        "type": "bool",
+        "help_text": "When true, Todos sent with a slash command can only be sent to members of the channel the command is run in.",
+        "placeholder": "",
+        "default": false
+      },
+      {
+        "key": "reply_only_on_create_and_complete",
+        "display_name": "Only reply on threads when Todos are added or completed:",
+        "type": "bool",
+        "help_text": "When true, the bot does not reply on the thread a Todo is attached to when the Todo is removed, popped or withdrawn. It still replies when the Todo is added, sent or completed.",
+        "placeholder": "",
+        "default": false
+      },
+      {
+        "key": "quiet_thread_channels",
+        "display_name": "Channels without thread replies:",
+        "type": "text",
+        "help_text": "Comma-separated IDs of the channels where the bot never replies on the threads Todos are attached to.",
+        "placeholder": "",
+        "default": ""
+      },
+      {
+        "key": "max_reminder_items",
+        "display_name": "Maximum Todos on the daily reminder:",
+        "type": "number",
+        "help_text": "The daily reminder lists at most this many Todos, and tells users how many more they have. Fewer are listed when needed to fit in a single message.",
+        "placeholder": "",
+        "default": 50
+      },
+      {
+        "key": "announcement_channel_id",
+        "display_name": "Announcement channel ID:",
+        "type": "text",
+        "help_text": "When set, the bot posts a message describing the plugin in this channel the first time the plugin is activated. It is only posted once per install.",
+        "placeholder": "",
+        "default": ""
+      },
+      {
+        "key": "audit_channel_id",
+        "display_name": "Completions audit channel ID:",
+        "type": "text",
+        "help_text": "When set, the bot logs every completed Todo in this channel, with who completed it and when. Completions close together are logged in a single post.",
+        "placeholder": "",
+        "default": ""
       }
     ]
   }
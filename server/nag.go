@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	// NagJobInterval is how often the todos that nag their owners are checked
+	NagJobInterval = 5 * time.Minute
+	// MinNagInterval is the shortest interval a todo can nag its owner at
+	MinNagInterval = 15 * time.Minute
+	// MaxNagInterval is the longest interval a todo can nag its owner at
+	MaxNagInterval = 30 * 24 * time.Hour
+)
+
+var nagIntervalRegexp = regexp.MustCompile(`^(\d+)([mhd])$`)
+
+// parseNagInterval parses intervals like 30m, 4h or 2d.
+func parseNagInterval(input string) (time.Duration, error) {
+	match := nagIntervalRegexp.FindStringSubmatch(input)
+	if match == nil {
+		return 0, fmt.Errorf("`%s` is not a valid interval, use minutes, hours or days like 30m, 4h or 2d", input)
+	}
+
+	amount, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, fmt.Errorf("`%s` is not a valid interval", input)
+	}
+
+	unit := time.Minute
+	switch match[2] {
+	case "h":
+		unit = time.Hour
+	case "d":
+		unit = 24 * time.Hour
+	}
+
+	interval := time.Duration(amount) * unit
+	if interval < MinNagInterval || interval > MaxNagInterval {
+		return 0, fmt.Errorf("the interval must be between %s and %d days", MinNagInterval, MaxNagInterval/(24*time.Hour))
+	}
+
+	return interval, nil
+}
+
+// formatNagInterval formats interval like parseNagInterval parses it, in the largest unit it is a whole number of.
+func formatNagInterval(interval time.Duration) string {
+	switch {
+	case interval%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", interval/(24*time.Hour))
+	case interval%time.Hour == 0:
+		return fmt.Sprintf("%dh", interval/time.Hour)
+	default:
+		return fmt.Sprintf("%dm", interval/time.Minute)
+	}
+}
+
+func (p *Plugin) sendNags() {
+	now := model.GetMillis()
+	if err := p.sendDueNags(now); err != nil {
+		p.API.LogError("Unable to send nags", "error", err.Error())
+	}
+	if err := p.sendDueTriageReminders(now); err != nil {
+		p.API.LogError("Unable to send triage reminders", "error", err.Error())
+	}
+}
+
+// sendDueTriageReminders reminds the users whose triage reminder is due at now about the todos they have not
+// accepted or declined yet. Users with nothing waiting are not reminded until something arrives.
+func (p *Plugin) sendDueTriageReminders(now int64) error {
+	reminders, _, err := p.getTriageReminders()
+	if err != nil {
+		return errors.Wrap(err, "unable to get the triage reminders")
+	}
+
+	sent := map[string]bool{}
+	for _, reminder := range reminders {
+		if reminder.LastSentAt+reminder.Interval > now {
+			continue
+		}
+
+		issues, listErr := p.listManager.GetIssueList(reminder.UserID, InListKey)
+		if listErr != nil {
+			p.API.LogError("Unable to get the incoming list for a triage reminder", "error", listErr.Error())
+			continue
+		}
+		if len(issues) == 0 {
+			continue
+		}
+
+		p.PostBotDM(reminder.UserID, fmt.Sprintf("You have %d received Todos waiting to be accepted or declined:\n\n%s", len(issues), issuesListToString(issues)))
+		sent[reminder.UserID] = true
+	}
+
+	if len(sent) == 0 {
+		return nil
+	}
+
+	return p.updateTriageReminders(func(reminders []*triageReminder) []*triageReminder {
+		for _, reminder := range reminders {
+			if sent[reminder.UserID] {
+				reminder.LastSentAt = now
+			}
+		}
+		return reminders
+	})
+}
+
+// sendDueNags DMs the owners of the todos whose nag is due at now. Todos that were completed,
+// removed or had their nag cleared since are dropped from the stored nags.
+func (p *Plugin) sendDueNags(now int64) error {
+	nags, _, err := p.getNags()
+	if err != nil {
+		return errors.Wrap(err, "unable to get the nags")
+	}
+
+	stopped := []*nagRef{}
+	for _, nag := range nags {
+		issue, due, nagErr := p.listManager.NagIssue(nag.UserID, nag.IssueID, now)
+		if nagErr != nil {
+			if errors.Cause(nagErr) == errIssueNotFound {
+				stopped = append(stopped, nag)
+			} else {
+				p.API.LogError("Unable to check a nag", "error", nagErr.Error())
+			}
+			continue
+		}
+		if issue == nil {
+			stopped = append(stopped, nag)
+			continue
+		}
+		if due {
+			p.PostBotDM(nag.UserID, fmt.Sprintf("This Todo is still waiting for you:\n%s", issue.Message))
+		}
+	}
+
+	if len(stopped) == 0 {
+		return nil
+	}
+
+	return p.removeNags(stopped)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNagInterval(t *testing.T) {
+	for input, want := range map[string]time.Duration{
+		"30m": 30 * time.Minute,
+		"4h":  4 * time.Hour,
+		"2d":  48 * time.Hour,
+	} {
+		interval, err := parseNagInterval(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, interval, input)
+	}
+
+	for _, input := range []string{"", "4", "h", "-1h", "1w", "5m", "31d"} {
+		_, err := parseNagInterval(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestSendDueNags(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	p := env.newPlugin()
+	extra := &model.CommandArgs{UserId: "alice"}
+
+	first, err := p.listManager.AddIssue("alice", "call the bank", "", "", IssueFields{})
+	require.NoError(t, err)
+	_, err = p.listManager.AddIssue("alice", "is quiet", "", "", IssueFields{})
+	require.NoError(t, err)
+
+	_, err = p.runNagCommand([]string{"1", "1h"}, extra)
+	require.NoError(t, err)
+	issue, err := p.listManager.GetIssue("alice", first.ID)
+	require.NoError(t, err)
+	hour := int64(time.Hour / time.Millisecond)
+	setAt := issue.LastNagAt
+
+	require.NoError(t, p.sendDueNags(setAt+hour-1))
+	assert.Empty(t, env.postsTo("dm_alice"))
+
+	require.NoError(t, p.sendDueNags(setAt+hour))
+	posts := env.postsTo("dm_alice")
+	require.Len(t, posts, 1)
+	assert.Equal(t, "This Todo is still waiting for you:\ncall the bank", posts[0].Message)
+
+	// The next nag is an interval after the last one
+	require.NoError(t, p.sendDueNags(setAt+2*hour-1))
+	assert.Len(t, env.postsTo("dm_alice"), 1)
+	require.NoError(t, p.sendDueNags(setAt+2*hour))
+	assert.Len(t, env.postsTo("dm_alice"), 2)
+
+	// Turning it off stops the nags
+	_, err = p.runNagCommand([]string{"1", "off"}, extra)
+	require.NoError(t, err)
+	require.NoError(t, p.sendDueNags(setAt+10*hour))
+	assert.Len(t, env.postsTo("dm_alice"), 2)
+	nags, _, err := p.getNags()
+	require.NoError(t, err)
+	assert.Empty(t, nags)
+
+	// Todos put aside for someday nag again once they are back
+	_, err = p.runNagCommand([]string{"1", "1h"}, extra)
+	require.NoError(t, err)
+	require.NoError(t, p.listManager.SetSomeday("alice", first.ID, true))
+	require.NoError(t, p.sendDueNags(setAt+20*hour))
+	assert.Len(t, env.postsTo("dm_alice"), 2)
+	require.NoError(t, p.listManager.SetSomeday("alice", first.ID, false))
+	require.NoError(t, p.sendDueNags(setAt+21*hour))
+	assert.Len(t, env.postsTo("dm_alice"), 3)
+
+	// Completing the todo stops the nags too, it is second on the list since it came back
+	_, err = p.runNagCommand([]string{"2", "1h"}, extra)
+	require.NoError(t, err)
+	_, _, _, err = p.listManager.CompleteIssue("alice", first.ID)
+	require.NoError(t, err)
+	require.NoError(t, p.sendDueNags(setAt+100*hour))
+	assert.Len(t, env.postsTo("dm_alice"), 3)
+	nags, _, err = p.getNags()
+	require.NoError(t, err)
+	assert.Empty(t, nags)
+
+	isUserError, err := p.runNagCommand([]string{"1", "1w"}, extra)
+	assert.Error(t, err)
+	assert.True(t, isUserError)
+}
+
+func TestSendDueTriageReminders(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+	hour := int64(time.Hour / time.Millisecond)
+	now := model.GetMillis()
+
+	_, err := p.runSettingsCommand([]string{"triage_reminder", "4h"}, &model.CommandArgs{UserId: "bob"})
+	require.NoError(t, err)
+	assert.Equal(t, "You will be reminded every 4h about the received Todos you have not accepted or declined.", env.lastEphemeral())
+	assert.Equal(t, 4*hour, p.getTriageReminderInterval("bob"))
+
+	first, err := p.listManager.SendIssue("alice", "bob", "review the budget", "", "", IssueFields{})
+	require.NoError(t, err)
+	second, err := p.listManager.SendIssue("alice", "bob", "book the venue", "", "", IssueFields{})
+	require.NoError(t, err)
+	env.resetRecords()
+
+	require.NoError(t, p.sendDueTriageReminders(now+3*hour))
+	assert.Empty(t, env.postsTo("dm_bob"))
+
+	require.NoError(t, p.sendDueTriageReminders(now+5*hour))
+	posts := env.postsTo("dm_bob")
+	require.Len(t, posts, 1)
+	assert.Contains(t, posts[0].Message, "You have 2 received Todos waiting to be accepted or declined:")
+	assert.Contains(t, posts[0].Message, "review the budget")
+
+	// The next reminder is an interval after the last one
+	require.NoError(t, p.sendDueTriageReminders(now+8*hour))
+	assert.Len(t, env.postsTo("dm_bob"), 1)
+
+	// Reminders stop once everything was accepted or declined
+	_, _, err = p.listManager.AcceptIssue("bob", first)
+	require.NoError(t, err)
+	_, _, _, _, err = p.listManager.RemoveIssue("bob", second)
+	require.NoError(t, err)
+	require.NoError(t, p.sendDueTriageReminders(now+20*hour))
+	assert.Len(t, env.postsTo("dm_bob"), 1)
+
+	_, err = p.runSettingsCommand([]string{"triage_reminder", "off"}, &model.CommandArgs{UserId: "bob"})
+	require.NoError(t, err)
+	assert.Zero(t, p.getTriageReminderInterval("bob"))
+}
+
+func TestFormatNagInterval(t *testing.T) {
+	for _, input := range []string{"30m", "90m", "4h", "2d", "36h"} {
+		interval, err := parseNagInterval(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, input, formatNagInterval(interval))
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+const (
+	// nextStarredScore is added to the score of starred todos
+	nextStarredScore = 10
+	// nextNagScore is added to the score of todos that nag their owner
+	nextNagScore = 5
+	// nextWaitingScore is taken from the score of todos waiting on someone else
+	nextWaitingScore = 20
+	// nextMaxAgeScore caps the score a todo gets for its age, one point per day
+	nextMaxAgeScore = 14
+)
+
+// nextActionScore scores how much an issue of the my list deserves to be done next at now.
+// Starred and nagging issues go first, older issues before newer ones, and issues waiting on
+// someone else go last since there is nothing to do on them yet.
+func nextActionScore(issue *ExtendedIssue, now int64) int {
+	score := 0
+
+	ageDays := issue.ageDays(now)
+	if ageDays > nextMaxAgeScore {
+		ageDays = nextMaxAgeScore
+	}
+	if ageDays > 0 {
+		score += ageDays
+	}
+
+	if issue.Starred {
+		score += nextStarredScore
+	}
+	if issue.NagInterval > 0 {
+		score += nextNagScore
+	}
+	if issue.WaitingOn != "" {
+		score -= nextWaitingScore
+	}
+
+	return score
+}
+
+// pickNextAction returns the position of the issue with the best score at now, or -1 when there
+// are no issues. Ties go to the issue higher on the list.
+func pickNextAction(issues []*ExtendedIssue, now int64) int {
+	best := -1
+	bestScore := 0
+	for i, issue := range issues {
+		score := nextActionScore(issue, now)
+		if best == -1 || score > bestScore {
+			best = i
+			bestScore = score
+		}
+	}
+
+	return best
+}
+
+func (p *Plugin) runNextCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	issues, err := p.listManager.GetIssueList(extra.UserId, MyListKey)
+	if err != nil {
+		return false, err
+	}
+
+	position := pickNextAction(issues, model.GetMillis())
+	if position == -1 {
+		p.postCommandResponse(extra, "There are no Todos on your list. Enjoy your free time!")
+		return false, nil
+	}
+
+	p.postCommandResponse(extra, fmt.Sprintf("Next up, number %d on your list:\n\n%s", position+1, issueToString(issues[position])))
+	return false, nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextActionScore(t *testing.T) {
+	day := int64(24 * time.Hour / time.Millisecond)
+	now := 100 * day
+	issueAt := func(ageDays int64) *ExtendedIssue {
+		return &ExtendedIssue{Issue: Issue{CreateAt: now - ageDays*day}}
+	}
+
+	tests := []struct {
+		name  string
+		issue func() *ExtendedIssue
+		want  int
+	}{
+		{
+			name:  "New issue",
+			issue: func() *ExtendedIssue { return issueAt(0) },
+			want:  0,
+		},
+		{
+			name:  "Older issues score a point per day",
+			issue: func() *ExtendedIssue { return issueAt(3) },
+			want:  3,
+		},
+		{
+			name:  "Age score is capped",
+			issue: func() *ExtendedIssue { return issueAt(60) },
+			want:  nextMaxAgeScore,
+		},
+		{
+			name: "Starred issue",
+			issue: func() *ExtendedIssue {
+				issue := issueAt(1)
+				issue.Starred = true
+				return issue
+			},
+			want: 1 + nextStarredScore,
+		},
+		{
+			name: "Nagging issue",
+			issue: func() *ExtendedIssue {
+				issue := issueAt(0)
+				issue.NagInterval = int64(time.Hour / time.Millisecond)
+				return issue
+			},
+			want: nextNagScore,
+		},
+		{
+			name: "Issue waiting on someone else",
+			issue: func() *ExtendedIssue {
+				issue := issueAt(2)
+				issue.Starred = true
+				issue.WaitingOn = "bob"
+				return issue
+			},
+			want: 2 + nextStarredScore - nextWaitingScore,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, nextActionScore(tt.issue(), now))
+		})
+	}
+}
+
+func TestPickNextAction(t *testing.T) {
+	day := int64(24 * time.Hour / time.Millisecond)
+	now := 100 * day
+
+	assert.Equal(t, -1, pickNextAction(nil, now))
+
+	issues := []*ExtendedIssue{
+		{Issue: Issue{Message: "new", CreateAt: now}},
+		{Issue: Issue{Message: "old", CreateAt: now - 5*day}},
+		{Issue: Issue{Message: "as old", CreateAt: now - 5*day}},
+	}
+	assert.Equal(t, 1, pickNextAction(issues, now), "Ties go to the issue higher on the list")
+
+	issues[0].Starred = true
+	assert.Equal(t, 0, pickNextAction(issues, now))
+
+	issues[0].WaitingOn = "bob"
+	assert.Equal(t, 1, pickNextAction(issues, now))
+}
+
+func TestNextCommand(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	p := env.newPlugin()
+	extra := &model.CommandArgs{UserId: "alice"}
+
+	_, err := p.runNextCommand(nil, extra)
+	require.NoError(t, err)
+	assert.Contains(t, env.lastEphemeral(), "There are no Todos on your list")
+
+	_, err = p.listManager.AddIssue("alice", "write the notes", "", "", IssueFields{})
+	require.NoError(t, err)
+	second, err := p.listManager.AddIssue("alice", "book the room", "", "", IssueFields{})
+	require.NoError(t, err)
+	_, err = p.listManager.StarIssue("alice", second.ID)
+	require.NoError(t, err)
+
+	_, err = p.runNextCommand(nil, extra)
+	require.NoError(t, err)
+	assert.Contains(t, env.lastEphemeral(), "Next up, number 2 on your list:\n\n#### book the room\n")
+}
@@ -4,16 +4,38 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/mattermost/mattermost-plugin-api/cluster"
 	"github.com/mattermost/mattermost-plugin-api/experimental/telemetry"
 	"github.com/mattermost/mattermost-server/v5/model"
 	"github.com/mattermost/mattermost-server/v5/plugin"
 	"github.com/pkg/errors"
 )
 
+var (
+	// errSendToOthersDisabled is returned to users trying to send Todos when the feature is disabled
+	errSendToOthersDisabled = errors.New("sending Todos to other users is disabled on this server")
+	// errUserNotFound is returned when the user a Todo is sent to does not exist
+	errUserNotFound = errors.New("user not found")
+	// errUserBlocked is returned when the user a Todo is sent to does not accept Todo requests
+	errUserBlocked = errors.New("the user has blocked Todo requests")
+)
+
+// Machine readable codes of the errors returned by the HTTP API
+const (
+	errorCodeInvalidInput         = "invalid_input"
+	errorCodeForbidden            = "forbidden"
+	errorCodeNotFound             = "not_found"
+	errorCodeIssueNotFound        = "issue_not_found"
+	errorCodeUserNotFound         = "user_not_found"
+	errorCodeUserBlocked          = "user_blocked"
+	errorCodeSendToOthersDisabled = "send_to_others_disabled"
+	errorCodeInternal             = "internal_error"
+)
+
 const (
 	// WSEventRefresh is the WebSocket event for refreshing the Todo list
 	WSEventRefresh = "refresh"
@@ -25,25 +47,68 @@ const (
 // ListManager represents the logic on the lists
 type ListManager interface {
 	// AddIssue adds a todo to userID's myList with the message
-	AddIssue(userID, message, description, postID string) (*Issue, error)
+	AddIssue(userID, message, description, postID string, fields IssueFields) (*Issue, error)
 	// SendIssue sends the todo with the message from senderID to receiverID and returns the receiver's issueID
-	SendIssue(senderID, receiverID, message, description, postID string) (string, error)
+	SendIssue(senderID, receiverID, message, description, postID string, fields IssueFields) (string, error)
+	// SendUntrackedIssue sends the todo like SendIssue, without keeping it on senderID's outgoing list.
+	// The receiver completing or removing it does not notify the sender. Users can also send themselves untracked todos.
+	SendUntrackedIssue(senderID, receiverID, message, description, postID string, fields IssueFields) (string, error)
+	// GetIssue gets the todo issueID if it is on any of the lists of userID
+	GetIssue(userID, issueID string) (*ExtendedIssue, error)
 	// GetIssueList gets the todos on listID for userID
 	GetIssueList(userID, listID string) ([]*ExtendedIssue, error)
+	// GetStoredIssues gets the todos on listID for userID as they are stored, without extending them like GetIssueList
+	GetStoredIssues(userID, listID string) ([]*Issue, error)
+	// CountIssues returns the number of todos on listID for userID
+	CountIssues(userID, listID string) (int, error)
 	// CompleteIssue completes the todo issueID for userID, and returns the issue and the foreign ID if any
 	CompleteIssue(userID, issueID string) (issue *Issue, foreignID string, listToUpdate string, err error)
 	// AcceptIssue moves one the todo issueID of userID from inbox to myList, and returns the message and the foreignUserID if any
 	AcceptIssue(userID, issueID string) (todoMessage string, foreignUserID string, err error)
+	// AcceptIssuesFrom accepts all the todos of userID's inbox sent by senderID, and returns their messages along with
+	// the messages of the ones senderID did not mute
+	AcceptIssuesFrom(userID, senderID string) (todoMessages []string, unmutedMessages []string, err error)
 	// RemoveIssue removes the todo issueID for userID and returns the issue, the foreign ID if any and whether the user sent the todo to someone else
 	RemoveIssue(userID, issueID string) (issue *Issue, foreignID string, isSender bool, listToUpdate string, err error)
-	// PopIssue the first element of myList for userID and returns the issue and the foreign ID if any
-	PopIssue(userID string) (issue *Issue, foreignID string, err error)
+	// PopIssue removes the first element of myList for userID, or the most urgent one if mostUrgent is set, and
+	// returns the issue and the foreign ID if any
+	PopIssue(userID string, mostUrgent bool) (issue *Issue, foreignID string, err error)
 	// BumpIssue moves a issueID sent by userID to the top of its receiver inbox list
 	BumpIssue(userID string, issueID string) (todoMessage string, receiver string, foreignIssueID string, err error)
-	// EditIssue updates the message on an issue
-	EditIssue(userID string, issueID string, newMessage string, newDescription string) (foreignUserID string, list string, oldMessage string, err error)
-	// ChangeAssignment updates an issue to assign a different person
-	ChangeAssignment(issueID string, userID string, sendTo string) (issueMessage, oldOwner string, err error)
+	// BumpIssues bumps several issueIDs sent by userID like BumpIssue, and returns the bumped received issues by receiver
+	BumpIssues(userID string, issueIDs []string) (bumped map[string][]*Issue, err error)
+	// EditIssue updates the message on an issue, rejecting empty messages and texts longer than MaxIssueTextLength,
+	// and returns the changes made
+	EditIssue(userID string, issueID string, newMessage string, newDescription string) (foreignUserID string, list string, diff *IssueDiff, err error)
+	// UpdateIssue applies update to the issue issueID of userID and saves it, on both sides of a shared issue
+	UpdateIssue(userID, issueID string, update func(issue *Issue)) error
+	// ChangeAssignment updates an issue to assign a different person, returning the ID of the issue the new receiver got.
+	// The issue stays on the outgoing list of userID if track is true, and is handed over like an untracked issue otherwise.
+	ChangeAssignment(issueID string, userID string, sendTo string, track bool) (issueMessage, receiverIssueID, oldOwner string, err error)
+	// StarIssue toggles the star on the todo issueID of userID, and returns whether it is now starred
+	StarIssue(userID, issueID string) (starred bool, err error)
+	// MuteIssue toggles whether userID is notified about the changes made to the shared todo issueID by the other side,
+	// and returns whether it is now muted
+	MuteIssue(userID, issueID string) (muted bool, err error)
+	// IsIssueMuted returns whether userID muted their shared todo issueID
+	IsIssueMuted(userID, issueID string) bool
+	// IsMutedByForeignUser returns whether the other side of the shared todo issueID of userID muted it
+	IsMutedByForeignUser(userID, issueID string) bool
+	// SetSomeday moves the todo issueID of userID from myList to the someday list, or back if someday is false
+	SetSomeday(userID, issueID string, someday bool) error
+	// MoveIssue moves the todo issueID of userID to toList, putting it aside for some day, back from it, or accepting it.
+	// It returns the list it was moved from, and the foreign user and message if it was accepted.
+	MoveIssue(userID, issueID, toList string) (fromList, foreignUserID, todoMessage string, err error)
+	// RelateIssues links or unlinks the todos issueID and otherIssueID of userID as related to each other, on both of them
+	RelateIssues(userID, issueID, otherIssueID string, related bool) error
+	// SetWaitingOn marks the todo issueID of userID as waiting on waitingOnUserID, or clears it if empty
+	SetWaitingOn(userID, issueID, waitingOnUserID string) (*Issue, error)
+	// SetNagInterval makes the todo issueID of userID's myList nag them every interval milliseconds, or stops it if 0
+	SetNagInterval(userID, issueID string, interval int64) (*Issue, error)
+	// NagIssue returns the todo issueID of userID and whether a nag is due at now, recording it if so.
+	// A nil issue without error means the todo should no longer nag the user, and errIssueNotFound that it is gone.
+	// Todos put aside for someday are never due.
+	NagIssue(userID, issueID string, now int64) (issue *Issue, due bool, err error)
 	// GetUserName returns the readable username from userID
 	GetUserName(userID string) string
 }
@@ -63,8 +128,14 @@ type Plugin struct {
 
 	listManager ListManager
 
+	refreshThrottle *refreshThrottle
+	auditLog        *auditLog
+
 	telemetryClient telemetry.Client
 	tracker         telemetry.Tracker
+
+	nagJob      *cluster.Job
+	reminderJob *cluster.Job
 }
 
 func (p *Plugin) OnActivate() error {
@@ -84,16 +155,52 @@ func (p *Plugin) OnActivate() error {
 	p.BotUserID = botID
 
 	p.listManager = NewListManager(p.API)
+	p.refreshThrottle = newRefreshThrottle(MaxRefreshEventsPerSecond, time.Second, p.publishRefreshEvent)
+	p.auditLog = newAuditLog(AuditBatchWindow, p.postAuditLines)
 
 	p.telemetryClient, err = telemetry.NewRudderClient()
 	if err != nil {
 		p.API.LogWarn("telemetry client not started", "error", err.Error())
 	}
 
+	p.nagJob, err = cluster.Schedule(p.API, "nag_job", cluster.MakeWaitForInterval(NagJobInterval), p.sendNags)
+	if err != nil {
+		return errors.Wrap(err, "failed to schedule the nag job")
+	}
+
+	if err = p.backfillReminderUsers(); err != nil {
+		p.API.LogWarn("Unable to register the users for the daily reminder", "error", err.Error())
+	}
+
+	p.reminderJob, err = cluster.Schedule(p.API, "reminder_job", cluster.MakeWaitForInterval(ReminderJobInterval), p.sendDailyReminders)
+	if err != nil {
+		return errors.Wrap(err, "failed to schedule the reminder job")
+	}
+
+	if err = p.announceInstall(); err != nil {
+		p.API.LogWarn("Unable to announce the plugin", "error", err.Error())
+	}
+
 	return p.API.RegisterCommand(getCommand())
 }
 
 func (p *Plugin) OnDeactivate() error {
+	if p.auditLog != nil {
+		p.auditLog.flush()
+	}
+
+	if p.nagJob != nil {
+		if err := p.nagJob.Close(); err != nil {
+			p.API.LogWarn("OnDeactivate: failed to close the nag job", "error", err.Error())
+		}
+	}
+
+	if p.reminderJob != nil {
+		if err := p.reminderJob.Close(); err != nil {
+			p.API.LogWarn("OnDeactivate: failed to close the reminder job", "error", err.Error())
+		}
+	}
+
 	if p.telemetryClient != nil {
 		err := p.telemetryClient.Close()
 		if err != nil {
@@ -111,6 +218,8 @@ func (p *Plugin) ServeHTTP(c *plugin.Context, w http.ResponseWriter, r *http.Req
 		p.handleAdd(w, r)
 	case "/list":
 		p.handleList(w, r)
+	case "/lists":
+		p.handleLists(w, r)
 	case "/remove":
 		p.handleRemove(w, r)
 	case "/complete":
@@ -119,6 +228,10 @@ func (p *Plugin) ServeHTTP(c *plugin.Context, w http.ResponseWriter, r *http.Req
 		p.handleAccept(w, r)
 	case "/bump":
 		p.handleBump(w, r)
+	case "/bump_bulk":
+		p.handleBumpBulk(w, r)
+	case "/move_bulk":
+		p.handleMoveBulk(w, r)
 	case "/telemetry":
 		p.handleTelemetry(w, r)
 	case "/config":
@@ -127,6 +240,24 @@ func (p *Plugin) ServeHTTP(c *plugin.Context, w http.ResponseWriter, r *http.Req
 		p.handleEdit(w, r)
 	case "/change_assignment":
 		p.handleChangeAssignment(w, r)
+	case "/validate_user":
+		p.handleValidateUser(w, r)
+	case "/autocomplete/recipients":
+		p.handleAutocompleteRecipients(w, r)
+	case "/issue/share":
+		p.handleShare(w, r)
+	case "/issue/import":
+		p.handleImport(w, r)
+	case "/issue/by-ref":
+		p.handleIssueByRef(w, r)
+	case "/channel/counts":
+		p.handleChannelCounts(w, r)
+	case "/admin/report":
+		p.handleAdminReport(w, r)
+	case "/dialog/add":
+		p.handleDialogAdd(w, r)
+	case "/dialog/add/submit":
+		p.handleDialogAddSubmit(w, r)
 	default:
 		http.NotFound(w, r)
 	}
@@ -163,6 +294,15 @@ type addAPIRequest struct {
 	Description string `json:"description"`
 	SendTo      string `json:"send_to"`
 	PostID      string `json:"post_id"`
+	// Untracked sends the todo without keeping it on the sender's outgoing list
+	Untracked bool `json:"untracked,omitempty"`
+	// DueAt is the deadline of the todo in milliseconds
+	DueAt int64 `json:"due_at,omitempty"`
+	// Priority is high, normal or low, for the todo added to the user's own list or sent to SendTo. When not set, it
+	// is the default priority of the user for their own todos, and normal for the sent ones.
+	Priority string `json:"priority,omitempty"`
+	// ExternalRef is a reference of the integration adding the todo, to look it up later through /issue/by-ref
+	ExternalRef string `json:"external_ref,omitempty"`
 }
 
 func (p *Plugin) handleAdd(w http.ResponseWriter, r *http.Request) {
@@ -181,10 +321,34 @@ func (p *Plugin) handleAdd(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	addRequest.Message, addRequest.Description, err = sanitizeIssueText(addRequest.Message, addRequest.Description)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to add issue", err)
+		return
+	}
+
+	if addRequest.DueAt < 0 {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Due date cannot be negative", errors.New("negative due date"))
+		return
+	}
+
+	priority, err := parsePriority(addRequest.Priority)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid priority", err)
+		return
+	}
+
+	addRequest.ExternalRef = strings.TrimSpace(addRequest.ExternalRef)
+	if len([]rune(addRequest.ExternalRef)) > MaxExternalRefLength {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "External reference too long", errors.Errorf("the external reference cannot be longer than %d characters", MaxExternalRefLength))
+		return
+	}
+
+	fields := IssueFields{DueAt: addRequest.DueAt, Priority: priority, HasPriority: addRequest.Priority != "", ExternalRef: addRequest.ExternalRef}
 	senderName := p.listManager.GetUserName(userID)
 
 	if addRequest.SendTo == "" {
-		_, err = p.listManager.AddIssue(userID, addRequest.Message, addRequest.Description, addRequest.PostID)
+		_, err = p.listManager.AddIssue(userID, addRequest.Message, addRequest.Description, addRequest.PostID, fields)
 		if err != nil {
 			p.API.LogError("Unable to add issue err=" + err.Error())
 			p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to add issue", err)
@@ -196,7 +360,7 @@ func (p *Plugin) handleAdd(w http.ResponseWriter, r *http.Request) {
 		p.sendRefreshEvent(userID, []string{MyListKey})
 
 		replyMessage := fmt.Sprintf("@%s attached a todo to this thread", senderName)
-		p.postReplyIfNeeded(addRequest.PostID, replyMessage, addRequest.Message)
+		p.postReplyIfNeeded(addRequest.PostID, replyMessage, addRequest.Message, threadReplyCreate)
 
 		return
 	}
@@ -204,12 +368,18 @@ func (p *Plugin) handleAdd(w http.ResponseWriter, r *http.Request) {
 	receiver, appErr := p.API.GetUserByUsername(addRequest.SendTo)
 	if appErr != nil {
 		p.API.LogError("username not valid, err=" + appErr.Error())
-		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to find user", appErr)
+		p.handleErrorWithCode(w, http.StatusNotFound, "Unable to find user", errors.Wrap(errUserNotFound, appErr.Error()))
 		return
 	}
 
 	if receiver.Id == userID {
-		_, err = p.listManager.AddIssue(userID, addRequest.Message, addRequest.Description, addRequest.PostID)
+		listID := MyListKey
+		if p.getSelfSendToInboxPreference(userID) {
+			listID = InListKey
+			_, err = p.listManager.SendUntrackedIssue(userID, userID, addRequest.Message, addRequest.Description, addRequest.PostID, fields)
+		} else {
+			_, err = p.listManager.AddIssue(userID, addRequest.Message, addRequest.Description, addRequest.PostID, fields)
+		}
 		if err != nil {
 			p.API.LogError("Unable to add issue err=" + err.Error())
 			p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to add issue", err)
@@ -218,10 +388,15 @@ func (p *Plugin) handleAdd(w http.ResponseWriter, r *http.Request) {
 
 		p.trackAddIssue(userID, sourceWebapp, addRequest.PostID != "")
 
-		p.sendRefreshEvent(userID, []string{MyListKey})
+		p.sendRefreshEvent(userID, []string{listID})
 
 		replyMessage := fmt.Sprintf("@%s attached a todo to this thread", senderName)
-		p.postReplyIfNeeded(addRequest.PostID, replyMessage, addRequest.Message)
+		p.postReplyIfNeeded(addRequest.PostID, replyMessage, addRequest.Message, threadReplyCreate)
+		return
+	}
+
+	if !p.getConfiguration().EnableSendToOthers {
+		p.handleErrorWithCode(w, http.StatusForbidden, "Unable to send issue", errSendToOthersDisabled)
 		return
 	}
 
@@ -231,12 +406,22 @@ func (p *Plugin) handleAdd(w http.ResponseWriter, r *http.Request) {
 		receiverAllowIncomingTaskRequestsPreference = true
 	}
 	if !receiverAllowIncomingTaskRequestsPreference {
+		// The webapp does not show the error, the DM tells the user why nothing was sent
 		replyMessage := fmt.Sprintf("@%s has blocked Todo requests", receiver.Username)
 		p.PostBotDM(userID, replyMessage)
+		p.handleErrorWithCode(w, http.StatusForbidden, "Unable to send issue", errUserBlocked)
 		return
 	}
 
-	issueID, err := p.listManager.SendIssue(userID, receiver.Id, addRequest.Message, addRequest.Description, addRequest.PostID)
+	sendIssue := p.listManager.SendIssue
+	if addRequest.Untracked {
+		sendIssue = p.listManager.SendUntrackedIssue
+	}
+	issueID, err := sendIssue(userID, receiver.Id, addRequest.Message, addRequest.Description, addRequest.PostID, fields)
+	if err == errSharedDescriptionTooLong {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to send issue", err)
+		return
+	}
 	if err != nil {
 		p.API.LogError("Unable to send issue err=" + err.Error())
 		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to send issue", err)
@@ -244,18 +429,32 @@ func (p *Plugin) handleAdd(w http.ResponseWriter, r *http.Request) {
 	}
 
 	p.trackSendIssue(userID, sourceWebapp, addRequest.PostID != "")
+	p.rememberRecipient(userID, receiver.Id)
 
-	p.sendRefreshEvent(userID, []string{OutListKey})
+	if !addRequest.Untracked {
+		p.sendRefreshEvent(userID, []string{OutListKey})
+	}
 	p.sendRefreshEvent(receiver.Id, []string{InListKey})
 
 	receiverMessage := fmt.Sprintf("You have received a new Todo from @%s", senderName)
 	p.PostBotCustomDM(receiver.Id, receiverMessage, addRequest.Message, issueID)
 
 	replyMessage := fmt.Sprintf("@%s sent @%s a todo attached to this thread", senderName, addRequest.SendTo)
-	p.postReplyIfNeeded(addRequest.PostID, replyMessage, addRequest.Message)
+	p.postReplyIfNeeded(addRequest.PostID, replyMessage, addRequest.Message, threadReplyCreate)
 }
 
-func (p *Plugin) postReplyIfNeeded(postID, message, todo string) {
+// Lifecycle events of a todo attached to a thread that are replied on the thread
+const (
+	threadReplyCreate   = "create"
+	threadReplyComplete = "complete"
+	threadReplyRemove   = "remove"
+)
+
+func (p *Plugin) postReplyIfNeeded(postID, message, todo, event string) {
+	if event == threadReplyRemove && p.getConfiguration().ReplyOnlyOnCreateAndComplete {
+		return
+	}
+
 	if postID != "" {
 		err := p.ReplyPostBot(postID, message, todo)
 		if err != nil {
@@ -278,6 +477,12 @@ func (p *Plugin) handleList(w http.ResponseWriter, r *http.Request) {
 		listID = OutListKey
 	case InFlag:
 		listID = InListKey
+	case StarredFlag:
+		listID = StarredListKey
+	case OverdueFlag:
+		listID = OverdueListKey
+	case SomedayFlag:
+		listID = SomedayListKey
 	}
 
 	issues, err := p.listManager.GetIssueList(userID, listID)
@@ -287,34 +492,21 @@ func (p *Plugin) handleList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(issues) > 0 && r.URL.Query().Get("reminder") == "true" && p.getReminderPreference(userID) {
-		var lastReminderAt int64
-		lastReminderAt, err = p.getLastReminderTimeForUser(userID)
-		if err != nil {
+	var reminder *reminderStatus
+	if r.URL.Query().Get("reminder") == "true" {
+		if reminder, err = p.checkDailyReminder(userID, issues); err != nil {
 			p.API.LogError("Unable to send reminder err=" + err.Error())
 			p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to send reminder", err)
 			return
 		}
+	}
 
-		var timezone *time.Location
-		offset, _ := strconv.Atoi(r.Header.Get("X-Timezone-Offset"))
-		timezone = time.FixedZone("local", -60*offset)
-
-		// Post reminder message if it's the next day and been more than an hour since the last post
-		now := model.GetMillis()
-		nt := time.Unix(now/1000, 0).In(timezone)
-		lt := time.Unix(lastReminderAt/1000, 0).In(timezone)
-		if nt.Sub(lt).Hours() >= 1 && (nt.Day() != lt.Day() || nt.Month() != lt.Month() || nt.Year() != lt.Year()) {
-			p.PostBotDM(userID, "Daily Reminder:\n\n"+issuesListToString(issues))
-			p.trackDailySummary(userID)
-			err = p.saveLastReminderTimeForUser(userID)
-			if err != nil {
-				p.API.LogError("Unable to save last reminder for user err=" + err.Error())
-			}
-		}
+	var response interface{} = issues
+	if r.URL.Query().Get("meta") == "true" {
+		response = &listAPIResponse{Issues: issues, Total: len(issues), Reminder: reminder}
 	}
 
-	issuesJSON, err := json.Marshal(issues)
+	issuesJSON, err := json.Marshal(response)
 	if err != nil {
 		p.API.LogError("Unable marhsal issues list to json err=" + err.Error())
 		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable marhsal issues list to json", err)
@@ -327,10 +519,115 @@ func (p *Plugin) handleList(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// listAPIResponse is returned by /list instead of the bare issues when the meta query parameter is true
+type listAPIResponse struct {
+	Issues []*ExtendedIssue `json:"issues"`
+	Total  int              `json:"total"`
+	// Reminder is only set when the fetch checked the daily reminder
+	Reminder *reminderStatus `json:"reminder,omitempty"`
+}
+
+// reminderStatus tells whether a list fetch sent the daily reminder, and from when the next one can be sent.
+// NextReminderAt is 0 when the user turned the daily reminders off.
+type reminderStatus struct {
+	Sent           bool  `json:"sent"`
+	NextReminderAt int64 `json:"next_reminder_at"`
+}
+
+// checkDailyReminder sends the daily reminder with the given issues if the user wants it and it is due, in the
+// time zone of their profile like the reminder job.
+func (p *Plugin) checkDailyReminder(userID string, issues []*ExtendedIssue) (*reminderStatus, error) {
+	status := &reminderStatus{}
+	if !p.getReminderPreference(userID) {
+		return status, nil
+	}
+
+	lastReminderAt, err := p.getLastReminderTimeForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	snoozedUntil, err := p.getReminderSnoozedUntil(userID)
+	if err != nil {
+		p.API.LogError("Unable to get reminder snooze, err=" + err.Error())
+	}
+
+	timezone := p.getUserTimezone(userID)
+
+	now := model.GetMillis()
+	if len(issues) > 0 && shouldRemind(now, lastReminderAt, snoozedUntil, timezone) {
+		claimed, err := p.claimDailyReminder(userID, lastReminderAt, now)
+		if err != nil {
+			return nil, err
+		}
+		if claimed {
+			p.sendDailyReminder(userID, issues)
+			status.Sent = true
+			lastReminderAt = now
+		}
+	}
+	status.NextReminderAt = nextReminderAt(lastReminderAt, snoozedUntil, timezone)
+	return status, nil
+}
+
+type listsAPIResponse struct {
+	My  []*ExtendedIssue `json:"my"`
+	In  []*ExtendedIssue `json:"in"`
+	Out []*ExtendedIssue `json:"out"`
+}
+
+func (p *Plugin) handleLists(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	lists := listsAPIResponse{}
+	for _, list := range []struct {
+		listID string
+		issues *[]*ExtendedIssue
+	}{
+		{MyListKey, &lists.My},
+		{InListKey, &lists.In},
+		{OutListKey, &lists.Out},
+	} {
+		issues, err := p.listManager.GetIssueList(userID, list.listID)
+		if err != nil {
+			p.API.LogError("Unable to get issues for user err=" + err.Error())
+			p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to get issues for user", err)
+			return
+		}
+		*list.issues = issues
+	}
+
+	// The reminder only covers the user's own list, as it does for the single list endpoint
+	if r.URL.Query().Get("reminder") == "true" {
+		if _, err := p.checkDailyReminder(userID, lists.My); err != nil {
+			p.API.LogError("Unable to send reminder err=" + err.Error())
+			p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to send reminder", err)
+			return
+		}
+	}
+
+	listsJSON, err := json.Marshal(lists)
+	if err != nil {
+		p.API.LogError("Unable marhsal issues lists to json err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable marhsal issues lists to json", err)
+		return
+	}
+
+	_, err = w.Write(listsJSON)
+	if err != nil {
+		p.API.LogError("Unable to write json response err=" + err.Error())
+	}
+}
+
 type editAPIRequest struct {
 	ID          string `json:"id"`
 	Message     string `json:"message"`
 	Description string `json:"description"`
+	Points      *int   `json:"points,omitempty"`
 }
 
 func (p *Plugin) handleEdit(w http.ResponseWriter, r *http.Request) {
@@ -349,16 +646,45 @@ func (p *Plugin) handleEdit(w http.ResponseWriter, r *http.Request) {
 	}
 	r.Body.Close()
 
-	foreignUserID, list, oldMessage, err := p.listManager.EditIssue(userID, editRequest.ID, editRequest.Message, editRequest.Description)
+	var err error
+	editRequest.Message, editRequest.Description, err = sanitizeIssueText(editRequest.Message, editRequest.Description)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to edit issue", err)
+		return
+	}
+
+	if editRequest.Points != nil && *editRequest.Points < 0 {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Points cannot be negative", errors.New("negative points"))
+		return
+	}
+
+	foreignUserID, list, diff, err := p.listManager.EditIssue(userID, editRequest.ID, editRequest.Message, editRequest.Description)
+	if err == errEmptyMessage || err == errIssueTextTooLong || err == errSharedDescriptionTooLong {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to edit issue", err)
+		return
+	}
 	if err != nil {
 		p.API.LogError("Unable to edit message: err=" + err.Error())
-		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to edit issue", err)
+		p.handleErrorWithCode(w, apiErrorStatus(err), "Unable to edit issue", err)
 		return
 	}
 
+	if editRequest.Points != nil {
+		points := *editRequest.Points
+		if err = p.listManager.UpdateIssue(userID, editRequest.ID, func(issue *Issue) { issue.Points = points }); err != nil {
+			p.API.LogError("Unable to set points: err=" + err.Error())
+			p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to edit issue", err)
+			return
+		}
+	}
+
 	p.trackEditIssue(userID)
 	p.sendRefreshEvent(userID, []string{list})
 
+	if p.listManager.IsMutedByForeignUser(userID, editRequest.ID) {
+		foreignUserID = ""
+	}
+
 	if foreignUserID != "" {
 		var lists []string
 		if list == OutListKey {
@@ -369,14 +695,81 @@ func (p *Plugin) handleEdit(w http.ResponseWriter, r *http.Request) {
 		p.sendRefreshEvent(foreignUserID, lists)
 
 		userName := p.listManager.GetUserName(userID)
-		message := fmt.Sprintf("@%s modified a Todo from:\n%s\nTo:\n%s", userName, oldMessage, editRequest.Message)
+		message := fmt.Sprintf("@%s modified a Todo from:\n%s\nTo:\n%s", userName, diff.OldMessage, diff.NewMessage)
 		p.PostBotDM(foreignUserID, message)
 	}
+
+	diffJSON, err := json.Marshal(diff)
+	if err != nil {
+		p.API.LogError("Unable to marshal the issue diff to json err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to marshal the issue diff to json", err)
+		return
+	}
+
+	_, err = w.Write(diffJSON)
+	if err != nil {
+		p.API.LogError("Unable to write json response err=" + err.Error())
+	}
+}
+
+type validateUserAPIResponse struct {
+	Valid          bool   `json:"valid"`
+	AllowsIncoming bool   `json:"allows_incoming"`
+	DisplayName    string `json:"display_name,omitempty"`
+}
+
+// handleValidateUser tells whether a Todo can be sent to the user with the username query parameter.
+// The display name is left out for users that do not accept Todos, so it only tells the sender they exist.
+func (p *Plugin) handleValidateUser(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := validateUserAPIResponse{}
+	username := strings.TrimPrefix(strings.TrimSpace(r.URL.Query().Get("username")), "@")
+	if username != "" {
+		if receiver, appErr := p.API.GetUserByUsername(username); appErr == nil {
+			response.Valid = true
+			response.AllowsIncoming = receiver.Id == userID
+			if !response.AllowsIncoming && p.getConfiguration().EnableSendToOthers {
+				allowsIncoming, err := p.getAllowIncomingTaskRequestsPreference(receiver.Id)
+				if err != nil {
+					p.API.LogError("Error when getting allow incoming task request preference, err=", err)
+					allowsIncoming = true
+				}
+				response.AllowsIncoming = allowsIncoming
+			}
+			if response.AllowsIncoming {
+				response.DisplayName = receiver.GetDisplayName(model.SHOW_FULLNAME)
+			}
+		}
+	}
+
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		p.API.LogError("Unable to marshal the user validation to json err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to marshal the user validation to json", err)
+		return
+	}
+
+	_, err = w.Write(responseJSON)
+	if err != nil {
+		p.API.LogError("Unable to write json response err=" + err.Error())
+	}
 }
 
 type changeAssignmentAPIRequest struct {
 	ID     string `json:"id"`
 	SendTo string `json:"send_to"`
+	// Untracked hands the todo over without keeping it on the outgoing list
+	Untracked bool `json:"untracked,omitempty"`
 }
 
 func (p *Plugin) handleChangeAssignment(w http.ResponseWriter, r *http.Request) {
@@ -403,28 +796,39 @@ func (p *Plugin) handleChangeAssignment(w http.ResponseWriter, r *http.Request)
 	receiver, appErr := p.API.GetUserByUsername(changeRequest.SendTo)
 	if appErr != nil {
 		p.API.LogError("username not valid, err=" + appErr.Error())
-		p.handleErrorWithCode(w, http.StatusNotFound, "Unable to find user", appErr)
+		p.handleErrorWithCode(w, http.StatusNotFound, "Unable to find user", errors.Wrap(errUserNotFound, appErr.Error()))
+		return
+	}
+
+	if receiver.Id != userID && !p.getConfiguration().EnableSendToOthers {
+		p.handleErrorWithCode(w, http.StatusForbidden, "Unable to change the assignment", errSendToOthersDisabled)
 		return
 	}
 
-	issueMessage, oldOwner, err := p.listManager.ChangeAssignment(changeRequest.ID, userID, receiver.Id)
+	issueMessage, receiverIssueID, oldOwner, err := p.listManager.ChangeAssignment(changeRequest.ID, userID, receiver.Id, !changeRequest.Untracked)
 	if err != nil {
 		p.API.LogError("Unable to change the assignment of an issue: err=" + err.Error())
-		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to change the assignment", err)
+		p.handleErrorWithCode(w, apiErrorStatus(err), "Unable to change the assignment", err)
 		return
 	}
 
 	p.trackChangeAssignment(userID)
 
+	p.notifyAssignmentChanged(userID, receiver.Id, receiverIssueID, oldOwner, issueMessage)
+}
+
+// notifyAssignmentChanged refreshes the lists of everyone involved in a change of assignment and lets
+// the new receiver and the previous one know about it.
+func (p *Plugin) notifyAssignmentChanged(userID, receiverID, receiverIssueID, oldOwner, issueMessage string) {
 	p.sendRefreshEvent(userID, []string{MyListKey, OutListKey})
 
 	userName := p.listManager.GetUserName(userID)
-	if receiver.Id != userID {
-		p.sendRefreshEvent(receiver.Id, []string{InListKey})
+	if receiverID != userID {
+		p.sendRefreshEvent(receiverID, []string{InListKey})
 		receiverMessage := fmt.Sprintf("You have received a new Todo from @%s", userName)
-		p.PostBotCustomDM(receiver.Id, receiverMessage, issueMessage, changeRequest.ID)
+		p.PostBotCustomDM(receiverID, receiverMessage, issueMessage, receiverIssueID)
 	}
-	if oldOwner != "" {
+	if oldOwner != "" && oldOwner != receiverID {
 		p.sendRefreshEvent(oldOwner, []string{InListKey, MyListKey})
 		oldOwnerMessage := fmt.Sprintf("@%s removed you from Todo:\n%s", userName, issueMessage)
 		p.PostBotDM(oldOwner, oldOwnerMessage)
@@ -450,18 +854,29 @@ func (p *Plugin) handleAccept(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	foreignMuted := p.listManager.IsMutedByForeignUser(userID, acceptRequest.ID)
 	todoMessage, sender, err := p.listManager.AcceptIssue(userID, acceptRequest.ID)
 	if err != nil {
 		p.API.LogError("Unable to accept issue err=" + err.Error())
-		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to accept issue", err)
+		p.handleErrorWithCode(w, apiErrorStatus(err), "Unable to accept issue", err)
 		return
 	}
 
 	p.trackAcceptIssue(userID)
 
 	p.sendRefreshEvent(userID, []string{MyListKey, InListKey})
+
+	// Todos sent without tracking them are not linked to their sender
+	if sender == "" {
+		return
+	}
+
 	p.sendRefreshEvent(sender, []string{OutListKey})
 
+	if foreignMuted {
+		return
+	}
+
 	userName := p.listManager.GetUserName(userID)
 	message := fmt.Sprintf("@%s accepted a Todo you sent: %s", userName, todoMessage)
 	p.PostBotDM(sender, message)
@@ -469,6 +884,8 @@ func (p *Plugin) handleAccept(w http.ResponseWriter, r *http.Request) {
 
 type completeAPIRequest struct {
 	ID string `json:"id"`
+	// Note is posted along with the completion on the thread the todo is attached to
+	Note string `json:"note,omitempty"`
 }
 
 func (p *Plugin) handleComplete(w http.ResponseWriter, r *http.Request) {
@@ -486,26 +903,41 @@ func (p *Plugin) handleComplete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	foreignMuted := p.listManager.IsMutedByForeignUser(userID, completeRequest.ID)
 	issue, foreignID, listToUpdate, err := p.listManager.CompleteIssue(userID, completeRequest.ID)
 	if err != nil {
 		p.API.LogError("Unable to complete issue err=" + err.Error())
-		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to complete issue", err)
+		p.handleErrorWithCode(w, apiErrorStatus(err), "Unable to complete issue", err)
 		return
 	}
 
+	p.notifyIssueCompleted(userID, issue, foreignID, listToUpdate, foreignMuted, strings.TrimSpace(completeRequest.Note))
+}
+
+// notifyIssueCompleted refreshes the lists, logs the completion and notifies the people involved after userID completed issue.
+// The foreign user is not sent a DM if they muted the issue. A closing note, if any, is added to the thread reply.
+func (p *Plugin) notifyIssueCompleted(userID string, issue *Issue, foreignID, listToUpdate string, foreignMuted bool, note string) {
 	p.sendRefreshEvent(userID, []string{listToUpdate})
 
 	p.trackCompleteIssue(userID)
 
 	userName := p.listManager.GetUserName(userID)
+	p.logCompletion(userName, issue, time.Now())
+
 	replyMessage := fmt.Sprintf("@%s completed a todo attached to this thread", userName)
-	p.postReplyIfNeeded(issue.PostID, replyMessage, issue.Message)
+	if note != "" {
+		replyMessage += " with a note: " + note
+	}
+	p.postReplyIfNeeded(issue.PostID, replyMessage, issue.Message, threadReplyComplete)
 
 	if foreignID == "" {
 		return
 	}
 
 	p.sendRefreshEvent(foreignID, []string{OutListKey})
+	if foreignMuted {
+		return
+	}
 
 	message := fmt.Sprintf("@%s completed a Todo you sent: %s", userName, issue.Message)
 	p.PostBotDM(foreignID, message)
@@ -531,10 +963,11 @@ func (p *Plugin) handleRemove(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	foreignMuted := p.listManager.IsMutedByForeignUser(userID, removeRequest.ID)
 	issue, foreignID, isSender, listToUpdate, err := p.listManager.RemoveIssue(userID, removeRequest.ID)
 	if err != nil {
 		p.API.LogError("Unable to remove issue, err=" + err.Error())
-		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to remove issue", err)
+		p.handleErrorWithCode(w, apiErrorStatus(err), "Unable to remove issue", err)
 		return
 	}
 	p.sendRefreshEvent(userID, []string{listToUpdate})
@@ -543,21 +976,25 @@ func (p *Plugin) handleRemove(w http.ResponseWriter, r *http.Request) {
 
 	userName := p.listManager.GetUserName(userID)
 	replyMessage := fmt.Sprintf("@%s removed a todo attached to this thread", userName)
-	p.postReplyIfNeeded(issue.PostID, replyMessage, issue.Message)
+	p.postReplyIfNeeded(issue.PostID, replyMessage, issue.Message, threadReplyRemove)
 
 	if foreignID == "" {
 		return
 	}
 
-	list := InListKey
+	// The receiver may have already accepted the todo, so both of its lists may hold it
+	lists := []string{MyListKey, InListKey}
 
 	message := fmt.Sprintf("@%s removed a Todo you received: %s", userName, issue.Message)
 	if isSender {
 		message = fmt.Sprintf("@%s declined a Todo you sent: %s", userName, issue.Message)
-		list = OutListKey
+		lists = []string{OutListKey}
 	}
 
-	p.sendRefreshEvent(foreignID, []string{list})
+	p.sendRefreshEvent(foreignID, lists)
+	if foreignMuted {
+		return
+	}
 
 	p.PostBotDM(foreignID, message)
 }
@@ -583,9 +1020,13 @@ func (p *Plugin) handleBump(w http.ResponseWriter, r *http.Request) {
 	}
 
 	todoMessage, foreignUser, foreignIssueID, err := p.listManager.BumpIssue(userID, bumpRequest.ID)
+	if err == errAlreadyCompleted {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to bump issue", err)
+		return
+	}
 	if err != nil {
 		p.API.LogError("Unable to bump issue, err=" + err.Error())
-		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to bump issue", err)
+		p.handleErrorWithCode(w, apiErrorStatus(err), "Unable to bump issue", err)
 		return
 	}
 
@@ -596,12 +1037,350 @@ func (p *Plugin) handleBump(w http.ResponseWriter, r *http.Request) {
 	}
 
 	p.sendRefreshEvent(foreignUser, []string{InListKey})
+	if p.listManager.IsMutedByForeignUser(userID, bumpRequest.ID) {
+		return
+	}
 
 	userName := p.listManager.GetUserName(userID)
 	message := fmt.Sprintf("@%s bumped a Todo you received.", userName)
 	p.PostBotCustomDM(foreignUser, message, todoMessage, foreignIssueID)
 }
 
+// MaxBulkBump is the maximum number of issues that can be bumped at once
+const MaxBulkBump = 50
+
+type bumpBulkAPIRequest struct {
+	IDs []string `json:"ids"`
+}
+
+func (p *Plugin) handleBumpBulk(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	var bumpRequest *bumpBulkAPIRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&bumpRequest)
+	if err != nil {
+		p.API.LogError("Unable to decode JSON err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to decode JSON", err)
+		return
+	}
+
+	if len(bumpRequest.IDs) == 0 || len(bumpRequest.IDs) > MaxBulkBump {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to bump issues", fmt.Errorf("between 1 and %d issues can be bumped at once", MaxBulkBump))
+		return
+	}
+
+	bumped, err := p.listManager.BumpIssues(userID, bumpRequest.IDs)
+	if errors.Cause(err) == errAlreadyCompleted {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to bump issues", err)
+		return
+	}
+	if err != nil {
+		p.API.LogError("Unable to bump issues, err=" + err.Error())
+		p.handleErrorWithCode(w, apiErrorStatus(err), "Unable to bump issues", err)
+		return
+	}
+
+	userName := p.listManager.GetUserName(userID)
+	for receiver, issues := range bumped {
+		for range issues {
+			p.trackBumpIssue(userID)
+		}
+
+		p.sendRefreshEvent(receiver, []string{InListKey})
+
+		unmuted := []*Issue{}
+		for _, issue := range issues {
+			if !p.listManager.IsIssueMuted(receiver, issue.ID) {
+				unmuted = append(unmuted, issue)
+			}
+		}
+		issues = unmuted
+		if len(issues) == 0 {
+			continue
+		}
+
+		if len(issues) == 1 {
+			message := fmt.Sprintf("@%s bumped a Todo you received.", userName)
+			p.PostBotCustomDM(receiver, message, issues[0].Message, issues[0].ID)
+			continue
+		}
+
+		message := fmt.Sprintf("@%s bumped %d Todos you received:\n", userName, len(issues))
+		for _, issue := range issues {
+			message += "\n* " + issue.Message
+		}
+		p.PostBotDM(receiver, message)
+	}
+}
+
+// MaxBulkMove is the maximum number of issues that can be moved at once
+const MaxBulkMove = 50
+
+// acceptedTodosMessage tells the sender of todoMessages that userName accepted them.
+func acceptedTodosMessage(userName string, todoMessages []string) string {
+	if len(todoMessages) == 1 {
+		return fmt.Sprintf("@%s accepted a Todo you sent: %s", userName, todoMessages[0])
+	}
+
+	message := fmt.Sprintf("@%s accepted %d Todos you sent:\n", userName, len(todoMessages))
+	for _, todoMessage := range todoMessages {
+		message += "\n* " + todoMessage
+	}
+	return message
+}
+
+type moveBulkAPIRequest struct {
+	IDs []string `json:"ids"`
+	// List is where to move the issues, my or someday
+	List string `json:"list"`
+}
+
+// moveBulkResult tells whether one of the issues of a bulk move was moved, or why not
+type moveBulkResult struct {
+	ID    string `json:"id"`
+	Moved bool   `json:"moved"`
+	Error string `json:"error,omitempty"`
+}
+
+func (p *Plugin) handleMoveBulk(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	var moveRequest *moveBulkAPIRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&moveRequest)
+	if err != nil {
+		p.API.LogError("Unable to decode JSON err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to decode JSON", err)
+		return
+	}
+
+	if len(moveRequest.IDs) == 0 || len(moveRequest.IDs) > MaxBulkMove {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to move issues", fmt.Errorf("between 1 and %d issues can be moved at once", MaxBulkMove))
+		return
+	}
+
+	var toList string
+	switch moveRequest.List {
+	case MyFlag:
+		toList = MyListKey
+	case SomedayFlag:
+		toList = SomedayListKey
+	default:
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to move issues", fmt.Errorf("issues can only be moved to the %s or %s list", MyFlag, SomedayFlag))
+		return
+	}
+
+	results := []*moveBulkResult{}
+	refreshed := []string{}
+	accepted := map[string][]string{}
+	for _, issueID := range moveRequest.IDs {
+		foreignMuted := p.listManager.IsMutedByForeignUser(userID, issueID)
+		fromList, sender, todoMessage, moveErr := p.listManager.MoveIssue(userID, issueID, toList)
+		if moveErr != nil {
+			results = append(results, &moveBulkResult{ID: issueID, Error: moveErr.Error()})
+			continue
+		}
+
+		results = append(results, &moveBulkResult{ID: issueID, Moved: true})
+		refreshed = mergeLists(refreshed, []string{fromList, toList})
+		if fromList == InListKey {
+			p.trackAcceptIssue(userID)
+			// Todos sent without tracking them are not linked to their sender
+			if sender != "" {
+				if _, ok := accepted[sender]; !ok {
+					accepted[sender] = []string{}
+				}
+				// The sender is not told about the Todos they muted
+				if !foreignMuted {
+					accepted[sender] = append(accepted[sender], todoMessage)
+				}
+			}
+		}
+	}
+
+	if len(refreshed) > 0 {
+		p.sendRefreshEvent(userID, refreshed)
+	}
+
+	userName := p.listManager.GetUserName(userID)
+	for sender, todoMessages := range accepted {
+		p.sendRefreshEvent(sender, []string{OutListKey})
+
+		if len(todoMessages) > 0 {
+			p.PostBotDM(sender, acceptedTodosMessage(userName, todoMessages))
+		}
+	}
+
+	resultsJSON, err := json.Marshal(results)
+	if err != nil {
+		p.API.LogError("Unable marhsal move results to json err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable marhsal move results to json", err)
+		return
+	}
+
+	_, err = w.Write(resultsJSON)
+	if err != nil {
+		p.API.LogError("Unable to write json response err=" + err.Error())
+	}
+}
+
+// API endpoint to get a signed payload of an issue that can be shared with someone else
+func (p *Plugin) handleShare(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	issue, err := p.listManager.GetIssue(userID, r.URL.Query().Get("id"))
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusNotFound, "Unable to find the issue", err)
+		return
+	}
+
+	secret, err := p.getShareSecret()
+	if err != nil {
+		p.API.LogError("Unable to get the share secret err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to share the issue", err)
+		return
+	}
+
+	shared := &sharedIssue{
+		Message:     issue.Message,
+		Description: issue.Description,
+		SharedBy:    p.listManager.GetUserName(userID),
+		SharedAt:    model.GetMillis(),
+	}
+	if err = shared.sign(secret); err != nil {
+		p.API.LogError("Unable to sign the shared issue err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to share the issue", err)
+		return
+	}
+
+	sharedJSON, err := json.Marshal(shared)
+	if err != nil {
+		p.API.LogError("Unable marhsal shared issue to json err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable marhsal shared issue to json", err)
+		return
+	}
+
+	_, err = w.Write(sharedJSON)
+	if err != nil {
+		p.API.LogError("Unable to write json response err=" + err.Error())
+	}
+}
+
+// API endpoint to add an issue shared through /issue/share to the user's own list
+func (p *Plugin) handleImport(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var shared *sharedIssue
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&shared)
+	if err != nil {
+		p.API.LogError("Unable to decode JSON err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to decode JSON", err)
+		return
+	}
+
+	secret, err := p.getShareSecret()
+	if err != nil {
+		p.API.LogError("Unable to get the share secret err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to import the issue", err)
+		return
+	}
+
+	if !shared.verify(secret) {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to import the issue", errors.New("the shared issue was changed after it was shared"))
+		return
+	}
+
+	message, description, err := sanitizeIssueText(shared.Message, shared.Description)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to import the issue", err)
+		return
+	}
+
+	_, err = p.listManager.AddIssue(userID, message, description, "", IssueFields{})
+	if err != nil {
+		p.API.LogError("Unable to add issue err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to import the issue", err)
+		return
+	}
+
+	p.trackAddIssue(userID, sourceWebapp, false)
+
+	p.sendRefreshEvent(userID, []string{MyListKey})
+}
+
+// API endpoint to find the issue of the user an integration attached a reference to when adding it
+func (p *Plugin) handleIssueByRef(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ref := r.URL.Query().Get("ref")
+	if ref == "" {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Missing the external reference", errors.New("missing ref"))
+		return
+	}
+
+	groups, err := p.findIssues(userID, func(issue *ExtendedIssue) bool {
+		return issue.ExternalRef == ref
+	})
+	if err != nil {
+		p.API.LogError("Unable to get issues for user err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to find the issue", err)
+		return
+	}
+	if len(groups) == 0 {
+		p.handleErrorWithCode(w, http.StatusNotFound, "Unable to find the issue", errIssueNotFound)
+		return
+	}
+
+	issueJSON, err := json.Marshal(groups[0].Issues[0])
+	if err != nil {
+		p.API.LogError("Unable marhsal issue to json err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable marhsal issue to json", err)
+		return
+	}
+
+	_, err = w.Write(issueJSON)
+	if err != nil {
+		p.API.LogError("Unable to write json response err=" + err.Error())
+	}
+}
+
 // API endpoint to retrieve plugin configurations
 func (p *Plugin) handleConfig(w http.ResponseWriter, r *http.Request) {
 	userID := r.Header.Get("Mattermost-User-ID")
@@ -618,9 +1397,11 @@ func (p *Plugin) handleConfig(w http.ResponseWriter, r *http.Request) {
 	if p.configuration != nil {
 		// retrieve client only configurations
 		clientConfig := struct {
-			HideTeamSidebar bool `json:"hide_team_sidebar"`
+			HideTeamSidebar    bool `json:"hide_team_sidebar"`
+			EnableSendToOthers bool `json:"enable_send_to_others"`
 		}{
-			HideTeamSidebar: p.configuration.HideTeamSidebar,
+			HideTeamSidebar:    p.configuration.HideTeamSidebar,
+			EnableSendToOthers: p.configuration.EnableSendToOthers,
 		}
 
 		configJSON, err := json.Marshal(clientConfig)
@@ -637,18 +1418,68 @@ func (p *Plugin) handleConfig(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (p *Plugin) sendRefreshEvent(userID string, lists []string) {
-	p.API.PublishWebSocketEvent(
-		WSEventRefresh,
-		map[string]interface{}{"lists": lists},
-		&model.WebsocketBroadcast{UserId: userID},
-	)
+const (
+	// DefaultReminderHeader is the text the daily reminder starts with, unless the user chose their own
+	DefaultReminderHeader = "Daily Reminder:"
+	// MaxReminderHeaderLength is the length of the longest text users can start their daily reminder with
+	MaxReminderHeaderLength = 100
+)
+
+// shouldRemind decides whether the daily reminder is due at now. The reminder is posted if it's the next day
+// and been more than an hour since the last post, unless the reminders are snoozed until a later time.
+func shouldRemind(now, lastReminderAt, snoozedUntil int64, timezone *time.Location) bool {
+	if now < snoozedUntil {
+		return false
+	}
+
+	nt := time.Unix(now/1000, 0).In(timezone)
+	lt := time.Unix(lastReminderAt/1000, 0).In(timezone)
+	return nt.Sub(lt).Hours() >= 1 && (nt.Day() != lt.Day() || nt.Month() != lt.Month() || nt.Year() != lt.Year())
+}
+
+// nextReminderAt returns from when shouldRemind lets the daily reminder be sent again: the start of the day after
+// the last reminder, at least an hour after it, and not before the end of a snooze.
+func nextReminderAt(lastReminderAt, snoozedUntil int64, timezone *time.Location) int64 {
+	next := int64(0)
+	if lastReminderAt > 0 {
+		lt := time.Unix(lastReminderAt/1000, 0).In(timezone)
+		next = time.Date(lt.Year(), lt.Month(), lt.Day()+1, 0, 0, 0, 0, timezone).Unix() * 1000
+		if anHourLater := lastReminderAt + int64(time.Hour/time.Millisecond); anHourLater > next {
+			next = anHourLater
+		}
+	}
+	if snoozedUntil > next {
+		next = snoozedUntil
+	}
+	return next
+}
+
+// sendDailyReminder posts the daily reminder with issues to userID, and remembers the order of the issues
+// so the user can act on them by replying to the reminder. Callers claim the reminder of the day first.
+func (p *Plugin) sendDailyReminder(userID string, issues []*ExtendedIssue) {
+	includeDescriptions := p.getReminderDescriptionsPreference(userID)
+	header := p.getReminderHeaderPreference(userID) + "\n\n"
+	// Stay within the post length of servers with older databases, whatever the server
+	maxLength := model.POST_MESSAGE_MAX_RUNES_V1 - len(header) - len(reminderReplyHelp)
+	// The reminder stays on the DM, where its Todos can be completed by replying to it
+	p.postBotDirectMessage(userID, header+reminderToString(issues, includeDescriptions, p.getConfiguration().getMaxReminderItems(), maxLength)+reminderReplyHelp)
+	p.trackDailySummary(userID)
+
+	issueIDs := make([]string, len(issues))
+	for i, issue := range issues {
+		issueIDs[i] = issue.ID
+	}
+	err := p.saveReminderIssues(userID, issueIDs)
+	if err != nil {
+		p.API.LogError("Unable to save last reminder issues for user err=" + err.Error())
+	}
 }
 
 // Publish a WebSocket event to update the client config of the plugin on the webapp end.
 func (p *Plugin) sendConfigUpdateEvent() {
 	clientConfigMap := map[string]interface{}{
-		"hide_team_sidebar": p.configuration.HideTeamSidebar,
+		"hide_team_sidebar":     p.configuration.HideTeamSidebar,
+		"enable_send_to_others": p.configuration.EnableSendToOthers,
 	}
 
 	p.API.PublishWebSocketEvent(
@@ -658,14 +1489,49 @@ func (p *Plugin) sendConfigUpdateEvent() {
 	)
 }
 
+// apiErrorStatus returns the HTTP status of an error returned by the list manager.
+func apiErrorStatus(err error) int {
+	if errors.Cause(err) == errIssueNotFound {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// apiErrorCode returns the machine readable code of err, falling back to one matching the HTTP status.
+func apiErrorCode(status int, err error) string {
+	switch errors.Cause(err) {
+	case errIssueNotFound:
+		return errorCodeIssueNotFound
+	case errUserNotFound:
+		return errorCodeUserNotFound
+	case errUserBlocked:
+		return errorCodeUserBlocked
+	case errSendToOthersDisabled:
+		return errorCodeSendToOthersDisabled
+	}
+
+	switch status {
+	case http.StatusBadRequest:
+		return errorCodeInvalidInput
+	case http.StatusForbidden:
+		return errorCodeForbidden
+	case http.StatusNotFound:
+		return errorCodeNotFound
+	default:
+		return errorCodeInternal
+	}
+}
+
 func (p *Plugin) handleErrorWithCode(w http.ResponseWriter, code int, errTitle string, err error) {
 	w.WriteHeader(code)
 	b, _ := json.Marshal(struct {
 		Error   string `json:"error"`
 		Details string `json:"details"`
+		Code    string `json:"code"`
 	}{
 		Error:   errTitle,
 		Details: err.Error(),
+		Code:    apiErrorCode(code, err),
 	})
 	_, _ = w.Write(b)
 }
@@ -1,11 +1,1452 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/mattermost/mattermost-plugin-api/experimental/telemetry"
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestServeHTTP(t *testing.T) {
 	assert.True(t, true)
 }
+
+const testBotID = "bot_id"
+
+// testEnv is an in-memory fake of the server the plugin talks to. It backs the KV store
+// with a map and records the posts and WebSocket events published by the plugin.
+type testEnv struct {
+	api *plugintest.API
+
+	mutex      sync.Mutex
+	kv         map[string][]byte
+	users      map[string]*model.User
+	teams      map[string][]*model.Team
+	posts      []*model.Post
+	ephemerals []*model.Post
+	events     []testRefreshEvent
+}
+
+type testRefreshEvent struct {
+	userID string
+	lists  []string
+}
+
+func newTestEnv() *testEnv {
+	env := &testEnv{
+		api:   &plugintest.API{},
+		kv:    map[string][]byte{},
+		users: map[string]*model.User{},
+		teams: map[string][]*model.Team{},
+	}
+	api := env.api
+
+	api.On("KVGet", mock.AnythingOfType("string")).Return(
+		func(key string) []byte {
+			env.mutex.Lock()
+			defer env.mutex.Unlock()
+			return env.kv[key]
+		},
+		func(key string) *model.AppError { return nil },
+	)
+	api.On("KVSet", mock.AnythingOfType("string"), mock.Anything).Return(
+		func(key string, value []byte) *model.AppError {
+			env.mutex.Lock()
+			defer env.mutex.Unlock()
+			env.kv[key] = value
+			return nil
+		},
+	)
+	api.On("KVCompareAndSet", mock.AnythingOfType("string"), mock.Anything, mock.Anything).Return(
+		func(key string, oldValue, newValue []byte) bool {
+			env.mutex.Lock()
+			defer env.mutex.Unlock()
+			if !bytes.Equal(env.kv[key], oldValue) {
+				return false
+			}
+			env.kv[key] = newValue
+			return true
+		},
+		func(key string, oldValue, newValue []byte) *model.AppError { return nil },
+	)
+	api.On("KVCompareAndDelete", mock.AnythingOfType("string"), mock.Anything).Return(
+		func(key string, oldValue []byte) bool {
+			env.mutex.Lock()
+			defer env.mutex.Unlock()
+			if !bytes.Equal(env.kv[key], oldValue) {
+				return false
+			}
+			delete(env.kv, key)
+			return true
+		},
+		func(key string, oldValue []byte) *model.AppError { return nil },
+	)
+	api.On("KVList", mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(
+		func(page, perPage int) []string {
+			env.mutex.Lock()
+			defer env.mutex.Unlock()
+			keys := []string{}
+			for key := range env.kv {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			if page*perPage >= len(keys) {
+				return []string{}
+			}
+			keys = keys[page*perPage:]
+			if len(keys) > perPage {
+				keys = keys[:perPage]
+			}
+			return keys
+		},
+		func(page, perPage int) *model.AppError { return nil },
+	)
+	api.On("KVDelete", mock.AnythingOfType("string")).Return(
+		func(key string) *model.AppError {
+			env.mutex.Lock()
+			defer env.mutex.Unlock()
+			delete(env.kv, key)
+			return nil
+		},
+	)
+
+	api.On("GetUser", mock.AnythingOfType("string")).Return(
+		func(userID string) *model.User {
+			return env.users[userID]
+		},
+		func(userID string) *model.AppError {
+			if env.users[userID] == nil {
+				return model.NewAppError("GetUser", "not_found", nil, "", 404)
+			}
+			return nil
+		},
+	)
+	api.On("GetUserByUsername", mock.AnythingOfType("string")).Return(
+		func(username string) *model.User {
+			return env.userByUsername(username)
+		},
+		func(username string) *model.AppError {
+			if env.userByUsername(username) == nil {
+				return model.NewAppError("GetUserByUsername", "not_found", nil, "", 404)
+			}
+			return nil
+		},
+	)
+
+	api.On("GetTeamsForUser", mock.AnythingOfType("string")).Return(
+		func(userID string) []*model.Team {
+			env.mutex.Lock()
+			defer env.mutex.Unlock()
+			return env.teams[userID]
+		},
+		func(userID string) *model.AppError { return nil },
+	)
+	api.On("GetDirectChannel", mock.AnythingOfType("string"), testBotID).Return(
+		func(userID, botID string) *model.Channel {
+			return &model.Channel{Id: "dm_" + userID, Type: model.CHANNEL_DIRECT}
+		},
+		func(userID, botID string) *model.AppError { return nil },
+	)
+	api.On("CreatePost", mock.Anything).Return(
+		func(post *model.Post) *model.Post {
+			env.mutex.Lock()
+			defer env.mutex.Unlock()
+			env.posts = append(env.posts, post)
+			return post
+		},
+		func(post *model.Post) *model.AppError { return nil },
+	)
+	api.On("SendEphemeralPost", mock.AnythingOfType("string"), mock.Anything).Return(
+		func(userID string, post *model.Post) *model.Post {
+			env.mutex.Lock()
+			defer env.mutex.Unlock()
+			env.ephemerals = append(env.ephemerals, post)
+			return post
+		},
+	)
+	api.On("PublishWebSocketEvent", WSEventRefresh, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		env.mutex.Lock()
+		defer env.mutex.Unlock()
+		payload := args.Get(1).(map[string]interface{})
+		broadcast := args.Get(2).(*model.WebsocketBroadcast)
+		env.events = append(env.events, testRefreshEvent{
+			userID: broadcast.UserId,
+			lists:  payload["lists"].([]string),
+		})
+	}).Return()
+
+	for _, method := range []string{"LogError", "LogWarn", "LogInfo", "LogDebug"} {
+		for arity := 1; arity <= 7; arity++ {
+			args := make([]interface{}, arity)
+			for i := range args {
+				args[i] = mock.Anything
+			}
+			api.On(method, args...).Maybe().Return()
+		}
+	}
+
+	return env
+}
+
+func (env *testEnv) addUser(id, username string) *model.User {
+	user := &model.User{Id: id, Username: username}
+	env.users[id] = user
+	return user
+}
+
+func (env *testEnv) addTeamMember(teamID, userID string) {
+	env.mutex.Lock()
+	defer env.mutex.Unlock()
+	env.teams[userID] = append(env.teams[userID], &model.Team{Id: teamID})
+}
+
+func (env *testEnv) userByUsername(username string) *model.User {
+	for _, user := range env.users {
+		if user.Username == username {
+			return user
+		}
+	}
+	return nil
+}
+
+// newPlugin returns a plugin wired to the fake server, ready to serve commands and requests.
+func (env *testEnv) newPlugin() *Plugin {
+	p := &Plugin{
+		BotUserID: testBotID,
+	}
+	p.SetAPI(env.api)
+	p.setConfiguration(&configuration{
+		EnableSendToOthers: true,
+	})
+	p.listManager = NewListManager(env.api)
+	// Tests record every refresh right away, the throttle is covered on its own
+	p.refreshThrottle = newRefreshThrottle(math.MaxInt32, time.Second, p.publishRefreshEvent)
+	// Tests flush the audit log when they check it
+	p.auditLog = newAuditLog(time.Hour, p.postAuditLines)
+	p.tracker = telemetry.NewTracker(nil, "", "", "", "", "", false, nil)
+	return p
+}
+
+// postsTo returns the posts created in the channel with channelID.
+func (env *testEnv) postsTo(channelID string) []*model.Post {
+	env.mutex.Lock()
+	defer env.mutex.Unlock()
+
+	posts := []*model.Post{}
+	for _, post := range env.posts {
+		if post.ChannelId == channelID {
+			posts = append(posts, post)
+		}
+	}
+	return posts
+}
+
+// lastEphemeral returns the message of the last ephemeral post, or an empty string if none.
+func (env *testEnv) lastEphemeral() string {
+	env.mutex.Lock()
+	defer env.mutex.Unlock()
+
+	if len(env.ephemerals) == 0 {
+		return ""
+	}
+	return env.ephemerals[len(env.ephemerals)-1].Message
+}
+
+// resetRecords clears the recorded posts and events, keeping the stored data.
+func (env *testEnv) resetRecords() {
+	env.mutex.Lock()
+	defer env.mutex.Unlock()
+
+	env.posts = nil
+	env.ephemerals = nil
+	env.events = nil
+}
+
+// refreshes returns the recorded refresh events.
+func (env *testEnv) refreshes() []testRefreshEvent {
+	env.mutex.Lock()
+	defer env.mutex.Unlock()
+
+	return append([]testRefreshEvent{}, env.events...)
+}
+
+// serve sends a request with the JSON encoded body to the plugin on behalf of userID.
+func (env *testEnv) serve(p *Plugin, userID, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var reader *bytes.Reader
+	if body == nil {
+		reader = bytes.NewReader(nil)
+	} else {
+		b, err := json.Marshal(body)
+		if err != nil {
+			panic(err)
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	r := httptest.NewRequest(method, path, reader)
+	r.Header.Set("Mattermost-User-ID", userID)
+	w := httptest.NewRecorder()
+	p.ServeHTTP(nil, w, r)
+	return w
+}
+
+func TestMutatorsRefreshEvents(t *testing.T) {
+	type fixture struct {
+		p     *Plugin
+		env   *testEnv
+		mine  string
+		sent  string
+		inbox string
+	}
+
+	setup := func(t *testing.T) *fixture {
+		env := newTestEnv()
+		env.addUser("alice", "alice")
+		env.addUser("bob", "bob")
+		env.addUser("carol", "carol")
+		p := env.newPlugin()
+
+		mine, err := p.listManager.AddIssue("alice", "mine", "", "", IssueFields{})
+		require.NoError(t, err)
+		inbox, err := p.listManager.SendIssue("alice", "bob", "shared", "", "", IssueFields{})
+		require.NoError(t, err)
+		sent, err := p.listManager.GetIssueList("alice", OutListKey)
+		require.NoError(t, err)
+		require.Len(t, sent, 1)
+
+		env.resetRecords()
+		return &fixture{p: p, env: env, mine: mine.ID, sent: sent[0].ID, inbox: inbox}
+	}
+
+	acceptInbox := func(t *testing.T, f *fixture) {
+		_, _, err := f.p.listManager.AcceptIssue("bob", f.inbox)
+		require.NoError(t, err)
+		f.env.resetRecords()
+	}
+
+	tests := []struct {
+		name   string
+		before func(t *testing.T, f *fixture)
+		run    func(f *fixture) *httptest.ResponseRecorder
+		want   []testRefreshEvent
+	}{
+		{
+			name: "add",
+			run: func(f *fixture) *httptest.ResponseRecorder {
+				return f.env.serve(f.p, "alice", http.MethodPost, "/add", addAPIRequest{Message: "new"})
+			},
+			want: []testRefreshEvent{{"alice", []string{MyListKey}}},
+		},
+		{
+			name: "add sending to someone",
+			run: func(f *fixture) *httptest.ResponseRecorder {
+				return f.env.serve(f.p, "alice", http.MethodPost, "/add", addAPIRequest{Message: "new", SendTo: "carol"})
+			},
+			want: []testRefreshEvent{{"alice", []string{OutListKey}}, {"carol", []string{InListKey}}},
+		},
+		{
+			name: "edit own issue",
+			run: func(f *fixture) *httptest.ResponseRecorder {
+				return f.env.serve(f.p, "alice", http.MethodPost, "/edit", editAPIRequest{ID: f.mine, Message: "edited"})
+			},
+			want: []testRefreshEvent{{"alice", []string{MyListKey}}},
+		},
+		{
+			name: "edit sent issue",
+			run: func(f *fixture) *httptest.ResponseRecorder {
+				return f.env.serve(f.p, "alice", http.MethodPost, "/edit", editAPIRequest{ID: f.sent, Message: "edited"})
+			},
+			want: []testRefreshEvent{{"alice", []string{OutListKey}}, {"bob", []string{MyListKey, InListKey}}},
+		},
+		{
+			name: "edit received issue",
+			run: func(f *fixture) *httptest.ResponseRecorder {
+				return f.env.serve(f.p, "bob", http.MethodPost, "/edit", editAPIRequest{ID: f.inbox, Message: "edited"})
+			},
+			want: []testRefreshEvent{{"bob", []string{InListKey}}, {"alice", []string{OutListKey}}},
+		},
+		{
+			name: "accept",
+			run: func(f *fixture) *httptest.ResponseRecorder {
+				return f.env.serve(f.p, "bob", http.MethodPost, "/accept", acceptAPIRequest{ID: f.inbox})
+			},
+			want: []testRefreshEvent{{"bob", []string{MyListKey, InListKey}}, {"alice", []string{OutListKey}}},
+		},
+		{
+			name: "complete own issue",
+			run: func(f *fixture) *httptest.ResponseRecorder {
+				return f.env.serve(f.p, "alice", http.MethodPost, "/complete", completeAPIRequest{ID: f.mine})
+			},
+			want: []testRefreshEvent{{"alice", []string{MyListKey}}},
+		},
+		{
+			name:   "complete accepted issue",
+			before: acceptInbox,
+			run: func(f *fixture) *httptest.ResponseRecorder {
+				return f.env.serve(f.p, "bob", http.MethodPost, "/complete", completeAPIRequest{ID: f.inbox})
+			},
+			want: []testRefreshEvent{{"bob", []string{MyListKey}}, {"alice", []string{OutListKey}}},
+		},
+		{
+			name: "remove received issue",
+			run: func(f *fixture) *httptest.ResponseRecorder {
+				return f.env.serve(f.p, "bob", http.MethodPost, "/remove", removeAPIRequest{ID: f.inbox})
+			},
+			want: []testRefreshEvent{{"bob", []string{InListKey}}, {"alice", []string{OutListKey}}},
+		},
+		{
+			name:   "remove sent issue already accepted",
+			before: acceptInbox,
+			run: func(f *fixture) *httptest.ResponseRecorder {
+				return f.env.serve(f.p, "alice", http.MethodPost, "/remove", removeAPIRequest{ID: f.sent})
+			},
+			want: []testRefreshEvent{{"alice", []string{OutListKey}}, {"bob", []string{MyListKey, InListKey}}},
+		},
+		{
+			name: "bump",
+			run: func(f *fixture) *httptest.ResponseRecorder {
+				return f.env.serve(f.p, "alice", http.MethodPost, "/bump", bumpAPIRequest{ID: f.sent})
+			},
+			want: []testRefreshEvent{{"bob", []string{InListKey}}},
+		},
+		{
+			name: "change assignment of own issue",
+			run: func(f *fixture) *httptest.ResponseRecorder {
+				return f.env.serve(f.p, "alice", http.MethodPost, "/change_assignment", changeAssignmentAPIRequest{ID: f.mine, SendTo: "carol"})
+			},
+			want: []testRefreshEvent{{"alice", []string{MyListKey, OutListKey}}, {"carol", []string{InListKey}}},
+		},
+		{
+			name: "change assignment of sent issue",
+			run: func(f *fixture) *httptest.ResponseRecorder {
+				return f.env.serve(f.p, "alice", http.MethodPost, "/change_assignment", changeAssignmentAPIRequest{ID: f.sent, SendTo: "carol"})
+			},
+			want: []testRefreshEvent{
+				{"alice", []string{MyListKey, OutListKey}},
+				{"carol", []string{InListKey}},
+				{"bob", []string{InListKey, MyListKey}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := setup(t)
+			if tt.before != nil {
+				tt.before(t, f)
+			}
+
+			w := tt.run(f)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			assert.Equal(t, tt.want, f.env.refreshes())
+		})
+	}
+}
+
+func TestChangeAssignmentMovesIssueToReceiver(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("carol", "carol")
+	p := env.newPlugin()
+
+	issue, err := p.listManager.AddIssue("alice", "mine", "", "", IssueFields{Points: 3, ExternalRef: "CI-42", Role: "qa", Priority: PriorityHigh})
+	require.NoError(t, err)
+
+	_, _, _, err = p.listManager.ChangeAssignment(issue.ID, "alice", "carol", true)
+	require.NoError(t, err)
+
+	received, err := p.listManager.GetIssueList("carol", InListKey)
+	require.NoError(t, err)
+	require.Len(t, received, 1)
+	assert.Equal(t, "mine", received[0].Message)
+	assert.Equal(t, issue.fields(), received[0].fields(), "the new receiver gets the fields of the todo")
+
+	sent, err := p.listManager.GetIssueList("alice", OutListKey)
+	require.NoError(t, err)
+	require.Len(t, sent, 1)
+	assert.Equal(t, "carol", sent[0].ForeignUser)
+}
+
+func TestChangeAssignmentTracking(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("carol", "carol")
+	p := env.newPlugin()
+
+	tracked, err := p.listManager.AddIssue("alice", "tracked", "", "", IssueFields{})
+	require.NoError(t, err)
+	untracked, err := p.listManager.AddIssue("alice", "untracked", "", "", IssueFields{})
+	require.NoError(t, err)
+
+	w := env.serve(p, "alice", http.MethodPost, "/change_assignment", changeAssignmentAPIRequest{ID: tracked.ID, SendTo: "carol"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = env.serve(p, "alice", http.MethodPost, "/change_assignment", changeAssignmentAPIRequest{ID: untracked.ID, SendTo: "carol", Untracked: true})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	sent, err := p.listManager.GetIssueList("alice", OutListKey)
+	require.NoError(t, err)
+	require.Len(t, sent, 1, "only the tracked Todo stays on the outgoing list")
+	assert.Equal(t, "tracked", sent[0].Message)
+	mine, err := p.listManager.GetIssueList("alice", MyListKey)
+	require.NoError(t, err)
+	assert.Empty(t, mine)
+
+	received, err := p.listManager.GetIssueList("carol", InListKey)
+	require.NoError(t, err)
+	require.Len(t, received, 2)
+	for _, issue := range received {
+		env.resetRecords()
+		w = env.serve(p, "carol", http.MethodPost, "/complete", &completeAPIRequest{ID: issue.ID})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		if issue.Message == "tracked" {
+			notes := env.postsTo("dm_alice")
+			require.Len(t, notes, 1)
+			assert.Equal(t, "@carol completed a Todo you sent: tracked", notes[0].Message)
+		} else {
+			assert.Empty(t, env.postsTo("dm_alice"), "untracked Todos do not notify the reassigner")
+		}
+	}
+
+	sent, err = p.listManager.GetIssueList("alice", OutListKey)
+	require.NoError(t, err)
+	assert.Empty(t, sent)
+}
+
+func TestCommandMutatorsRefreshEvents(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []testRefreshEvent
+	}{
+		{
+			name:    "add",
+			command: "/todo add new",
+			want:    []testRefreshEvent{{"alice", []string{MyListKey}}},
+		},
+		{
+			name:    "send",
+			command: "/todo send @bob new",
+			want:    []testRefreshEvent{{"alice", []string{OutListKey}}, {"bob", []string{InListKey}}},
+		},
+		{
+			name:    "pop accepted issue",
+			command: "/todo pop",
+			want:    []testRefreshEvent{{"carol", []string{OutListKey}}, {"alice", []string{MyListKey}}},
+		},
+		{
+			name:    "star",
+			command: "/todo star 1",
+			want:    []testRefreshEvent{{"alice", []string{MyListKey}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv()
+			env.addUser("alice", "alice")
+			env.addUser("bob", "bob")
+			env.addUser("carol", "carol")
+			p := env.newPlugin()
+
+			issueID, err := p.listManager.SendIssue("carol", "alice", "from carol", "", "", IssueFields{})
+			require.NoError(t, err)
+			_, _, err = p.listManager.AcceptIssue("alice", issueID)
+			require.NoError(t, err)
+			env.resetRecords()
+
+			_, appErr := p.ExecuteCommand(nil, &model.CommandArgs{UserId: "alice", Command: tt.command})
+			require.Nil(t, appErr)
+			assert.Equal(t, tt.want, env.refreshes())
+		})
+	}
+}
+
+func TestSendToOthersDisabled(t *testing.T) {
+	setup := func() (*Plugin, *testEnv) {
+		env := newTestEnv()
+		env.addUser("alice", "alice")
+		env.addUser("bob", "bob")
+		p := env.newPlugin()
+		p.setConfiguration(&configuration{EnableSendToOthers: false})
+		return p, env
+	}
+
+	t.Run("add with a receiver is rejected", func(t *testing.T) {
+		p, env := setup()
+		w := env.serve(p, "alice", http.MethodPost, "/add", addAPIRequest{Message: "task", SendTo: "bob"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		received, err := p.listManager.GetIssueList("bob", InListKey)
+		require.NoError(t, err)
+		assert.Empty(t, received)
+	})
+
+	t.Run("add to self still works", func(t *testing.T) {
+		p, env := setup()
+		w := env.serve(p, "alice", http.MethodPost, "/add", addAPIRequest{Message: "task", SendTo: "alice"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		issues, err := p.listManager.GetIssueList("alice", MyListKey)
+		require.NoError(t, err)
+		assert.Len(t, issues, 1)
+	})
+
+	t.Run("send command is rejected", func(t *testing.T) {
+		p, env := setup()
+		_, err := p.runSendCommand([]string{"@bob", "task"}, &model.CommandArgs{UserId: "alice"})
+		require.NoError(t, err)
+		assert.Contains(t, env.lastEphemeral(), "disabled")
+
+		received, err := p.listManager.GetIssueList("bob", InListKey)
+		require.NoError(t, err)
+		assert.Empty(t, received)
+	})
+
+	t.Run("send command to self still works", func(t *testing.T) {
+		p, _ := setup()
+		_, err := p.runSendCommand([]string{"@alice", "task"}, &model.CommandArgs{UserId: "alice"})
+		require.NoError(t, err)
+
+		issues, err := p.listManager.GetIssueList("alice", MyListKey)
+		require.NoError(t, err)
+		assert.Len(t, issues, 1)
+	})
+
+	t.Run("change assignment to others is rejected", func(t *testing.T) {
+		p, env := setup()
+		issue, err := p.listManager.AddIssue("alice", "task", "", "", IssueFields{})
+		require.NoError(t, err)
+
+		w := env.serve(p, "alice", http.MethodPost, "/change_assignment", changeAssignmentAPIRequest{ID: issue.ID, SendTo: "bob"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		issues, err := p.listManager.GetIssueList("alice", MyListKey)
+		require.NoError(t, err)
+		assert.Len(t, issues, 1)
+	})
+}
+
+func TestShouldRemind(t *testing.T) {
+	day := int64(24 * time.Hour / time.Millisecond)
+	lastReminderAt := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC).UnixNano() / int64(time.Millisecond)
+	snoozedUntil := lastReminderAt + 3*day
+
+	tests := []struct {
+		name         string
+		now          int64
+		snoozedUntil int64
+		want         bool
+	}{
+		{name: "Same day is not reminded", now: lastReminderAt + int64(2*time.Hour/time.Millisecond), want: false},
+		{name: "Next day is reminded", now: lastReminderAt + day, want: true},
+		{name: "First snoozed day is not reminded", now: lastReminderAt + day, snoozedUntil: snoozedUntil, want: false},
+		{name: "Last snoozed day is not reminded", now: snoozedUntil - 1, snoozedUntil: snoozedUntil, want: false},
+		{name: "Reminders resume after the snooze", now: snoozedUntil, snoozedUntil: snoozedUntil, want: true},
+		{name: "Past snooze has no effect", now: lastReminderAt + 5*day, snoozedUntil: snoozedUntil, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, shouldRemind(tt.now, lastReminderAt, tt.snoozedUntil, time.UTC))
+		})
+	}
+}
+
+func TestNextReminderAt(t *testing.T) {
+	hour := int64(time.Hour / time.Millisecond)
+	lastReminderAt := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC).UnixNano() / int64(time.Millisecond)
+	nextDay := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC).UnixNano() / int64(time.Millisecond)
+	lateReminderAt := time.Date(2024, 5, 1, 23, 30, 0, 0, time.UTC).UnixNano() / int64(time.Millisecond)
+
+	assert.Equal(t, int64(0), nextReminderAt(0, 0, time.UTC), "users never reminded can be reminded right away")
+	assert.Equal(t, nextDay, nextReminderAt(lastReminderAt, 0, time.UTC))
+	assert.Equal(t, lateReminderAt+hour, nextReminderAt(lateReminderAt, 0, time.UTC))
+	assert.Equal(t, nextDay+2*24*hour, nextReminderAt(lastReminderAt, nextDay+2*24*hour, time.UTC))
+	assert.Equal(t, nextDay, nextReminderAt(lastReminderAt, lastReminderAt, time.UTC), "past snoozes have no effect")
+
+	for _, now := range []int64{nextDay - 1, nextDay} {
+		assert.Equal(t, now >= nextReminderAt(lastReminderAt, 0, time.UTC), shouldRemind(now, lastReminderAt, 0, time.UTC))
+	}
+}
+
+func TestHandleListMeta(t *testing.T) {
+	env := newTestEnv()
+	p := env.newPlugin()
+
+	_, err := p.listManager.AddIssue("alice", "water the plants", "", "", IssueFields{})
+	require.NoError(t, err)
+
+	w := env.serve(p, "alice", http.MethodGet, "/list?meta=true", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response listAPIResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 1, response.Total)
+	require.Len(t, response.Issues, 1)
+	assert.Nil(t, response.Reminder, "the reminder is only reported when it is checked")
+
+	w = env.serve(p, "alice", http.MethodGet, "/list?meta=true&reminder=true", nil)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.NotNil(t, response.Reminder)
+	assert.True(t, response.Reminder.Sent)
+	assert.Greater(t, response.Reminder.NextReminderAt, model.GetMillis())
+
+	nextReminder := response.Reminder.NextReminderAt
+	response = listAPIResponse{}
+	w = env.serve(p, "alice", http.MethodGet, "/list?meta=true&reminder=true", nil)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.NotNil(t, response.Reminder)
+	assert.False(t, response.Reminder.Sent)
+	assert.Equal(t, nextReminder, response.Reminder.NextReminderAt)
+	assert.Len(t, env.postsTo("dm_alice"), 1)
+
+	require.NoError(t, p.saveReminderPreference("alice", false))
+	response = listAPIResponse{}
+	w = env.serve(p, "alice", http.MethodGet, "/list?meta=true&reminder=true", nil)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, &reminderStatus{}, response.Reminder)
+}
+
+func TestHandleListsMatchesIndividualLists(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+
+	_, err := p.listManager.AddIssue("alice", "mine", "", "", IssueFields{})
+	require.NoError(t, err)
+	_, err = p.listManager.SendIssue("bob", "alice", "received", "", "", IssueFields{})
+	require.NoError(t, err)
+	_, err = p.listManager.SendIssue("alice", "bob", "sent", "", "", IssueFields{})
+	require.NoError(t, err)
+
+	w := env.serve(p, "alice", http.MethodGet, "/lists", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var lists listsAPIResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &lists))
+
+	for flag, aggregated := range map[string][]*ExtendedIssue{MyFlag: lists.My, InFlag: lists.In, OutFlag: lists.Out} {
+		w = env.serve(p, "alice", http.MethodGet, "/list?list="+flag, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var individual []*ExtendedIssue
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &individual))
+		require.Len(t, individual, 1)
+		assert.Equal(t, individual, aggregated, flag)
+	}
+
+	// The reminder is sent once, with the user's own list only
+	env.resetRecords()
+	w = env.serve(p, "alice", http.MethodGet, "/lists?reminder=true", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	posts := env.postsTo("dm_alice")
+	require.Len(t, posts, 1)
+	assert.Contains(t, posts[0].Message, "1. mine")
+	assert.NotContains(t, posts[0].Message, "received")
+}
+
+func TestHandleBumpBulk(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	env.addUser("carol", "carol")
+	p := env.newPlugin()
+
+	for _, sent := range []struct{ receiver, message string }{
+		{"bob", "bob first"},
+		{"bob", "bob second"},
+		{"bob", "bob third"},
+		{"carol", "carol only"},
+	} {
+		_, err := p.listManager.SendIssue("alice", sent.receiver, sent.message, "", "", IssueFields{})
+		require.NoError(t, err)
+	}
+	sent, err := p.listManager.GetIssueList("alice", OutListKey)
+	require.NoError(t, err)
+	require.Len(t, sent, 4)
+	env.resetRecords()
+
+	w := env.serve(p, "alice", http.MethodPost, "/bump_bulk", &bumpBulkAPIRequest{IDs: []string{sent[2].ID, sent[1].ID, sent[3].ID}})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// Bumped issues go to the top of the inbox in the order they were given
+	received, err := p.listManager.GetIssueList("bob", InListKey)
+	require.NoError(t, err)
+	messages := []string{}
+	for _, issue := range received {
+		messages = append(messages, issue.Message)
+	}
+	assert.Equal(t, []string{"bob third", "bob second", "bob first"}, messages)
+
+	// Each receiver gets one refresh and one message
+	assert.ElementsMatch(t, []testRefreshEvent{
+		{"bob", []string{InListKey}},
+		{"carol", []string{InListKey}},
+	}, env.refreshes())
+
+	bobPosts := env.postsTo("dm_bob")
+	require.Len(t, bobPosts, 1)
+	assert.Equal(t, "@alice bumped 2 Todos you received:\n\n* bob third\n* bob second", bobPosts[0].Message)
+
+	carolPosts := env.postsTo("dm_carol")
+	require.Len(t, carolPosts, 1)
+	assert.Equal(t, "custom_todo", carolPosts[0].Type)
+	assert.Equal(t, "carol only", carolPosts[0].Props["todo"])
+
+	// Nothing is bumped if any of the issues is not a sent one
+	env.resetRecords()
+	w = env.serve(p, "alice", http.MethodPost, "/bump_bulk", &bumpBulkAPIRequest{IDs: []string{sent[0].ID, "unknown"}})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, env.refreshes())
+}
+
+func TestHandleMoveBulk(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	env.addUser("carol", "carol")
+	p := env.newPlugin()
+
+	mine, err := p.listManager.AddIssue("alice", "mine", "", "", IssueFields{})
+	require.NoError(t, err)
+	someday, err := p.listManager.AddIssue("alice", "learn the piano", "", "", IssueFields{})
+	require.NoError(t, err)
+	require.NoError(t, p.listManager.SetSomeday("alice", someday.ID, true))
+	firstReceived, err := p.listManager.SendIssue("bob", "alice", "review", "", "", IssueFields{})
+	require.NoError(t, err)
+	secondReceived, err := p.listManager.SendIssue("bob", "alice", "deploy", "", "", IssueFields{})
+	require.NoError(t, err)
+	_, err = p.listManager.SendIssue("alice", "carol", "sent away", "", "", IssueFields{})
+	require.NoError(t, err)
+	sent, err := p.listManager.GetIssueList("alice", OutListKey)
+	require.NoError(t, err)
+	require.Len(t, sent, 1)
+	env.resetRecords()
+
+	w := env.serve(p, "alice", http.MethodPost, "/move_bulk", &moveBulkAPIRequest{
+		IDs:  []string{someday.ID, firstReceived, mine.ID, sent[0].ID, secondReceived, "unknown"},
+		List: MyFlag,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var results []*moveBulkResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &results))
+	assert.Equal(t, []*moveBulkResult{
+		{ID: someday.ID, Moved: true},
+		{ID: firstReceived, Moved: true},
+		{ID: mine.ID, Error: errInvalidMove.Error()},
+		{ID: sent[0].ID, Error: errInvalidMove.Error()},
+		{ID: secondReceived, Moved: true},
+		{ID: "unknown", Error: errIssueNotFound.Error()},
+	}, results)
+
+	myList, err := p.listManager.GetIssueList("alice", MyListKey)
+	require.NoError(t, err)
+	assert.Len(t, myList, 4)
+
+	// The refreshes and notifications of the whole batch are sent once
+	assert.ElementsMatch(t, []testRefreshEvent{
+		{"alice", []string{SomedayListKey, MyListKey, InListKey}},
+		{"bob", []string{OutListKey}},
+	}, env.refreshes())
+	bobPosts := env.postsTo("dm_bob")
+	require.Len(t, bobPosts, 1)
+	assert.Equal(t, "@alice accepted 2 Todos you sent:\n\n* review\n* deploy", bobPosts[0].Message)
+
+	w = env.serve(p, "alice", http.MethodPost, "/move_bulk", &moveBulkAPIRequest{IDs: []string{mine.ID}, List: OutFlag})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSomedayIssuesAreLeftOutOfReminders(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	p := env.newPlugin()
+	extra := &model.CommandArgs{UserId: "alice"}
+
+	_, err := p.listManager.AddIssue("alice", "active", "", "", IssueFields{})
+	require.NoError(t, err)
+	_, err = p.listManager.AddIssue("alice", "learn the piano", "", "", IssueFields{})
+	require.NoError(t, err)
+
+	_, err = p.runSomedayCommand([]string{"2"}, extra)
+	require.NoError(t, err)
+
+	w := env.serve(p, "alice", http.MethodGet, "/list?list=someday", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var someday []*ExtendedIssue
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &someday))
+	require.Len(t, someday, 1)
+	assert.Equal(t, "learn the piano", someday[0].Message)
+
+	env.resetRecords()
+	w = env.serve(p, "alice", http.MethodGet, "/list?reminder=true", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var mine []*ExtendedIssue
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &mine))
+	require.Len(t, mine, 1)
+
+	posts := env.postsTo("dm_alice")
+	require.Len(t, posts, 1)
+	assert.Contains(t, posts[0].Message, "1. active")
+	assert.NotContains(t, posts[0].Message, "piano")
+
+	// Someday items can be brought back to the list
+	_, err = p.runActivateCommand([]string{"1"}, extra)
+	require.NoError(t, err)
+	mine, err = p.listManager.GetIssueList("alice", MyListKey)
+	require.NoError(t, err)
+	assert.Len(t, mine, 2)
+
+	isUserError, err := p.runActivateCommand([]string{"1"}, extra)
+	assert.Error(t, err)
+	assert.True(t, isUserError)
+}
+
+func TestCompleteNoteIsPostedToTheThread(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.api.On("GetPost", "thread_post").Return(&model.Post{Id: "thread_post", ChannelId: "town"}, nil)
+	p := env.newPlugin()
+
+	shipIt, err := p.listManager.AddIssue("alice", "ship it", "", "thread_post", IssueFields{})
+	require.NoError(t, err)
+	_, err = p.listManager.AddIssue("alice", "roll it out", "", "thread_post", IssueFields{})
+	require.NoError(t, err)
+
+	w := env.serve(p, "alice", http.MethodPost, "/complete", &completeAPIRequest{ID: shipIt.ID, Note: " released in 1.2 "})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	_, err = p.runCompleteCommand([]string{"1", "note:", "deployed", "to", "production"}, &model.CommandArgs{UserId: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, "Completed Todo: roll it out", env.lastEphemeral())
+
+	posts := env.postsTo("town")
+	require.Len(t, posts, 2)
+	assert.Equal(t, "@alice completed a todo attached to this thread with a note: released in 1.2\n> ship it", posts[0].Message)
+	assert.Equal(t, "@alice completed a todo attached to this thread with a note: deployed to production\n> roll it out", posts[1].Message)
+}
+
+func TestThreadRepliesOnlyOnCreateAndComplete(t *testing.T) {
+	tests := []struct {
+		name      string
+		reduced   bool
+		wantReply []string
+	}{
+		{
+			name:      "Every lifecycle event is replied by default",
+			reduced:   false,
+			wantReply: []string{"attached", "attached", "completed", "removed"},
+		},
+		{
+			name:      "Only adding and completing are replied when reduced",
+			reduced:   true,
+			wantReply: []string{"attached", "attached", "completed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv()
+			env.addUser("alice", "alice")
+			env.api.On("GetPost", "thread_post").Return(&model.Post{Id: "thread_post", ChannelId: "town"}, nil)
+			p := env.newPlugin()
+			p.setConfiguration(&configuration{EnableSendToOthers: true, ReplyOnlyOnCreateAndComplete: tt.reduced})
+
+			for i := 0; i < 2; i++ {
+				w := env.serve(p, "alice", http.MethodPost, "/add", &addAPIRequest{Message: "follow up", PostID: "thread_post"})
+				require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			}
+			issues, err := p.listManager.GetIssueList("alice", MyListKey)
+			require.NoError(t, err)
+			require.Len(t, issues, 2)
+
+			w := env.serve(p, "alice", http.MethodPost, "/complete", &completeAPIRequest{ID: issues[0].ID})
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			w = env.serve(p, "alice", http.MethodPost, "/remove", &removeAPIRequest{ID: issues[1].ID})
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+			replies := []string{}
+			for _, post := range env.postsTo("town") {
+				assert.Equal(t, "thread_post", post.RootId)
+				replies = append(replies, strings.Fields(post.Message)[1])
+			}
+			assert.Equal(t, tt.wantReply, replies)
+		})
+	}
+}
+
+func TestNoThreadRepliesInQuietChannels(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	env.api.On("GetPost", "quiet_post").Return(&model.Post{Id: "quiet_post", ChannelId: "quiet"}, nil)
+	env.api.On("GetPost", "town_post").Return(&model.Post{Id: "town_post", ChannelId: "town"}, nil)
+	p := env.newPlugin()
+	p.setConfiguration(&configuration{EnableSendToOthers: true, QuietThreadChannels: "other, quiet"})
+
+	for _, postID := range []string{"quiet_post", "town_post"} {
+		w := env.serve(p, "alice", http.MethodPost, "/add", &addAPIRequest{Message: "follow up", PostID: postID})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		w = env.serve(p, "alice", http.MethodPost, "/add", &addAPIRequest{Message: "review", SendTo: "bob", PostID: postID})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+
+	assert.Empty(t, env.postsTo("quiet"))
+	assert.Len(t, env.postsTo("town"), 2, "other channels are still replied on")
+}
+
+func TestHandleEditPoints(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+
+	receivedID, err := p.listManager.SendIssue("alice", "bob", "estimate me", "", "", IssueFields{})
+	require.NoError(t, err)
+
+	points := 8
+	w := env.serve(p, "bob", http.MethodPost, "/edit", editAPIRequest{ID: receivedID, Message: "estimate me", Points: &points})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	sent, err := p.listManager.GetIssueList("alice", OutListKey)
+	require.NoError(t, err)
+	require.Len(t, sent, 1)
+	assert.Equal(t, 8, sent[0].Points)
+
+	// Edits without points leave them as they are
+	w = env.serve(p, "bob", http.MethodPost, "/edit", editAPIRequest{ID: receivedID, Message: "estimated"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	received, err := p.listManager.GetIssue("bob", receivedID)
+	require.NoError(t, err)
+	assert.Equal(t, 8, received.Points)
+
+	points = -1
+	w = env.serve(p, "bob", http.MethodPost, "/edit", editAPIRequest{ID: receivedID, Message: "estimated", Points: &points})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandleEditReturnsDiff(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+
+	receivedID, err := p.listManager.SendIssue("alice", "bob", "draft", "old notes", "", IssueFields{})
+	require.NoError(t, err)
+
+	w := env.serve(p, "bob", http.MethodPost, "/edit", editAPIRequest{ID: receivedID, Message: "final", Description: "new notes"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var diff IssueDiff
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &diff))
+	assert.Equal(t, IssueDiff{OldMessage: "draft", NewMessage: "final", OldDescription: "old notes", NewDescription: "new notes"}, diff)
+
+	notes := env.postsTo("dm_alice")
+	require.Len(t, notes, 1)
+	assert.Equal(t, "@bob modified a Todo from:\ndraft\nTo:\nfinal", notes[0].Message)
+}
+
+func TestSendWithDueDate(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+
+	dueAt := time.Date(2026, time.March, 2, 17, 0, 0, 0, time.UTC)
+	w := env.serve(p, "alice", http.MethodPost, "/add", addAPIRequest{Message: "ship it", SendTo: "bob", DueAt: dueAt.Unix() * 1000})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	received, err := p.listManager.GetIssueList("bob", InListKey)
+	require.NoError(t, err)
+	require.Len(t, received, 1)
+	assert.Equal(t, dueAt.Unix()*1000, received[0].DueAt)
+	sent, err := p.listManager.GetIssueList("alice", OutListKey)
+	require.NoError(t, err)
+	require.Len(t, sent, 1)
+	assert.Equal(t, dueAt.Unix()*1000, sent[0].DueAt, "the sender sees the same deadline")
+
+	// The deadline shows on the reminder of the receiver once accepted
+	_, _, err = p.listManager.AcceptIssue("bob", received[0].ID)
+	require.NoError(t, err)
+	issues, err := p.listManager.GetIssueList("bob", MyListKey)
+	require.NoError(t, err)
+	p.sendDailyReminder("bob", issues)
+	posts := env.postsTo("dm_bob")
+	require.NotEmpty(t, posts)
+	assert.Contains(t, posts[len(posts)-1].Message, "* Due: March 2, 2026 at 17:00 UTC")
+
+	w = env.serve(p, "alice", http.MethodPost, "/add", addAPIRequest{Message: "someday", SendTo: "bob", DueAt: -1})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAddWithDueDateAndPriority(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	p := env.newPlugin()
+
+	dueAt := model.GetMillis() - time.Hour.Milliseconds()
+	w := env.serve(p, "alice", http.MethodPost, "/add", addAPIRequest{Message: "renew the domain", DueAt: dueAt})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = env.serve(p, "alice", http.MethodPost, "/add", addAPIRequest{Message: "water the plants"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	issues, err := p.listManager.GetIssueList("alice", MyListKey)
+	require.NoError(t, err)
+	require.Len(t, issues, 2)
+	assert.Equal(t, dueAt, issues[0].DueAt)
+	assert.Zero(t, issues[1].DueAt)
+
+	w = env.serve(p, "alice", http.MethodPost, "/add", addAPIRequest{Message: "file the taxes", Priority: "high"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = env.serve(p, "alice", http.MethodPost, "/add", addAPIRequest{Message: "someday", Priority: "urgent"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	issues, err = p.listManager.GetIssueList("alice", MyListKey)
+	require.NoError(t, err)
+	require.Len(t, issues, 3)
+	assert.Equal(t, "file the taxes", issues[0].Message)
+	assert.Equal(t, PriorityHigh, issues[0].Priority)
+
+	_, err = p.runListCommand([]string{"overdue"}, &model.CommandArgs{UserId: "alice"})
+	require.NoError(t, err)
+	response := env.lastEphemeral()
+	assert.Contains(t, response, "Overdue Todo list:")
+	assert.Contains(t, response, "renew the domain")
+	assert.NotContains(t, response, "water the plants")
+}
+
+func TestHandleIssueByRef(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+
+	w := env.serve(p, "alice", http.MethodPost, "/add", addAPIRequest{Message: "fix the build", ExternalRef: "CI-42"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = env.serve(p, "alice", http.MethodPost, "/add", addAPIRequest{Message: "review the fix", SendTo: "bob", ExternalRef: "PR-7"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = env.serve(p, "alice", http.MethodPost, "/add", addAPIRequest{Message: "no reference"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	tests := []struct {
+		name     string
+		userID   string
+		ref      string
+		wantCode int
+		want     string
+	}{
+		{name: "Own todo", userID: "alice", ref: "CI-42", wantCode: http.StatusOK, want: "fix the build"},
+		{name: "Sent todo", userID: "alice", ref: "PR-7", wantCode: http.StatusOK, want: "review the fix"},
+		{name: "Received todo", userID: "bob", ref: "PR-7", wantCode: http.StatusOK, want: "review the fix"},
+		{name: "Someone else's todo", userID: "bob", ref: "CI-42", wantCode: http.StatusNotFound},
+		{name: "Unknown reference", userID: "alice", ref: "CI-43", wantCode: http.StatusNotFound},
+		{name: "Missing reference", userID: "alice", ref: "", wantCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := env.serve(p, tt.userID, http.MethodGet, "/issue/by-ref?ref="+url.QueryEscape(tt.ref), nil)
+			require.Equal(t, tt.wantCode, w.Code, w.Body.String())
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			var issue ExtendedIssue
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &issue))
+			assert.Equal(t, tt.want, issue.Message)
+			assert.Equal(t, tt.ref, issue.ExternalRef)
+		})
+	}
+
+	w = env.serve(p, "alice", http.MethodPost, "/add", addAPIRequest{Message: "too long", ExternalRef: strings.Repeat("x", MaxExternalRefLength+1)})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandleValidateUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		want     validateUserAPIResponse
+	}{
+		{
+			name:     "User accepting Todos",
+			username: "bob",
+			want:     validateUserAPIResponse{Valid: true, AllowsIncoming: true, DisplayName: "Bob Builder"},
+		},
+		{
+			name:     "Leading @ is ignored",
+			username: "@bob",
+			want:     validateUserAPIResponse{Valid: true, AllowsIncoming: true, DisplayName: "Bob Builder"},
+		},
+		{
+			name:     "User blocking Todos",
+			username: "carol",
+			want:     validateUserAPIResponse{Valid: true},
+		},
+		{
+			name:     "Unknown user",
+			username: "nobody",
+			want:     validateUserAPIResponse{},
+		},
+		{
+			name:     "Empty username",
+			username: "",
+			want:     validateUserAPIResponse{},
+		},
+	}
+
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	bob := env.addUser("bob", "bob")
+	bob.FirstName, bob.LastName = "Bob", "Builder"
+	env.addUser("carol", "carol")
+	p := env.newPlugin()
+	require.NoError(t, p.saveAllowIncomingTaskRequestsPreference("carol", false))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := env.serve(p, "alice", http.MethodGet, "/validate_user?username="+url.QueryEscape(tt.username), nil)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+			var response validateUserAPIResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.want, response)
+		})
+	}
+}
+
+func TestAPIErrorCodes(t *testing.T) {
+	type fixture struct {
+		env *testEnv
+		p   *Plugin
+	}
+
+	tests := []struct {
+		name       string
+		serve      func(f *fixture) *httptest.ResponseRecorder
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name: "Malformed request",
+			serve: func(f *fixture) *httptest.ResponseRecorder {
+				return f.env.serve(f.p, "alice", http.MethodPost, "/complete", "not an object")
+			},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   errorCodeInvalidInput,
+		},
+		{
+			name: "Todo not on the user's lists",
+			serve: func(f *fixture) *httptest.ResponseRecorder {
+				return f.env.serve(f.p, "alice", http.MethodPost, "/complete", completeAPIRequest{ID: "missing"})
+			},
+			wantStatus: http.StatusNotFound,
+			wantCode:   errorCodeIssueNotFound,
+		},
+		{
+			name: "Bumping a todo that was not sent",
+			serve: func(f *fixture) *httptest.ResponseRecorder {
+				_, err := f.p.listManager.SendIssue("alice", "bob", "sent", "", "", IssueFields{})
+				require.NoError(t, err)
+				return f.env.serve(f.p, "alice", http.MethodPost, "/bump_bulk", bumpBulkAPIRequest{IDs: []string{"missing"}})
+			},
+			wantStatus: http.StatusNotFound,
+			wantCode:   errorCodeIssueNotFound,
+		},
+		{
+			name: "Sending to an unknown user",
+			serve: func(f *fixture) *httptest.ResponseRecorder {
+				return f.env.serve(f.p, "alice", http.MethodPost, "/add", addAPIRequest{Message: "review", SendTo: "nobody"})
+			},
+			wantStatus: http.StatusNotFound,
+			wantCode:   errorCodeUserNotFound,
+		},
+		{
+			name: "Sending to a user blocking Todos",
+			serve: func(f *fixture) *httptest.ResponseRecorder {
+				w := f.env.serve(f.p, "alice", http.MethodPost, "/add", addAPIRequest{Message: "review", SendTo: "carol"})
+				issues, err := f.p.listManager.GetIssueList("carol", InListKey)
+				require.NoError(t, err)
+				assert.Empty(t, issues)
+				posts := f.env.postsTo("dm_alice")
+				require.Len(t, posts, 1, "the sender is still told why nothing was sent")
+				assert.Equal(t, "@carol has blocked Todo requests", posts[0].Message)
+				return w
+			},
+			wantStatus: http.StatusForbidden,
+			wantCode:   errorCodeUserBlocked,
+		},
+		{
+			name: "Sending with sending to others disabled",
+			serve: func(f *fixture) *httptest.ResponseRecorder {
+				f.p.setConfiguration(&configuration{EnableSendToOthers: false})
+				return f.env.serve(f.p, "alice", http.MethodPost, "/add", addAPIRequest{Message: "review", SendTo: "bob"})
+			},
+			wantStatus: http.StatusForbidden,
+			wantCode:   errorCodeSendToOthersDisabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv()
+			env.addUser("alice", "alice")
+			env.addUser("bob", "bob")
+			env.addUser("carol", "carol")
+			p := env.newPlugin()
+			require.NoError(t, p.saveAllowIncomingTaskRequestsPreference("carol", false))
+
+			w := tt.serve(&fixture{env: env, p: p})
+			require.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+
+			var response struct {
+				Error   string `json:"error"`
+				Details string `json:"details"`
+				Code    string `json:"code"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.wantCode, response.Code)
+			assert.NotEmpty(t, response.Error)
+		})
+	}
+}
+
+func TestWhitespaceOnlyMessagesAreRejected(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+	issue, err := p.listManager.AddIssue("alice", "keep me", "", "", IssueFields{})
+	require.NoError(t, err)
+
+	t.Run("Add endpoint", func(t *testing.T) {
+		w := env.serve(p, "alice", http.MethodPost, "/add", addAPIRequest{Message: "  \n\t "})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Send endpoint", func(t *testing.T) {
+		w := env.serve(p, "alice", http.MethodPost, "/add", addAPIRequest{Message: " ", SendTo: "bob"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Edit endpoint", func(t *testing.T) {
+		w := env.serve(p, "alice", http.MethodPost, "/edit", editAPIRequest{ID: issue.ID, Message: "   "})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Add command", func(t *testing.T) {
+		isUserError, err := p.runAddCommand([]string{}, &model.CommandArgs{UserId: "alice"})
+		assert.Equal(t, errEmptyMessage, err)
+		assert.True(t, isUserError)
+	})
+
+	t.Run("Send command", func(t *testing.T) {
+		isUserError, err := p.runSendCommand([]string{"@bob", "track:off"}, &model.CommandArgs{UserId: "alice"})
+		assert.Equal(t, errEmptyMessage, err)
+		assert.True(t, isUserError)
+	})
+
+	mine, err := p.listManager.GetIssueList("alice", MyListKey)
+	require.NoError(t, err)
+	require.Len(t, mine, 1)
+	assert.Equal(t, "keep me", mine[0].Message)
+	received, err := p.listManager.GetIssueList("bob", InListKey)
+	require.NoError(t, err)
+	assert.Empty(t, received)
+
+	// Surrounding whitespace is trimmed from the todos that are kept
+	w := env.serve(p, "alice", http.MethodPost, "/edit", editAPIRequest{ID: issue.ID, Message: "  edited \n", Description: " details "})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	edited, err := p.listManager.GetIssue("alice", issue.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "edited", edited.Message)
+	assert.Equal(t, "details", edited.Description)
+}
+
+func TestMutedIssueSuppressesItsNotifications(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+
+	mutedID, err := p.listManager.SendIssue("alice", "bob", "chatty", "", "", IssueFields{})
+	require.NoError(t, err)
+	otherID, err := p.listManager.SendIssue("alice", "bob", "quiet", "", "", IssueFields{})
+	require.NoError(t, err)
+
+	_, err = p.runMuteCommand([]string{"out", "1"}, &model.CommandArgs{UserId: "alice"})
+	require.NoError(t, err)
+	assert.Contains(t, env.lastEphemeral(), "Muted Todo: chatty")
+	env.resetRecords()
+
+	w := env.serve(p, "bob", http.MethodPost, "/edit", editAPIRequest{ID: mutedID, Message: "chattier"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = env.serve(p, "bob", http.MethodPost, "/complete", completeAPIRequest{ID: mutedID})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Empty(t, env.postsTo("dm_alice"))
+	assert.Contains(t, env.refreshes(), testRefreshEvent{"alice", []string{OutListKey}}, "the muted list is still refreshed")
+
+	w = env.serve(p, "bob", http.MethodPost, "/edit", editAPIRequest{ID: otherID, Message: "louder"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	posts := env.postsTo("dm_alice")
+	require.Len(t, posts, 1)
+	assert.Contains(t, posts[0].Message, "louder")
+
+	// Accepting a muted todo does not tell its sender either, however it is accepted
+	muteSent := func(message string) {
+		sent, err := p.listManager.GetIssueList("alice", OutListKey)
+		require.NoError(t, err)
+		for _, issue := range sent {
+			if issue.Message == message {
+				_, err = p.listManager.MuteIssue("alice", issue.ID)
+				require.NoError(t, err)
+				return
+			}
+		}
+		t.Fatalf("no sent todo %q", message)
+	}
+	send := func(message string, muted bool) string {
+		id, err := p.listManager.SendIssue("alice", "bob", message, "", "", IssueFields{})
+		require.NoError(t, err)
+		if muted {
+			muteSent(message)
+		}
+		return id
+	}
+
+	env.resetRecords()
+	w = env.serve(p, "bob", http.MethodPost, "/accept", acceptAPIRequest{ID: send("accepted muted", true)})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Empty(t, env.postsTo("dm_alice"))
+	assert.Contains(t, env.refreshes(), testRefreshEvent{"alice", []string{OutListKey}})
+
+	w = env.serve(p, "bob", http.MethodPost, "/move_bulk", &moveBulkAPIRequest{IDs: []string{send("moved muted", true), send("moved", false)}, List: MyFlag})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	posts = env.postsTo("dm_alice")
+	require.Len(t, posts, 1)
+	assert.Equal(t, "@bob accepted a Todo you sent: moved", posts[0].Message)
+
+	env.resetRecords()
+	send("accepted from muted", true)
+	send("accepted from", false)
+	_, err = p.runAcceptFromCommand([]string{"@alice"}, &model.CommandArgs{UserId: "bob"})
+	require.NoError(t, err)
+	posts = env.postsTo("dm_alice")
+	require.Len(t, posts, 1)
+	assert.Contains(t, posts[0].Message, "accepted from")
+	assert.NotContains(t, posts[0].Message, "accepted from muted")
+	assert.Contains(t, env.lastEphemeral(), "accepted from muted", "the receiver still sees all the todos they accepted")
+
+	// Only shared todos can be muted
+	_, err = p.listManager.AddIssue("alice", "mine", "", "", IssueFields{})
+	require.NoError(t, err)
+	isUserError, err := p.runMuteCommand([]string{"1"}, &model.CommandArgs{UserId: "alice"})
+	assert.Error(t, err)
+	assert.True(t, isUserError)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+const (
+	// MaxRecentRecipients is the number of users a user sent todos to that are remembered
+	MaxRecentRecipients = 5
+	// MaxRecipientSuggestions is the number of users suggested when autocompleting whom to send a todo to
+	MaxRecipientSuggestions = 10
+)
+
+// addRecentRecipient moves recipientID to the front of recentIDs, keeping at most MaxRecentRecipients.
+func addRecentRecipient(recentIDs []string, recipientID string) []string {
+	updated := []string{recipientID}
+	for _, id := range recentIDs {
+		if id != recipientID && len(updated) < MaxRecentRecipients {
+			updated = append(updated, id)
+		}
+	}
+	return updated
+}
+
+// rememberRecipient records that userID just sent a todo to recipientID, so they are suggested first next time.
+func (p *Plugin) rememberRecipient(userID, recipientID string) {
+	recentIDs, err := p.getRecentRecipients(userID)
+	if err != nil {
+		p.API.LogError("Unable to get the recent recipients err=" + err.Error())
+		return
+	}
+
+	if err = p.saveRecentRecipients(userID, addRecentRecipient(recentIDs, recipientID)); err != nil {
+		p.API.LogError("Unable to save the recent recipients err=" + err.Error())
+	}
+}
+
+// rankRecipientSuggestions suggests the recent recipients whose username starts with term first, in the order
+// they were sent todos, followed by the other matching users, up to MaxRecipientSuggestions.
+func rankRecipientSuggestions(recent, matching []*model.User, term string) []model.AutocompleteListItem {
+	term = strings.ToLower(term)
+	items := []model.AutocompleteListItem{}
+	suggested := map[string]bool{}
+
+	suggest := func(user *model.User, hint string) {
+		if suggested[user.Id] || len(items) >= MaxRecipientSuggestions {
+			return
+		}
+		suggested[user.Id] = true
+		items = append(items, model.AutocompleteListItem{
+			Item:     "@" + user.Username,
+			Hint:     hint,
+			HelpText: user.GetDisplayName(model.SHOW_FULLNAME),
+		})
+	}
+
+	for _, user := range recent {
+		if strings.HasPrefix(strings.ToLower(user.Username), term) {
+			suggest(user, "(recent)")
+		}
+	}
+	for _, user := range matching {
+		suggest(user, "")
+	}
+
+	return items
+}
+
+// API endpoint called by the server to autocomplete the user of /todo send
+func (p *Plugin) handleAutocompleteRecipients(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	term := strings.TrimSpace(strings.TrimPrefix(query.Get("user_input"), query.Get("parsed")))
+	term = strings.TrimPrefix(term, "@")
+	// The server passes the team and channel the command is typed in
+	extra := &model.CommandArgs{TeamId: query.Get("team_id"), ChannelId: query.Get("channel_id")}
+
+	recentIDs, err := p.getRecentRecipients(userID)
+	if err != nil {
+		p.API.LogError("Unable to get the recent recipients err=" + err.Error())
+	}
+	recent := []*model.User{}
+	for _, id := range recentIDs {
+		if user, appErr := p.API.GetUser(id); appErr == nil && user.DeleteAt == 0 && p.isReceiverInCommandChannel(user.Id, extra) {
+			recent = append(recent, user)
+		}
+	}
+
+	matching := []*model.User{}
+	if term != "" {
+		search := &model.UserSearch{Term: term, TeamId: extra.TeamId, Limit: MaxRecipientSuggestions}
+		if p.getConfiguration().RestrictSendToChannelMembers {
+			search.InChannelId = extra.ChannelId
+		}
+		users, appErr := p.API.SearchUsers(search)
+		if appErr != nil {
+			p.API.LogError("Unable to search the recipients err=" + appErr.Error())
+		} else {
+			matching = users
+		}
+	}
+
+	itemsJSON, err := json.Marshal(rankRecipientSuggestions(recent, matching, term))
+	if err != nil {
+		p.API.LogError("Unable to marshal the recipient suggestions err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to autocomplete the recipients", err)
+		return
+	}
+
+	_, err = w.Write(itemsJSON)
+	if err != nil {
+		p.API.LogError("Unable to write json response err=" + err.Error())
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddRecentRecipient(t *testing.T) {
+	assert.Equal(t, []string{"bob"}, addRecentRecipient(nil, "bob"))
+	assert.Equal(t, []string{"carol", "bob"}, addRecentRecipient([]string{"bob"}, "carol"))
+	assert.Equal(t, []string{"bob", "carol"}, addRecentRecipient([]string{"carol", "bob"}, "bob"), "sending again moves the recipient to the front")
+
+	full := []string{"u1", "u2", "u3", "u4", "u5"}
+	assert.Equal(t, []string{"u6", "u1", "u2", "u3", "u4"}, addRecentRecipient(full, "u6"), "the oldest recipient is forgotten")
+}
+
+func TestRankRecipientSuggestions(t *testing.T) {
+	bob := &model.User{Id: "bob", Username: "bob", FirstName: "Bob", LastName: "Builder"}
+	bobby := &model.User{Id: "bobby", Username: "bobby"}
+	carol := &model.User{Id: "carol", Username: "carol"}
+
+	items := rankRecipientSuggestions([]*model.User{bobby, carol}, []*model.User{bob, bobby}, "Bo")
+	require.Len(t, items, 2)
+	assert.Equal(t, model.AutocompleteListItem{Item: "@bobby", Hint: "(recent)", HelpText: "bobby"}, items[0])
+	assert.Equal(t, model.AutocompleteListItem{Item: "@bob", HelpText: "Bob Builder"}, items[1])
+
+	items = rankRecipientSuggestions([]*model.User{bobby, carol}, nil, "")
+	require.Len(t, items, 2)
+	assert.Equal(t, "@bobby", items[0].Item)
+	assert.Equal(t, "@carol", items[1].Item)
+}
+
+func TestHandleAutocompleteRecipients(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	env.addUser("bobby", "bobby")
+	p := env.newPlugin()
+	env.api.On("SearchUsers", mock.Anything).Return([]*model.User{env.users["bob"], env.users["bobby"]}, nil)
+
+	_, err := p.runSendCommand([]string{"@bobby", "look", "at", "this"}, &model.CommandArgs{UserId: "alice"})
+	require.NoError(t, err)
+
+	query := url.Values{"user_input": {"todo send @bo"}, "parsed": {"todo send "}}
+	w := env.serve(p, "alice", http.MethodGet, "/autocomplete/recipients?"+query.Encode(), nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var items []model.AutocompleteListItem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &items))
+	require.Len(t, items, 2)
+	assert.Equal(t, "@bobby", items[0].Item, "the recent recipient is ranked first")
+	assert.Equal(t, "(recent)", items[0].Hint)
+	assert.Equal(t, "@bob", items[1].Item)
+}
+
+func TestHandleAutocompleteRecipientsRestrictedToChannel(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	env.addUser("bobby", "bobby")
+	p := env.newPlugin()
+	p.setConfiguration(&configuration{EnableSendToOthers: true, RestrictSendToChannelMembers: true})
+	env.api.On("GetChannelMember", "standup", "bob").Return(&model.ChannelMember{ChannelId: "standup", UserId: "bob"}, nil)
+	env.api.On("GetChannelMember", "standup", "bobby").Return(nil, &model.AppError{Message: "not a member"})
+	env.api.On("SearchUsers", &model.UserSearch{Term: "bo", TeamId: "team", InChannelId: "standup", Limit: MaxRecipientSuggestions}).Return([]*model.User{env.users["bob"]}, nil)
+	require.NoError(t, p.saveRecentRecipients("alice", []string{"bobby", "bob"}))
+
+	query := url.Values{"user_input": {"todo send @bo"}, "parsed": {"todo send "}, "team_id": {"team"}, "channel_id": {"standup"}}
+	w := env.serve(p, "alice", http.MethodGet, "/autocomplete/recipients?"+query.Encode(), nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var items []model.AutocompleteListItem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &items))
+	require.Len(t, items, 1, "recent recipients outside of the channel are not suggested")
+	assert.Equal(t, "@bob", items[0].Item)
+	assert.Equal(t, "(recent)", items[0].Hint)
+}
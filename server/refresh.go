@@ -0,0 +1,124 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+// MaxRefreshEventsPerSecond is the number of refresh events a user is sent each second before the rest are merged
+const MaxRefreshEventsPerSecond = 5
+
+// refreshThrottle bounds the refresh events sent to each user to max per window. The events past the limit are
+// merged into a single trailing event, sent when the window is over, so the lists always end up refreshed.
+type refreshThrottle struct {
+	max     int
+	window  time.Duration
+	publish func(userID string, lists []string)
+
+	mutex sync.Mutex
+	users map[string]*userRefreshes
+	// prunedAt is the last time the users whose window is over were forgotten
+	prunedAt time.Time
+}
+
+// userRefreshes tracks the refresh events sent to a user during the current window.
+type userRefreshes struct {
+	windowStart time.Time
+	sent        int
+	// pending holds the lists of the trailing event, when there is one
+	pending    []string
+	hasPending bool
+}
+
+func newRefreshThrottle(max int, window time.Duration, publish func(userID string, lists []string)) *refreshThrottle {
+	return &refreshThrottle{
+		max:     max,
+		window:  window,
+		publish: publish,
+		users:   map[string]*userRefreshes{},
+	}
+}
+
+// send publishes a refresh of lists for userID, or merges it into the trailing event if the user reached the limit.
+func (t *refreshThrottle) send(userID string, lists []string) {
+	t.mutex.Lock()
+	now := time.Now()
+	user := t.users[userID]
+	if user == nil || (!user.hasPending && now.Sub(user.windowStart) >= t.window) {
+		t.prune(now)
+		user = &userRefreshes{windowStart: now}
+		t.users[userID] = user
+	}
+
+	if !user.hasPending && user.sent < t.max {
+		user.sent++
+		t.mutex.Unlock()
+		t.publish(userID, lists)
+		return
+	}
+
+	user.pending = mergeLists(user.pending, lists)
+	if !user.hasPending {
+		user.hasPending = true
+		time.AfterFunc(user.windowStart.Add(t.window).Sub(now), func() { t.flush(userID) })
+	}
+	t.mutex.Unlock()
+}
+
+// prune forgets the users whose window is over and who have no trailing event, at most once per window.
+// The mutex must be held.
+func (t *refreshThrottle) prune(now time.Time) {
+	if now.Sub(t.prunedAt) < t.window {
+		return
+	}
+	t.prunedAt = now
+
+	for userID, user := range t.users {
+		if !user.hasPending && now.Sub(user.windowStart) >= t.window {
+			delete(t.users, userID)
+		}
+	}
+}
+
+// flush publishes the trailing event of userID, which counts as the first event of a new window.
+func (t *refreshThrottle) flush(userID string) {
+	t.mutex.Lock()
+	user := t.users[userID]
+	lists := user.pending
+	t.users[userID] = &userRefreshes{windowStart: time.Now(), sent: 1}
+	t.mutex.Unlock()
+
+	t.publish(userID, lists)
+}
+
+// mergeLists adds the lists missing from merged, keeping their order.
+func mergeLists(merged, lists []string) []string {
+	for _, list := range lists {
+		found := false
+		for _, m := range merged {
+			if m == list {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, list)
+		}
+	}
+	return merged
+}
+
+func (p *Plugin) sendRefreshEvent(userID string, lists []string) {
+	p.refreshThrottle.send(userID, lists)
+}
+
+// publishRefreshEvent tells the webapp of userID to reload lists.
+func (p *Plugin) publishRefreshEvent(userID string, lists []string) {
+	p.API.PublishWebSocketEvent(
+		WSEventRefresh,
+		map[string]interface{}{"lists": lists},
+		&model.WebsocketBroadcast{UserId: userID},
+	)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshThrottleBoundsBursts(t *testing.T) {
+	var mutex sync.Mutex
+	published := []testRefreshEvent{}
+	throttle := newRefreshThrottle(2, 50*time.Millisecond, func(userID string, lists []string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		published = append(published, testRefreshEvent{userID, lists})
+	})
+	events := func() []testRefreshEvent {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]testRefreshEvent{}, published...)
+	}
+
+	for i := 0; i < 50; i++ {
+		throttle.send("alice", []string{MyListKey})
+		throttle.send("alice", []string{InListKey, MyListKey})
+	}
+	throttle.send("bob", []string{OutListKey})
+
+	assert.Equal(t, []testRefreshEvent{
+		{"alice", []string{MyListKey}},
+		{"alice", []string{InListKey, MyListKey}},
+		{"bob", []string{OutListKey}},
+	}, events(), "each user gets their own limit")
+
+	// The rest of the burst is merged into a single trailing refresh
+	require.Eventually(t, func() bool { return len(events()) == 4 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, testRefreshEvent{"alice", []string{MyListKey, InListKey}}, events()[3])
+	time.Sleep(100 * time.Millisecond)
+	assert.Len(t, events(), 4)
+
+	// Once the window is over, events are sent right away again
+	throttle.send("alice", []string{OutListKey})
+	assert.Len(t, events(), 5)
+}
+
+func TestRefreshThrottlePrunesUsers(t *testing.T) {
+	throttle := newRefreshThrottle(1, 20*time.Millisecond, func(userID string, lists []string) {})
+
+	throttle.send("alice", []string{MyListKey})
+	throttle.send("bob", []string{MyListKey})
+	time.Sleep(50 * time.Millisecond)
+
+	// Users whose window is over are forgotten when another one is tracked
+	throttle.send("carol", []string{MyListKey})
+	throttle.mutex.Lock()
+	defer throttle.mutex.Unlock()
+	assert.Len(t, throttle.users, 1)
+	assert.Contains(t, throttle.users, "carol")
+}
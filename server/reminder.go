@@ -0,0 +1,135 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+const (
+	// ReminderJobInterval is how often the reminder job checks for the daily reminders that are due
+	ReminderJobInterval = 15 * time.Minute
+	// ReminderJobHour is the local hour from which the reminder job sends the daily reminder of the day
+	ReminderJobHour = 9
+)
+
+func (p *Plugin) sendDailyReminders() {
+	if err := p.sendDueDailyReminders(model.GetMillis()); err != nil {
+		p.API.LogError("Unable to send daily reminders", "error", err.Error())
+	}
+}
+
+// backfillReminderUsers registers the users who had Todos before the reminder job kept track of the users to remind,
+// the first time the plugin is activated since then.
+func (p *Plugin) backfillReminderUsers() error {
+	backfilled, appErr := p.API.KVGet(StoreReminderUsersBackfilledKey)
+	if appErr != nil {
+		return appErr
+	}
+	if backfilled != nil {
+		return nil
+	}
+
+	keys, err := listAllKeys(p.API)
+	if err != nil {
+		return err
+	}
+
+	// The users with nothing left on their list are unregistered by the reminder job
+	if err = registerReminderUsers(p.API, reportUserIDs(keys)); err != nil {
+		return err
+	}
+
+	appErr = p.API.KVSet(StoreReminderUsersBackfilledKey, []byte("true"))
+	if appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// sendDueDailyReminders sends the daily reminder due at now to the registered users, from ReminderJobHour of their
+// day in the time zone of their profile. Users who fetched their list earlier that day already got it the same way.
+// Users who were removed or have nothing left on their list are unregistered, until they get a new Todo.
+func (p *Plugin) sendDueDailyReminders(now int64) error {
+	userIDs, _, err := getReminderUsers(p.API)
+	if err != nil {
+		return err
+	}
+
+	unregistered := []string{}
+	for _, userID := range userIDs {
+		user, appErr := p.API.GetUser(userID)
+		if appErr != nil {
+			if appErr.StatusCode == http.StatusNotFound {
+				unregistered = append(unregistered, userID)
+			} else {
+				p.API.LogError("Unable to get user for the daily reminder err=" + appErr.Error())
+			}
+			continue
+		}
+		if user.DeleteAt != 0 {
+			unregistered = append(unregistered, userID)
+			continue
+		}
+
+		timezone := userTimezone(user)
+		if time.Unix(now/1000, 0).In(timezone).Hour() < ReminderJobHour || !p.getReminderPreference(userID) {
+			continue
+		}
+
+		lastReminderAt, err := p.getLastReminderTimeForUser(userID)
+		if err != nil {
+			p.API.LogError("Unable to get last reminder for user err=" + err.Error())
+			continue
+		}
+
+		snoozedUntil, err := p.getReminderSnoozedUntil(userID)
+		if err != nil {
+			p.API.LogError("Unable to get reminder snooze, err=" + err.Error())
+		}
+
+		if !shouldRemind(now, lastReminderAt, snoozedUntil, timezone) {
+			continue
+		}
+
+		issues, err := p.listManager.GetIssueList(userID, MyListKey)
+		if err != nil {
+			p.API.LogError("Unable to get issues for user err=" + err.Error())
+			continue
+		}
+		if len(issues) == 0 {
+			unregistered = append(unregistered, userID)
+			continue
+		}
+
+		claimed, err := p.claimDailyReminder(userID, lastReminderAt, now)
+		if err != nil {
+			p.API.LogError("Unable to save last reminder for user err=" + err.Error())
+			continue
+		}
+		if claimed {
+			p.sendDailyReminder(userID, issues)
+		}
+	}
+
+	return unregisterReminderUsers(p.API, unregistered)
+}
+
+// userTimezone returns the time zone of the profile of user, UTC if it has none.
+func userTimezone(user *model.User) *time.Location {
+	timezone, err := time.LoadLocation(user.GetPreferredTimezone())
+	if err != nil {
+		return time.UTC
+	}
+	return timezone
+}
+
+// getUserTimezone returns the time zone of the profile of userID, UTC if it has none or the user cannot be found.
+func (p *Plugin) getUserTimezone(userID string) *time.Location {
+	user, appErr := p.API.GetUser(userID)
+	if appErr != nil {
+		return time.UTC
+	}
+	return userTimezone(user)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setTimezoneAtHour sets the profile time zone of user to one where it is hour at now.
+func setTimezoneAtHour(user *model.User, now int64, hour int) {
+	diff := (hour - time.Unix(now/1000, 0).UTC().Hour() + 24) % 24
+	if diff > 12 {
+		diff -= 24
+	}
+
+	// The Etc zones are named after the offset to get back to UTC
+	name := "Etc/UTC"
+	if diff > 0 {
+		name = fmt.Sprintf("Etc/GMT-%d", diff)
+	} else if diff < 0 {
+		name = fmt.Sprintf("Etc/GMT+%d", -diff)
+	}
+	user.Timezone = model.StringMap{"useAutomaticTimezone": "false", "manualTimezone": name}
+}
+
+func TestSendDueDailyReminders(t *testing.T) {
+	env := newTestEnv()
+	alice := env.addUser("alice", "alice")
+	p := env.newPlugin()
+	now := model.GetMillis()
+
+	// Fetching the list does not register the user, getting a Todo does
+	w := env.serve(p, "alice", http.MethodGet, "/list?reminder=true", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	userIDs, _, err := getReminderUsers(p.API)
+	require.NoError(t, err)
+	assert.Empty(t, userIDs)
+
+	_, err = p.listManager.AddIssue("alice", "water the plants", "", "", IssueFields{})
+	require.NoError(t, err)
+	_, err = p.listManager.AddIssue("alice", "call the bank", "", "", IssueFields{})
+	require.NoError(t, err)
+	userIDs, _, err = getReminderUsers(p.API)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice"}, userIDs)
+
+	// Nothing is sent before ReminderJobHour of the user's day
+	setTimezoneAtHour(alice, now, ReminderJobHour-1)
+	require.NoError(t, p.sendDueDailyReminders(now))
+	assert.Empty(t, env.postsTo("dm_alice"))
+
+	setTimezoneAtHour(alice, now, ReminderJobHour+1)
+	require.NoError(t, p.sendDueDailyReminders(now))
+	posts := env.postsTo("dm_alice")
+	require.Len(t, posts, 1)
+	assert.Contains(t, posts[0].Message, "water the plants")
+
+	// The reminder is sent once per day, whichever way it is sent
+	require.NoError(t, p.sendDueDailyReminders(now))
+	w = env.serve(p, "alice", http.MethodGet, "/list?reminder=true", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Len(t, env.postsTo("dm_alice"), 1)
+}
+
+func TestSendDueDailyRemindersAfterFetch(t *testing.T) {
+	env := newTestEnv()
+	alice := env.addUser("alice", "alice")
+	bob := env.addUser("bob", "bob")
+	p := env.newPlugin()
+	now := model.GetMillis()
+	setTimezoneAtHour(alice, now, ReminderJobHour+1)
+	setTimezoneAtHour(bob, now, ReminderJobHour+1)
+
+	_, err := p.listManager.AddIssue("alice", "water the plants", "", "", IssueFields{})
+	require.NoError(t, err)
+	w := env.serve(p, "alice", http.MethodGet, "/list?reminder=true", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Len(t, env.postsTo("dm_alice"), 1)
+
+	require.NoError(t, p.sendDueDailyReminders(now))
+	assert.Len(t, env.postsTo("dm_alice"), 1, "users who fetched their list already got the reminder")
+
+	require.NoError(t, p.saveReminderPreference("bob", false))
+	_, err = p.listManager.AddIssue("bob", "call the bank", "", "", IssueFields{})
+	require.NoError(t, err)
+	require.NoError(t, p.sendDueDailyReminders(now))
+	assert.Empty(t, env.postsTo("dm_bob"), "users who turned the reminders off are left alone")
+}
+
+func TestSendDueDailyRemindersUnregistersUsers(t *testing.T) {
+	env := newTestEnv()
+	alice := env.addUser("alice", "alice")
+	bob := env.addUser("bob", "bob")
+	carol := env.addUser("carol", "carol")
+	p := env.newPlugin()
+	now := model.GetMillis()
+	for _, user := range []*model.User{alice, bob, carol} {
+		setTimezoneAtHour(user, now, ReminderJobHour+1)
+	}
+
+	// Receiving a Todo registers the receiver, and accepting it keeps them registered
+	receivedID, err := p.listManager.SendIssue("bob", "alice", "review", "", "", IssueFields{})
+	require.NoError(t, err)
+	_, _, err = p.listManager.AcceptIssue("alice", receivedID)
+	require.NoError(t, err)
+	issue, err := p.listManager.AddIssue("bob", "done soon", "", "", IssueFields{})
+	require.NoError(t, err)
+	_, err = p.listManager.AddIssue("carol", "leaving", "", "", IssueFields{})
+	require.NoError(t, err)
+	userIDs, _, err := getReminderUsers(p.API)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"alice", "bob", "carol"}, userIDs)
+
+	// Users with nothing left on their list and removed users are not kept around
+	_, _, _, err = p.listManager.CompleteIssue("bob", issue.ID)
+	require.NoError(t, err)
+	delete(env.users, "carol")
+	require.NoError(t, p.sendDueDailyReminders(now))
+	assert.Len(t, env.postsTo("dm_alice"), 1)
+	assert.Empty(t, env.postsTo("dm_bob"))
+	userIDs, _, err = getReminderUsers(p.API)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice"}, userIDs)
+
+	// Getting a Todo again registers them again
+	_, err = p.listManager.AddIssue("bob", "next one", "", "", IssueFields{})
+	require.NoError(t, err)
+	userIDs, _, err = getReminderUsers(p.API)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, userIDs)
+}
+
+func TestClaimDailyReminder(t *testing.T) {
+	env := newTestEnv()
+	p := env.newPlugin()
+	now := model.GetMillis()
+
+	claimed, err := p.claimDailyReminder("alice", 0, now)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	// Whoever checked before the reminder was sent cannot send it again
+	claimed, err = p.claimDailyReminder("alice", 0, now+1)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	lastReminderAt, err := p.getLastReminderTimeForUser("alice")
+	require.NoError(t, err)
+	assert.Equal(t, now, lastReminderAt)
+}
+
+func TestSendDueDailyRemindersAfterSomeday(t *testing.T) {
+	env := newTestEnv()
+	alice := env.addUser("alice", "alice")
+	p := env.newPlugin()
+	now := model.GetMillis()
+	setTimezoneAtHour(alice, now, ReminderJobHour+1)
+
+	issue, err := p.listManager.AddIssue("alice", "learn the piano", "", "", IssueFields{})
+	require.NoError(t, err)
+	require.NoError(t, p.listManager.SetSomeday("alice", issue.ID, true))
+	require.NoError(t, p.sendDueDailyReminders(now))
+	userIDs, _, err := getReminderUsers(p.API)
+	require.NoError(t, err)
+	assert.Empty(t, userIDs, "users with only someday Todos are not reminded")
+
+	// Bringing a Todo back from someday registers the user again
+	require.NoError(t, p.listManager.SetSomeday("alice", issue.ID, false))
+	userIDs, _, err = getReminderUsers(p.API)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice"}, userIDs)
+	require.NoError(t, p.sendDueDailyReminders(now))
+	assert.Len(t, env.postsTo("dm_alice"), 1)
+}
+
+func TestBackfillReminderUsers(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+
+	// Users who had Todos before the reminder users were kept are not registered
+	_, err := p.listManager.AddIssue("alice", "water the plants", "", "", IssueFields{})
+	require.NoError(t, err)
+	_, err = p.listManager.SendIssue("alice", "bob", "call the bank", "", "", IssueFields{})
+	require.NoError(t, err)
+	delete(env.kv, StoreReminderUsersKey)
+
+	require.NoError(t, p.backfillReminderUsers())
+	userIDs, _, err := getReminderUsers(p.API)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"alice", "bob"}, userIDs)
+
+	// The backfill only runs once
+	delete(env.kv, StoreReminderUsersKey)
+	require.NoError(t, p.backfillReminderUsers())
+	userIDs, _, err = getReminderUsers(p.API)
+	require.NoError(t, err)
+	assert.Empty(t, userIDs)
+}
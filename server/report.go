@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+// reportListSizeBuckets and reportAgeBuckets are the upper bounds of the buckets of the usage report
+// distributions. The values past the last bound fall in a last open bucket.
+var (
+	reportListSizeBuckets = []int{0, 5, 10, 25}
+	reportAgeBuckets      = []int{1, 7, 30}
+)
+
+// usageReport is an aggregate of how the plugin is used. It holds counts only, nothing in it identifies a user
+// or tells what their todos are about.
+type usageReport struct {
+	// Users is the number of users with at least one todo on one of their lists
+	Users int `json:"users"`
+	// Lists is the number of todos on each list, all users included
+	Lists map[string]int `json:"lists"`
+	// Starred, Overdue and WithDueDate count the todos of all the lists
+	Starred     int `json:"starred"`
+	Overdue     int `json:"overdue"`
+	WithDueDate int `json:"with_due_date"`
+	// ListSizes is how many users have that many todos on their list
+	ListSizes map[string]int `json:"list_sizes"`
+	// Ages is how many todos of the users lists are that many days old
+	Ages map[string]int `json:"ages"`
+}
+
+func newUsageReport() *usageReport {
+	return &usageReport{
+		Lists:     map[string]int{MyFlag: 0, InFlag: 0, OutFlag: 0, SomedayFlag: 0},
+		ListSizes: map[string]int{},
+		Ages:      map[string]int{},
+	}
+}
+
+// reportBucket names the bucket of bounds value falls in, like "0", "1-5" or "26+".
+func reportBucket(bounds []int, value int) string {
+	lower := 0
+	for _, upper := range bounds {
+		if value <= upper {
+			if lower == upper {
+				return strconv.Itoa(upper)
+			}
+			return fmt.Sprintf("%d-%d", lower, upper)
+		}
+		lower = upper + 1
+	}
+	return fmt.Sprintf("%d+", lower)
+}
+
+// addUser adds the lists of a user to the report at now. lists holds the issues of each of the my, in, out and
+// someday lists, by their flag.
+func (r *usageReport) addUser(lists map[string][]*Issue, now int64) {
+	active := false
+	for flag, issues := range lists {
+		r.Lists[flag] += len(issues)
+		if len(issues) > 0 {
+			active = true
+		}
+
+		for _, issue := range issues {
+			if issue.Starred {
+				r.Starred++
+			}
+			if issue.DueAt > 0 {
+				r.WithDueDate++
+				if issue.DueAt < now {
+					r.Overdue++
+				}
+			}
+		}
+	}
+	if !active {
+		return
+	}
+
+	r.Users++
+	r.ListSizes[reportBucket(reportListSizeBuckets, len(lists[MyFlag]))]++
+	for _, issue := range lists[MyFlag] {
+		r.Ages[reportBucket(reportAgeBuckets, issue.ageDays(now))]++
+	}
+}
+
+// reportUserIDs returns the IDs of the users with a stored list among keys, each once.
+func reportUserIDs(keys []string) []string {
+	userIDs := []string{}
+	seen := map[string]bool{}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, StoreListKey+"_") {
+			continue
+		}
+
+		// User IDs have no underscores, the list IDs all start with one
+		userID := strings.SplitN(strings.TrimPrefix(key, StoreListKey+"_"), "_", 2)[0]
+		if userID != "" && !seen[userID] {
+			seen[userID] = true
+			userIDs = append(userIDs, userID)
+		}
+	}
+	return userIDs
+}
+
+// getUsageReport builds the usage report at now from the lists of all the users in the KV store.
+func (p *Plugin) getUsageReport(now int64) (*usageReport, error) {
+	keys, err := listAllKeys(p.API)
+	if err != nil {
+		return nil, err
+	}
+
+	report := newUsageReport()
+	for _, userID := range reportUserIDs(keys) {
+		lists := map[string][]*Issue{}
+		for flag, listID := range map[string]string{MyFlag: MyListKey, InFlag: InListKey, OutFlag: OutListKey, SomedayFlag: SomedayListKey} {
+			issues, err := p.listManager.GetStoredIssues(userID, listID)
+			if err != nil {
+				return nil, err
+			}
+			lists[flag] = issues
+		}
+		report.addUser(lists, now)
+	}
+
+	return report, nil
+}
+
+// API endpoint to get the anonymized usage report, for system admins
+func (p *Plugin) handleAdminReport(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !p.API.HasPermissionTo(userID, model.PERMISSION_MANAGE_SYSTEM) {
+		p.handleErrorWithCode(w, http.StatusForbidden, "Unable to get the usage report", errors.New("only system admins can get the usage report"))
+		return
+	}
+
+	report, err := p.getUsageReport(model.GetMillis())
+	if err != nil {
+		p.API.LogError("Unable to build the usage report err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to get the usage report", err)
+		return
+	}
+
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		p.API.LogError("Unable marhsal usage report to json err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable marhsal usage report to json", err)
+		return
+	}
+
+	_, err = w.Write(reportJSON)
+	if err != nil {
+		p.API.LogError("Unable to write json response err=" + err.Error())
+	}
+}
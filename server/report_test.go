@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportBucket(t *testing.T) {
+	for value, want := range map[int]string{0: "0", 1: "1-5", 5: "1-5", 6: "6-10", 25: "11-25", 26: "26+", 300: "26+"} {
+		assert.Equal(t, want, reportBucket(reportListSizeBuckets, value), value)
+	}
+	assert.Equal(t, "0-1", reportBucket(reportAgeBuckets, 0))
+	assert.Equal(t, "31+", reportBucket(reportAgeBuckets, 45))
+}
+
+func TestReportUserIDs(t *testing.T) {
+	keys := []string{"order_alice", "order_alice_in", "order_bob_someday", "item_x", "reminder_users", "order_carol_out"}
+	assert.Equal(t, []string{"alice", "bob", "carol"}, reportUserIDs(keys))
+}
+
+func TestHandleAdminReport(t *testing.T) {
+	env := newTestEnv()
+	admin := env.addUser(model.NewId(), "admin")
+	alice := env.addUser(model.NewId(), "alice")
+	bob := env.addUser(model.NewId(), "bob")
+	env.api.On("HasPermissionTo", admin.Id, model.PERMISSION_MANAGE_SYSTEM).Return(true)
+	env.api.On("HasPermissionTo", alice.Id, model.PERMISSION_MANAGE_SYSTEM).Return(false)
+	p := env.newPlugin()
+
+	now := model.GetMillis()
+	late, err := p.listManager.AddIssue(alice.Id, "renew the secret domain", "", "", IssueFields{DueAt: now - time.Hour.Milliseconds()})
+	require.NoError(t, err)
+	_, err = p.listManager.StarIssue(alice.Id, late.ID)
+	require.NoError(t, err)
+	_, err = p.listManager.AddIssue(alice.Id, "water the plants", "", "", IssueFields{DueAt: now + time.Hour.Milliseconds()})
+	require.NoError(t, err)
+	_, err = p.listManager.SendIssue(alice.Id, bob.Id, "review the budget", "", "", IssueFields{})
+	require.NoError(t, err)
+
+	w := env.serve(p, alice.Id, http.MethodGet, "/admin/report", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = env.serve(p, admin.Id, http.MethodGet, "/admin/report", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var report *usageReport
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, 2, report.Users)
+	assert.Equal(t, map[string]int{MyFlag: 2, InFlag: 1, OutFlag: 1, SomedayFlag: 0}, report.Lists)
+	assert.Equal(t, 1, report.Starred)
+	assert.Equal(t, 2, report.WithDueDate)
+	assert.Equal(t, 1, report.Overdue)
+	assert.Equal(t, map[string]int{"0": 1, "1-5": 1}, report.ListSizes)
+	assert.Equal(t, map[string]int{"0-1": 2}, report.Ages)
+
+	// Nothing in the report tells who the users are or what their todos are about
+	body := w.Body.String()
+	for _, secret := range []string{alice.Id, bob.Id, "alice", "bob", "secret", "plants", "budget"} {
+		assert.NotContains(t, body, secret)
+	}
+}
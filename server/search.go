@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+// searchQuery is a parsed /todo search query. An issue matches when it passes every filter and contains every word.
+type searchQuery struct {
+	Starred bool
+	Overdue bool
+	Waiting bool
+	Role    string
+	// Priority is only used when HasPriority is set, since it is zero for normal
+	Priority    int
+	HasPriority bool
+	Words       []string
+}
+
+var searchFilterRegexp = regexp.MustCompile(`^(is|role|priority):(\S+)$`)
+
+// parseSearchQuery parses the tokens of queries like `is:overdue priority:high review`, where is can be starred,
+// overdue or waiting. The tokens that are not valid filters are searched as text.
+func parseSearchQuery(tokens []string) *searchQuery {
+	query := &searchQuery{}
+	for _, token := range tokens {
+		if token == "" {
+			continue
+		}
+
+		match := searchFilterRegexp.FindStringSubmatch(strings.ToLower(token))
+		if match != nil {
+			switch {
+			case match[1] == "is" && match[2] == "starred":
+				query.Starred = true
+				continue
+			case match[1] == "is" && match[2] == "overdue":
+				query.Overdue = true
+				continue
+			case match[1] == "is" && match[2] == "waiting":
+				query.Waiting = true
+				continue
+			case match[1] == "role" && roleRegexp.MatchString(match[2]):
+				query.Role = match[2]
+				continue
+			case match[1] == "priority":
+				if priority, err := parsePriority(match[2]); err == nil {
+					query.Priority = priority
+					query.HasPriority = true
+					continue
+				}
+			}
+		}
+
+		query.Words = append(query.Words, strings.ToLower(token))
+	}
+
+	return query
+}
+
+// matches checks whether issue matches the query at now. The words are looked for in the message and description.
+func (q *searchQuery) matches(issue *ExtendedIssue, now int64) bool {
+	if q.Starred && !issue.Starred {
+		return false
+	}
+	if q.Overdue && (issue.DueAt == 0 || issue.DueAt > now) {
+		return false
+	}
+	if q.Waiting && issue.WaitingOn == "" {
+		return false
+	}
+	if q.Role != "" && issue.Role != q.Role {
+		return false
+	}
+	if q.HasPriority && issue.Priority != q.Priority {
+		return false
+	}
+
+	text := strings.ToLower(issue.Message + "\n" + issue.Description)
+	for _, word := range q.Words {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p *Plugin) runSearchCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	queryText := strings.TrimSpace(strings.Join(args, " "))
+	if queryText == "" {
+		return true, errors.New("missing the search query")
+	}
+
+	query := parseSearchQuery(args)
+	now := model.GetMillis()
+	groups, err := p.findIssues(extra.UserId, func(issue *ExtendedIssue) bool {
+		return query.matches(issue, now)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if len(groups) == 0 {
+		p.postCommandResponse(extra, fmt.Sprintf("None of your Todos match `%s`.", queryText))
+		return false, nil
+	}
+
+	p.postCommandResponse(extra, fmt.Sprintf("Todos matching `%s`:\n", queryText)+issueGroupsToString(groups))
+	return false, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  *searchQuery
+	}{
+		{name: "Words only", query: "review the Docs", want: &searchQuery{Words: []string{"review", "the", "docs"}}},
+		{
+			name:  "Filters and words",
+			query: "is:overdue IS:Starred role:QA review",
+			want:  &searchQuery{Overdue: true, Starred: true, Role: "qa", Words: []string{"review"}},
+		},
+		{name: "Waiting", query: "is:waiting", want: &searchQuery{Waiting: true}},
+		{
+			name:  "Invalid filters are text",
+			query: "is:done priority:urgent #backend role:",
+			want:  &searchQuery{Words: []string{"is:done", "priority:urgent", "#backend", "role:"}},
+		},
+		{name: "Priority", query: "priority:High", want: &searchQuery{Priority: PriorityHigh, HasPriority: true}},
+		{name: "Normal priority", query: "priority:normal", want: &searchQuery{Priority: PriorityNormal, HasPriority: true}},
+		{name: "Empty tokens", query: "  review  ", want: &searchQuery{Words: []string{"review"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseSearchQuery(strings.Split(tt.query, " ")))
+		})
+	}
+}
+
+func TestSearchCommand(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+	extra := &model.CommandArgs{UserId: "alice"}
+
+	now := model.GetMillis()
+	_, err := p.listManager.AddIssue("alice", "review the backend PR", "", "", IssueFields{DueAt: now - time.Hour.Milliseconds(), Role: "qa"})
+	require.NoError(t, err)
+	_, err = p.listManager.AddIssue("alice", "review the frontend PR", "", "", IssueFields{DueAt: now + time.Hour.Milliseconds()})
+	require.NoError(t, err)
+	_, err = p.listManager.AddIssue("alice", "lunch", "", "", IssueFields{})
+	require.NoError(t, err)
+	_, err = p.listManager.SendIssue("alice", "bob", "update the docs", "Needs a review of the backend.", "", IssueFields{})
+	require.NoError(t, err)
+
+	_, err = p.runSearchCommand([]string{"review"}, extra)
+	require.NoError(t, err)
+	response := env.lastEphemeral()
+	assert.Contains(t, response, "Todos matching `review`:")
+	assert.Contains(t, response, "#### Your list\n\n1. review the backend PR")
+	assert.Contains(t, response, "2. review the frontend PR")
+	assert.Contains(t, response, "#### Sent\n\n1. update the docs")
+	assert.NotContains(t, response, "lunch")
+
+	_, err = p.runSearchCommand([]string{"is:overdue", "role:qa", "Review"}, extra)
+	require.NoError(t, err)
+	response = env.lastEphemeral()
+	assert.Contains(t, response, "1. review the backend PR")
+	assert.NotContains(t, response, "frontend")
+	assert.NotContains(t, response, "docs")
+
+	_, err = p.runSearchCommand([]string{"is:overdue", "frontend"}, extra)
+	require.NoError(t, err)
+	assert.Equal(t, "None of your Todos match `is:overdue frontend`.", env.lastEphemeral())
+
+	_, err = p.runSearchCommand([]string{"priority:high"}, extra)
+	require.NoError(t, err)
+	assert.Equal(t, "None of your Todos match `priority:high`.", env.lastEphemeral())
+
+	_, err = p.runSearchCommand([]string{"priority:normal", "frontend"}, extra)
+	require.NoError(t, err)
+	assert.Contains(t, env.lastEphemeral(), "review the frontend PR")
+
+	isUserError, err := p.runSearchCommand(nil, extra)
+	assert.Error(t, err)
+	assert.True(t, isUserError)
+}
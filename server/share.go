@@ -0,0 +1,49 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+)
+
+// sharedIssue is the shareable payload of an issue, signed so that changes made after sharing it
+// can be detected when it is imported.
+type sharedIssue struct {
+	Message     string `json:"message"`
+	Description string `json:"description,omitempty"`
+	SharedBy    string `json:"shared_by"`
+	SharedAt    int64  `json:"shared_at"`
+	Signature   string `json:"signature,omitempty"`
+}
+
+func (s *sharedIssue) computeSignature(secret []byte) (string, error) {
+	unsigned := *s
+	unsigned.Signature = ""
+	payload, err := json.Marshal(unsigned)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(payload)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func (s *sharedIssue) sign(secret []byte) error {
+	signature, err := s.computeSignature(secret)
+	if err != nil {
+		return err
+	}
+	s.Signature = signature
+	return nil
+}
+
+// verify reports whether the payload is unchanged since it was signed with secret.
+func (s *sharedIssue) verify(secret []byte) bool {
+	signature, err := s.computeSignature(secret)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(s.Signature))
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleShare(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	p := env.newPlugin()
+
+	issue, err := p.listManager.AddIssue("alice", "Write the report", "Quarterly numbers", "", IssueFields{})
+	require.NoError(t, err)
+
+	w := env.serve(p, "alice", http.MethodGet, "/issue/share?id="+issue.ID, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var shared sharedIssue
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &shared))
+	assert.Equal(t, "Write the report", shared.Message)
+	assert.Equal(t, "Quarterly numbers", shared.Description)
+	assert.Equal(t, "alice", shared.SharedBy)
+	assert.NotZero(t, shared.SharedAt)
+	assert.NotEmpty(t, shared.Signature)
+
+	secret, err := p.getShareSecret()
+	require.NoError(t, err)
+	assert.True(t, shared.verify(secret))
+
+	tampered := shared
+	tampered.Message = "Skip the report"
+	assert.False(t, tampered.verify(secret))
+	assert.False(t, shared.verify([]byte("another secret")))
+
+	// Only the user's own issues can be shared
+	w = env.serve(p, "bob", http.MethodGet, "/issue/share?id="+issue.ID, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandleImport(t *testing.T) {
+	env := newTestEnv()
+	env.addUser("alice", "alice")
+	env.addUser("bob", "bob")
+	p := env.newPlugin()
+
+	issue, err := p.listManager.AddIssue("alice", "Write the report", "Quarterly numbers", "", IssueFields{})
+	require.NoError(t, err)
+	w := env.serve(p, "alice", http.MethodGet, "/issue/share?id="+issue.ID, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var shared sharedIssue
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &shared))
+
+	// Payloads changed after they were shared are refused
+	tampered := shared
+	tampered.Message = "Skip the report"
+	w = env.serve(p, "bob", http.MethodPost, "/issue/import", tampered)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = env.serve(p, "bob", http.MethodPost, "/issue/import", shared)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	issues, err := p.listManager.GetIssueList("bob", MyListKey)
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	assert.Equal(t, "Write the report", issues[0].Message)
+	assert.Equal(t, "Quarterly numbers", issues[0].Description)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+// DefaultStaleDays is the age in days from which a todo of the my list is stale, when not given
+const DefaultStaleDays = 14
+
+// staleIssues keeps the issues that are at least days old at now, with their positions on the list.
+func staleIssues(issues []*ExtendedIssue, days int, now int64) *issueGroup {
+	return filterIssueGroup(issues, fmt.Sprintf("Older than %d days", days), func(issue *ExtendedIssue) bool {
+		return issue.ageDays(now) >= days
+	})
+}
+
+// staleIssuesToString renders the stale issues with how old each one is at now.
+func staleIssuesToString(group *issueGroup, now int64) string {
+	str := fmt.Sprintf("\n#### %s\n\n", group.Name)
+	for i, issue := range group.Issues {
+		str += issueListItemToString(group.Positions[i], issue, false)
+		str += fmt.Sprintf("   * %d days old\n", issue.ageDays(now))
+	}
+	return str
+}
+
+func (p *Plugin) runStaleCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	if len(args) > 1 {
+		return true, errors.New("too many arguments")
+	}
+
+	days := DefaultStaleDays
+	if len(args) == 1 {
+		var err error
+		days, err = strconv.Atoi(args[0])
+		if err != nil || days < 1 {
+			return true, fmt.Errorf("`%s` is not a valid number of days", args[0])
+		}
+	}
+
+	issues, err := p.listManager.GetIssueList(extra.UserId, MyListKey)
+	if err != nil {
+		return false, err
+	}
+
+	now := model.GetMillis()
+	group := staleIssues(issues, days, now)
+	if group == nil {
+		p.postCommandResponse(extra, fmt.Sprintf("There are no Todos on your list older than %d days.", days))
+		return false, nil
+	}
+
+	p.postCommandResponse(extra, "Stale Todos, consider completing or removing them:\n"+staleIssuesToString(group, now))
+	return false, nil
+}
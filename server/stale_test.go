@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaleIssues(t *testing.T) {
+	day := int64(24 * time.Hour / time.Millisecond)
+	now := 100 * day
+	issues := []*ExtendedIssue{
+		{Issue: Issue{Message: "ancient", CreateAt: now - 40*day}},
+		{Issue: Issue{Message: "fresh", CreateAt: now - day}},
+		{Issue: Issue{Message: "two weeks", CreateAt: now - 14*day}},
+	}
+
+	group := staleIssues(issues, DefaultStaleDays, now)
+	require.NotNil(t, group)
+	require.Len(t, group.Issues, 2)
+	assert.Equal(t, "ancient", group.Issues[0].Message)
+	assert.Equal(t, "two weeks", group.Issues[1].Message)
+	assert.Equal(t, []int{1, 3}, group.Positions)
+
+	rendered := staleIssuesToString(group, now)
+	assert.Contains(t, rendered, "* 40 days old\n")
+	assert.Contains(t, rendered, "* 14 days old\n")
+
+	group = staleIssues(issues, 30, now)
+	require.NotNil(t, group)
+	assert.Len(t, group.Issues, 1)
+
+	assert.Nil(t, staleIssues(issues, 60, now))
+}
+
+func TestStaleCommand(t *testing.T) {
+	env := newTestEnv()
+	p := env.newPlugin()
+
+	_, err := p.listManager.AddIssue("alice", "fresh", "", "", IssueFields{})
+	require.NoError(t, err)
+
+	_, err = p.runStaleCommand(nil, &model.CommandArgs{UserId: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, "There are no Todos on your list older than 14 days.", env.lastEphemeral())
+
+	for _, args := range [][]string{{"0"}, {"soon"}, {"1", "2"}} {
+		isUserError, err := p.runStaleCommand(args, &model.CommandArgs{UserId: "alice"})
+		assert.Error(t, err, args)
+		assert.True(t, isUserError, args)
+	}
+}
@@ -13,17 +13,74 @@ import (
 const (
 	// StoreRetries is the number of retries to use when storing lists fails on a race
 	StoreRetries = 3
+	// StoreKeysPerPage is the number of KV keys fetched at once when going through all the keys
+	StoreKeysPerPage = 1000
 	// StoreListKey is the key used to store lists in the plugin KV store. Still "order" for backwards compatibility.
 	StoreListKey = "order"
 	// StoreIssueKey is the key used to store issues in the plugin KV store. Still "item" for backwards compatibility.
 	StoreIssueKey = "item"
 	// StoreReminderKey is the key used to store the last time a user was reminded
 	StoreReminderKey = "reminder"
+	// StoreReminderIssuesKey is the key used to store the issues listed on the last reminder of a user, in order
+	StoreReminderIssuesKey = "reminder_issues"
 	// StoreReminderEnabledKey is the key used to store the user preference of auto daily reminder
 	StoreReminderEnabledKey = "reminder_enabled"
 
 	// StoreAllowIncomingTaskRequestsKey is the key used to store user preference for wallowing any incoming todo requests
 	StoreAllowIncomingTaskRequestsKey = "allow_incoming_task"
+
+	// StoreReminderDescriptionsKey is the key used to store the user preference of including descriptions in the daily reminder
+	StoreReminderDescriptionsKey = "reminder_descriptions"
+	// StoreReminderHeaderKey is the key used to store the user preference of the text the daily reminder starts with
+	StoreReminderHeaderKey = "reminder_header"
+	// StoreKeepCompletedSentKey is the key used to store the user preference of keeping the sent todos completed by their receivers
+	StoreKeepCompletedSentKey = "keep_completed_sent"
+	// StoreTeamDefaultsKey is the key used to store the default preferences of the members of a team
+	StoreTeamDefaultsKey = "team_defaults"
+	// StoreTeamsWithDefaultsKey is the key used to store the teams that set default preferences for their members
+	StoreTeamsWithDefaultsKey = "teams_with_defaults"
+	// StoreShareSecretKey is the key used to store the secret signing shared issues
+	StoreShareSecretKey = "share_secret"
+	// StoreNagsKey is the key used to store the todos that nag their owners
+	StoreNagsKey = "nags"
+	// StoreTriageRemindersKey is the key used to store the users reminded about the todos waiting on their incoming list
+	StoreTriageRemindersKey = "triage_reminders"
+	// StoreReminderUsersKey is the key used to store the users with todos the reminder job sends their daily reminder to
+	StoreReminderUsersKey = "reminder_users"
+	// StoreReminderUsersBackfilledKey is the key used to store that the users with todos from before the reminder
+	// users were kept were registered
+	StoreReminderUsersBackfilledKey = "reminder_users_backfilled"
+	// StoreReminderSnoozedUntilKey is the key used to store the time until which the daily reminder is snoozed
+	StoreReminderSnoozedUntilKey = "reminder_snoozed_until"
+
+	// StoreSelfSendToInboxKey is the key used to store the user preference of receiving the todos they send to themselves on their incoming list
+	StoreSelfSendToInboxKey = "self_send_to_inbox"
+
+	// StorePopUrgentKey is the key used to store the user preference of popping their most urgent todo instead of the top one
+	StorePopUrgentKey = "pop_urgent"
+
+	// StoreShareCountsKey is the key used to store the user preference of sharing how many todos they have with their channels
+	StoreShareCountsKey = "share_counts"
+
+	// StoreFocusKey is the key used to store the notifications held back while the user is in focus mode
+	StoreFocusKey = "focus"
+
+	// StoreNotificationChannelKey is the key used to store the channel the user chose to receive their notifications in instead of the bot DM
+	StoreNotificationChannelKey = "notification_channel"
+
+	// StoreRecentRecipientsKey is the key used to store the users a user sent todos to most recently
+	StoreRecentRecipientsKey = "recent_recipients"
+
+	// StoreInstallAnnouncedKey is the key used to store that the plugin was announced after it was installed
+	StoreInstallAnnouncedKey = "install_announced"
+
+	// StoreDefaultPriorityKey is the key used to store the user preference of the priority of the todos added without one
+	StoreDefaultPriorityKey = "default_priority"
+	// StorePriorityEmojiKey is the key used to store the user preference of the emoji shown before the todos of each priority
+	StorePriorityEmojiKey = "priority_emoji"
+
+	// StoreReceivedOnTopKey is the key used to store the user preference of placing new received todos on top of the inbox
+	StoreReceivedOnTopKey = "received_on_top"
 )
 
 // IssueRef denotes every element in any of the lists. Contains the issue that refers to,
@@ -33,6 +90,8 @@ type IssueRef struct {
 	IssueID        string `json:"issue_id"`
 	ForeignIssueID string `json:"foreign_issue_id"`
 	ForeignUserID  string `json:"foreign_user_id"`
+	// Muted stops the notifications about changes the foreign user makes to the issue
+	Muted bool `json:"muted,omitempty"`
 }
 
 func listKey(userID string, listID string) string {
@@ -47,6 +106,10 @@ func reminderKey(userID string) string {
 	return fmt.Sprintf("%s_%s", StoreReminderKey, userID)
 }
 
+func reminderIssuesKey(userID string) string {
+	return fmt.Sprintf("%s_%s", StoreReminderIssuesKey, userID)
+}
+
 func reminderEnabledKey(userID string) string {
 	return fmt.Sprintf("%s_%s", StoreReminderEnabledKey, userID)
 }
@@ -55,6 +118,62 @@ func allowIncomingTaskRequestsKey(userID string) string {
 	return fmt.Sprintf("%s_%s", StoreAllowIncomingTaskRequestsKey, userID)
 }
 
+func reminderDescriptionsKey(userID string) string {
+	return fmt.Sprintf("%s_%s", StoreReminderDescriptionsKey, userID)
+}
+
+func reminderHeaderKey(userID string) string {
+	return fmt.Sprintf("%s_%s", StoreReminderHeaderKey, userID)
+}
+
+func reminderSnoozedUntilKey(userID string) string {
+	return fmt.Sprintf("%s_%s", StoreReminderSnoozedUntilKey, userID)
+}
+
+func keepCompletedSentKey(userID string) string {
+	return fmt.Sprintf("%s_%s", StoreKeepCompletedSentKey, userID)
+}
+
+func teamDefaultsKey(teamID string) string {
+	return fmt.Sprintf("%s_%s", StoreTeamDefaultsKey, teamID)
+}
+
+func receivedOnTopKey(userID string) string {
+	return fmt.Sprintf("%s_%s", StoreReceivedOnTopKey, userID)
+}
+
+func selfSendToInboxKey(userID string) string {
+	return fmt.Sprintf("%s_%s", StoreSelfSendToInboxKey, userID)
+}
+
+func popUrgentKey(userID string) string {
+	return fmt.Sprintf("%s_%s", StorePopUrgentKey, userID)
+}
+
+func shareCountsKey(userID string) string {
+	return fmt.Sprintf("%s_%s", StoreShareCountsKey, userID)
+}
+
+func defaultPriorityKey(userID string) string {
+	return fmt.Sprintf("%s_%s", StoreDefaultPriorityKey, userID)
+}
+
+func priorityEmojiKey(userID string) string {
+	return fmt.Sprintf("%s_%s", StorePriorityEmojiKey, userID)
+}
+
+func focusKey(userID string) string {
+	return fmt.Sprintf("%s_%s", StoreFocusKey, userID)
+}
+
+func notificationChannelKey(userID string) string {
+	return fmt.Sprintf("%s_%s", StoreNotificationChannelKey, userID)
+}
+
+func recentRecipientsKey(userID string) string {
+	return fmt.Sprintf("%s_%s", StoreRecentRecipientsKey, userID)
+}
+
 type listStore struct {
 	api plugin.API
 }
@@ -146,7 +265,7 @@ func (l *listStore) GetIssueReference(userID, issueID, listID string) (*IssueRef
 			return ir, i, nil
 		}
 	}
-	return nil, 0, errors.New("cannot find issue")
+	return nil, 0, errIssueNotFound
 }
 
 func (l *listStore) GetIssueListAndReference(userID, issueID string) (string, *IssueRef, int) {
@@ -165,6 +284,11 @@ func (l *listStore) GetIssueListAndReference(userID, issueID string) (string, *I
 		return InListKey, ir, n
 	}
 
+	ir, n, _ = l.GetIssueReference(userID, issueID, SomedayListKey)
+	if ir != nil {
+		return SomedayListKey, ir, n
+	}
+
 	return "", nil, 0
 }
 
@@ -202,24 +326,24 @@ func (l *listStore) AddReference(userID, issueID, listID, foreignUserID, foreign
 	return errors.New("unable to store installation")
 }
 
-func (l *listStore) RemoveReference(userID, issueID, listID string) error {
+func (l *listStore) PrependReference(userID, issueID, listID, foreignUserID, foreignIssueID string) error {
 	for i := 0; i < StoreRetries; i++ {
 		list, originalJSONList, err := l.getList(userID, listID)
 		if err != nil {
 			return err
 		}
 
-		found := false
-		for i, ir := range list {
+		for _, ir := range list {
 			if ir.IssueID == issueID {
-				list = append(list[:i], list[i+1:]...)
-				found = true
+				return errors.New("issue id already exists in list")
 			}
 		}
 
-		if !found {
-			return errors.New("cannot find issue")
-		}
+		list = append([]*IssueRef{{
+			IssueID:        issueID,
+			ForeignIssueID: foreignIssueID,
+			ForeignUserID:  foreignUserID,
+		}}, list...)
 
 		ok, err := l.saveList(userID, listID, list, originalJSONList)
 		if err != nil {
@@ -236,33 +360,38 @@ func (l *listStore) RemoveReference(userID, issueID, listID string) error {
 	return errors.New("unable to store list")
 }
 
-func (l *listStore) PopReference(userID, listID string) (*IssueRef, error) {
+func (l *listStore) RemoveReference(userID, issueID, listID string) error {
 	for i := 0; i < StoreRetries; i++ {
 		list, originalJSONList, err := l.getList(userID, listID)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		if len(list) == 0 {
-			return nil, errors.New("cannot find issue")
+		found := false
+		for i, ir := range list {
+			if ir.IssueID == issueID {
+				list = append(list[:i], list[i+1:]...)
+				found = true
+			}
 		}
 
-		ir := list[0]
-		list = list[1:]
+		if !found {
+			return errors.New("cannot find issue")
+		}
 
 		ok, err := l.saveList(userID, listID, list, originalJSONList)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		// If err is nil but ok is false, then something else updated the installs between the get and set above
 		// so we need to try again, otherwise we can return
 		if ok {
-			return ir, nil
+			return nil
 		}
 	}
 
-	return nil, errors.New("unable to store list")
+	return errors.New("unable to store list")
 }
 
 func (l *listStore) BumpReference(userID, issueID, listID string) error {
@@ -303,6 +432,40 @@ func (l *listStore) BumpReference(userID, issueID, listID string) error {
 	return errors.New("unable to store list")
 }
 
+func (l *listStore) MuteReference(userID, issueID, listID string, muted bool) error {
+	for i := 0; i < StoreRetries; i++ {
+		list, originalJSONList, err := l.getList(userID, listID)
+		if err != nil {
+			return err
+		}
+
+		found := false
+		for _, ir := range list {
+			if ir.IssueID == issueID {
+				ir.Muted = muted
+				found = true
+			}
+		}
+
+		if !found {
+			return errors.New("cannot find issue")
+		}
+
+		ok, err := l.saveList(userID, listID, list, originalJSONList)
+		if err != nil {
+			return err
+		}
+
+		// If err is nil but ok is false, then something else updated the installs between the get and set above
+		// so we need to try again, otherwise we can return
+		if ok {
+			return nil
+		}
+	}
+
+	return errors.New("unable to store list")
+}
+
 func (l *listStore) GetList(userID, listID string) ([]*IssueRef, error) {
 	irs, _, err := l.getList(userID, listID)
 	return irs, err
@@ -365,17 +528,101 @@ func (l *listStore) legacyIssueRef(userID, listID string) ([]*IssueRef, []byte,
 	return newList, originalJSONList, nil
 }
 
-func (p *Plugin) saveLastReminderTimeForUser(userID string) error {
-	strTime := strconv.FormatInt(model.GetMillis(), 10)
-	appErr := p.API.KVSet(reminderKey(userID), []byte(strTime))
+func (p *Plugin) getLastReminderTimeForUser(userID string) (int64, error) {
+	timeBytes, appErr := p.API.KVGet(reminderKey(userID))
+	if appErr != nil {
+		return 0, errors.New(appErr.Error())
+	}
+
+	if timeBytes == nil {
+		return 0, nil
+	}
+
+	reminderAt, err := strconv.ParseInt(string(timeBytes), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return reminderAt, nil
+}
+
+// saveRecentRecipients stores the IDs of the users userID sent todos to, the most recent first
+func (p *Plugin) saveRecentRecipients(userID string, recipientIDs []string) error {
+	jsonRecipientIDs, err := json.Marshal(recipientIDs)
+	if err != nil {
+		return err
+	}
+
+	appErr := p.API.KVSet(recentRecipientsKey(userID), jsonRecipientIDs)
 	if appErr != nil {
 		return errors.New(appErr.Error())
 	}
 	return nil
 }
 
-func (p *Plugin) getLastReminderTimeForUser(userID string) (int64, error) {
-	timeBytes, appErr := p.API.KVGet(reminderKey(userID))
+// getRecentRecipients gets the IDs of the users userID sent todos to, the most recent first
+func (p *Plugin) getRecentRecipients(userID string) ([]string, error) {
+	jsonRecipientIDs, appErr := p.API.KVGet(recentRecipientsKey(userID))
+	if appErr != nil {
+		return nil, errors.New(appErr.Error())
+	}
+
+	if jsonRecipientIDs == nil {
+		return []string{}, nil
+	}
+
+	var recipientIDs []string
+	if err := json.Unmarshal(jsonRecipientIDs, &recipientIDs); err != nil {
+		return nil, err
+	}
+
+	return recipientIDs, nil
+}
+
+func (p *Plugin) saveReminderIssues(userID string, issueIDs []string) error {
+	jsonIssueIDs, err := json.Marshal(issueIDs)
+	if err != nil {
+		return err
+	}
+
+	appErr := p.API.KVSet(reminderIssuesKey(userID), jsonIssueIDs)
+	if appErr != nil {
+		return errors.New(appErr.Error())
+	}
+	return nil
+}
+
+// getReminderIssues gets the IDs of the issues listed on the last reminder of the user, in the order they were listed
+func (p *Plugin) getReminderIssues(userID string) ([]string, error) {
+	jsonIssueIDs, appErr := p.API.KVGet(reminderIssuesKey(userID))
+	if appErr != nil {
+		return nil, errors.New(appErr.Error())
+	}
+
+	if jsonIssueIDs == nil {
+		return []string{}, nil
+	}
+
+	var issueIDs []string
+	if err := json.Unmarshal(jsonIssueIDs, &issueIDs); err != nil {
+		return nil, err
+	}
+
+	return issueIDs, nil
+}
+
+func (p *Plugin) saveReminderSnoozedUntil(userID string, snoozedUntil int64) error {
+	strTime := strconv.FormatInt(snoozedUntil, 10)
+	appErr := p.API.KVSet(reminderSnoozedUntilKey(userID), []byte(strTime))
+	if appErr != nil {
+		return errors.New(appErr.Error())
+	}
+	return nil
+}
+
+// getReminderSnoozedUntil gets the time until which the daily reminder of the user is snoozed, or 0 if it is not snoozed
+func (p *Plugin) getReminderSnoozedUntil(userID string) (int64, error) {
+	timeBytes, appErr := p.API.KVGet(reminderSnoozedUntilKey(userID))
 	if appErr != nil {
 		return 0, errors.New(appErr.Error())
 	}
@@ -384,12 +631,12 @@ func (p *Plugin) getLastReminderTimeForUser(userID string) (int64, error) {
 		return 0, nil
 	}
 
-	reminderAt, err := strconv.ParseInt(string(timeBytes), 10, 64)
+	snoozedUntil, err := strconv.ParseInt(string(timeBytes), 10, 64)
 	if err != nil {
 		return 0, err
 	}
 
-	return reminderAt, nil
+	return snoozedUntil, nil
 }
 
 func (p *Plugin) saveReminderPreference(userID string, preference bool) error {
@@ -410,6 +657,9 @@ func (p *Plugin) getReminderPreference(userID string) bool {
 	}
 
 	if preferenceByte == nil {
+		if preference, ok := p.getTeamDefault(userID, func(defaults *teamDefaults) *bool { return defaults.Reminder }); ok {
+			return preference
+		}
 		p.API.LogInfo(`reminder preference is empty. Defaulting to "on"`)
 		return true
 	}
@@ -423,6 +673,147 @@ func (p *Plugin) getReminderPreference(userID string) bool {
 	return preference
 }
 
+func (p *Plugin) saveReminderDescriptionsPreference(userID string, preference bool) error {
+	preferenceString := strconv.FormatBool(preference)
+	appErr := p.API.KVSet(reminderDescriptionsKey(userID), []byte(preferenceString))
+	if appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// getReminderDescriptionsPreference - gets user preference on including descriptions in the daily reminder - default value will be false if in case any error
+func (p *Plugin) getReminderDescriptionsPreference(userID string) bool {
+	preferenceByte, appErr := p.API.KVGet(reminderDescriptionsKey(userID))
+	if appErr != nil {
+		p.API.LogError("error getting the reminder descriptions preference, err=", appErr.Error())
+		return false
+	}
+
+	if preferenceByte == nil {
+		return false
+	}
+
+	preference, err := strconv.ParseBool(string(preferenceByte))
+	if err != nil {
+		p.API.LogError("unable to parse the reminder descriptions preference, err=", err.Error())
+		return false
+	}
+
+	return preference
+}
+
+// saveReminderHeaderPreference stores header as the text the daily reminders of userID start with, or goes back to
+// DefaultReminderHeader if header is empty.
+func (p *Plugin) saveReminderHeaderPreference(userID, header string) error {
+	if header == "" {
+		if appErr := p.API.KVDelete(reminderHeaderKey(userID)); appErr != nil {
+			return appErr
+		}
+		return nil
+	}
+
+	appErr := p.API.KVSet(reminderHeaderKey(userID), []byte(header))
+	if appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// getReminderHeaderPreference - gets the text the daily reminders of the user start with - default value will be DefaultReminderHeader if in case any error
+func (p *Plugin) getReminderHeaderPreference(userID string) string {
+	header, appErr := p.API.KVGet(reminderHeaderKey(userID))
+	if appErr != nil {
+		p.API.LogError("error getting the reminder header preference, err=", appErr.Error())
+		return DefaultReminderHeader
+	}
+
+	if len(header) == 0 {
+		return DefaultReminderHeader
+	}
+
+	return string(header)
+}
+
+func (p *Plugin) saveDefaultPriorityPreference(userID string, priority int) error {
+	appErr := p.API.KVSet(defaultPriorityKey(userID), []byte(priorityName(priority)))
+	if appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// getDefaultPriorityPreference - gets the priority of the todos userID adds without choosing one - default value will be PriorityNormal if in case any error
+func getDefaultPriorityPreference(api plugin.API, userID string) int {
+	name, appErr := api.KVGet(defaultPriorityKey(userID))
+	if appErr != nil {
+		api.LogError("error getting the default priority preference, err=", appErr.Error())
+		return PriorityNormal
+	}
+
+	if name == nil {
+		return PriorityNormal
+	}
+
+	priority, err := parsePriority(string(name))
+	if err != nil {
+		api.LogError("unable to parse the default priority preference, err=", err.Error())
+		return PriorityNormal
+	}
+	return priority
+}
+
+// defaultPriorityEmoji are the emoji shown before the todos of each priority, by priority name, for the users who did
+// not choose their own
+var defaultPriorityEmoji = map[string]string{"high": ":exclamation:", "normal": "", "low": ""}
+
+// savePriorityEmojiPreference saves the emoji userID chose for the priorities in emoji, by priority name. The other
+// priorities show their default emoji.
+func (p *Plugin) savePriorityEmojiPreference(userID string, emoji map[string]string) error {
+	emojiJSON, err := json.Marshal(emoji)
+	if err != nil {
+		return err
+	}
+
+	appErr := p.API.KVSet(priorityEmojiKey(userID), emojiJSON)
+	if appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// getChosenPriorityEmoji gets the emoji userID chose for the priorities, by priority name, without the defaults
+func getChosenPriorityEmoji(api plugin.API, userID string) map[string]string {
+	emoji := map[string]string{}
+	emojiJSON, appErr := api.KVGet(priorityEmojiKey(userID))
+	if appErr != nil {
+		api.LogError("error getting the priority emoji preference, err=", appErr.Error())
+		return emoji
+	}
+
+	if emojiJSON == nil {
+		return emoji
+	}
+
+	if err := json.Unmarshal(emojiJSON, &emoji); err != nil {
+		api.LogError("unable to parse the priority emoji preference, err=", err.Error())
+		return map[string]string{}
+	}
+	return emoji
+}
+
+// getPriorityEmojiPreference - gets the emoji shown before the todos of each priority of userID, by priority name - default values will be defaultPriorityEmoji if in case any error
+func getPriorityEmojiPreference(api plugin.API, userID string) map[string]string {
+	emoji := map[string]string{}
+	for name, value := range defaultPriorityEmoji {
+		emoji[name] = value
+	}
+	for name, value := range getChosenPriorityEmoji(api, userID) {
+		emoji[name] = value
+	}
+	return emoji
+}
+
 func (p *Plugin) saveAllowIncomingTaskRequestsPreference(userID string, preference bool) error {
 	preferenceString := strconv.FormatBool(preference)
 	appErr := p.API.KVSet(allowIncomingTaskRequestsKey(userID), []byte(preferenceString))
@@ -441,6 +832,9 @@ func (p *Plugin) getAllowIncomingTaskRequestsPreference(userID string) (bool, er
 	}
 
 	if preferenceByte == nil {
+		if preference, ok := p.getTeamDefault(userID, func(defaults *teamDefaults) *bool { return defaults.AllowIncomingTaskRequests }); ok {
+			return preference, nil
+		}
 		p.API.LogDebug(`allow incoming task requests is empty. Defaulting to "on"`)
 		return true, nil
 	}
@@ -453,3 +847,779 @@ func (p *Plugin) getAllowIncomingTaskRequestsPreference(userID string) (bool, er
 
 	return preference, nil
 }
+
+func (p *Plugin) saveReceivedOnTopPreference(userID string, preference bool) error {
+	preferenceString := strconv.FormatBool(preference)
+	appErr := p.API.KVSet(receivedOnTopKey(userID), []byte(preferenceString))
+	if appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// getReceivedOnTopPreference - gets user preference on placing new received todos at the top of the inbox - default value will be false (bottom) if in case any error.
+// It takes the API instead of being a Plugin method since the list manager consults it when sending todos.
+func getReceivedOnTopPreference(api plugin.API, userID string) bool {
+	preferenceByte, appErr := api.KVGet(receivedOnTopKey(userID))
+	if appErr != nil {
+		api.LogError("error getting the received on top preference, err=", appErr.Error())
+		return false
+	}
+
+	if preferenceByte == nil {
+		return false
+	}
+
+	preference, err := strconv.ParseBool(string(preferenceByte))
+	if err != nil {
+		api.LogError("unable to parse the received on top preference, err=", err.Error())
+		return false
+	}
+
+	return preference
+}
+
+func (p *Plugin) saveKeepCompletedSentPreference(userID string, preference bool) error {
+	preferenceString := strconv.FormatBool(preference)
+	appErr := p.API.KVSet(keepCompletedSentKey(userID), []byte(preferenceString))
+	if appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// getKeepCompletedSentPreference - gets user preference on keeping the sent todos completed by their receivers on the outgoing list - default value will be false if in case any error
+func getKeepCompletedSentPreference(api plugin.API, userID string) bool {
+	preferenceByte, appErr := api.KVGet(keepCompletedSentKey(userID))
+	if appErr != nil {
+		api.LogError("error getting the keep completed sent preference, err=", appErr.Error())
+		return false
+	}
+
+	if preferenceByte == nil {
+		return false
+	}
+
+	preference, err := strconv.ParseBool(string(preferenceByte))
+	if err != nil {
+		api.LogError("unable to parse the keep completed sent preference, err=", err.Error())
+		return false
+	}
+
+	return preference
+}
+
+func (p *Plugin) saveSelfSendToInboxPreference(userID string, preference bool) error {
+	preferenceString := strconv.FormatBool(preference)
+	appErr := p.API.KVSet(selfSendToInboxKey(userID), []byte(preferenceString))
+	if appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// getSelfSendToInboxPreference - gets user preference on receiving the todos they send to themselves on their incoming list - default value will be false if in case any error
+func (p *Plugin) getSelfSendToInboxPreference(userID string) bool {
+	preferenceByte, appErr := p.API.KVGet(selfSendToInboxKey(userID))
+	if appErr != nil {
+		p.API.LogError("error getting the self send to inbox preference, err=", appErr.Error())
+		return false
+	}
+
+	if preferenceByte == nil {
+		return false
+	}
+
+	preference, err := strconv.ParseBool(string(preferenceByte))
+	if err != nil {
+		p.API.LogError("unable to parse the self send to inbox preference, err=", err.Error())
+		return false
+	}
+
+	return preference
+}
+
+func (p *Plugin) savePopUrgentPreference(userID string, preference bool) error {
+	preferenceString := strconv.FormatBool(preference)
+	appErr := p.API.KVSet(popUrgentKey(userID), []byte(preferenceString))
+	if appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// getPopUrgentPreference - gets user preference on popping their most urgent todo instead of the top one - default value will be false if in case any error
+func (p *Plugin) getPopUrgentPreference(userID string) bool {
+	preferenceByte, appErr := p.API.KVGet(popUrgentKey(userID))
+	if appErr != nil {
+		p.API.LogError("error getting the pop urgent preference, err=", appErr.Error())
+		return false
+	}
+
+	if preferenceByte == nil {
+		return false
+	}
+
+	preference, err := strconv.ParseBool(string(preferenceByte))
+	if err != nil {
+		p.API.LogError("unable to parse the pop urgent preference, err=", err.Error())
+		return false
+	}
+
+	return preference
+}
+
+func (p *Plugin) saveShareCountsPreference(userID string, preference bool) error {
+	preferenceString := strconv.FormatBool(preference)
+	appErr := p.API.KVSet(shareCountsKey(userID), []byte(preferenceString))
+	if appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// getShareCountsPreference - gets user preference on sharing how many todos they have with their channels - default value will be false if in case any error
+func (p *Plugin) getShareCountsPreference(userID string) bool {
+	preferenceByte, appErr := p.API.KVGet(shareCountsKey(userID))
+	if appErr != nil {
+		p.API.LogError("error getting the share counts preference, err=", appErr.Error())
+		return false
+	}
+
+	if preferenceByte == nil {
+		return false
+	}
+
+	preference, err := strconv.ParseBool(string(preferenceByte))
+	if err != nil {
+		p.API.LogError("unable to parse the share counts preference, err=", err.Error())
+		return false
+	}
+
+	return preference
+}
+
+// saveNotificationChannelPreference stores channelID as the channel userID receives their notifications in,
+// or goes back to the bot DM if channelID is empty.
+func (p *Plugin) saveNotificationChannelPreference(userID, channelID string) error {
+	if channelID == "" {
+		if appErr := p.API.KVDelete(notificationChannelKey(userID)); appErr != nil {
+			return appErr
+		}
+		return nil
+	}
+
+	appErr := p.API.KVSet(notificationChannelKey(userID), []byte(channelID))
+	if appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// getNotificationChannelPreference - gets the channel the user chose to receive their notifications in - default value will be empty, for the bot DM, if in case any error
+func (p *Plugin) getNotificationChannelPreference(userID string) string {
+	channelID, appErr := p.API.KVGet(notificationChannelKey(userID))
+	if appErr != nil {
+		p.API.LogError("error getting the notification channel preference, err=", appErr.Error())
+		return ""
+	}
+	return string(channelID)
+}
+
+// userPreferenceKeys returns the keys of all the preferences userID can set
+func userPreferenceKeys(userID string) []string {
+	return []string{
+		reminderEnabledKey(userID),
+		reminderSnoozedUntilKey(userID),
+		reminderDescriptionsKey(userID),
+		reminderHeaderKey(userID),
+		allowIncomingTaskRequestsKey(userID),
+		receivedOnTopKey(userID),
+		keepCompletedSentKey(userID),
+		selfSendToInboxKey(userID),
+		popUrgentKey(userID),
+		shareCountsKey(userID),
+		notificationChannelKey(userID),
+		defaultPriorityKey(userID),
+		priorityEmojiKey(userID),
+	}
+}
+
+// resetPreferences deletes all the preferences of userID, so they go back to their defaults
+func (p *Plugin) resetPreferences(userID string) error {
+	for _, key := range userPreferenceKeys(userID) {
+		if appErr := p.API.KVDelete(key); appErr != nil {
+			return appErr
+		}
+	}
+	return p.setTriageReminder(userID, 0, 0)
+}
+
+// teamDefaults holds the preferences a team admin set for the members of the team that did not choose their own.
+// Unset preferences are nil.
+type teamDefaults struct {
+	Reminder                  *bool `json:"reminder,omitempty"`
+	AllowIncomingTaskRequests *bool `json:"allow_incoming_task_requests,omitempty"`
+}
+
+func (p *Plugin) saveTeamDefaults(teamID string, defaults *teamDefaults) error {
+	defaultsJSON, err := json.Marshal(defaults)
+	if err != nil {
+		return err
+	}
+
+	appErr := p.API.KVSet(teamDefaultsKey(teamID), defaultsJSON)
+	if appErr != nil {
+		return appErr
+	}
+	return p.setTeamHasDefaults(teamID, defaults.Reminder != nil || defaults.AllowIncomingTaskRequests != nil)
+}
+
+// getTeamsWithDefaults returns the IDs of the teams that set default preferences, and the stored value they were read from.
+func (p *Plugin) getTeamsWithDefaults() ([]string, []byte, error) {
+	originalJSONTeams, appErr := p.API.KVGet(StoreTeamsWithDefaultsKey)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+
+	if originalJSONTeams == nil {
+		return []string{}, originalJSONTeams, nil
+	}
+
+	var teamIDs []string
+	if err := json.Unmarshal(originalJSONTeams, &teamIDs); err != nil {
+		return nil, nil, err
+	}
+
+	return teamIDs, originalJSONTeams, nil
+}
+
+// setTeamHasDefaults adds teamID to the teams that set default preferences, or removes it from them, retrying if
+// they change in the meantime.
+func (p *Plugin) setTeamHasDefaults(teamID string, hasDefaults bool) error {
+	for i := 0; i < StoreRetries; i++ {
+		teamIDs, originalJSONTeams, err := p.getTeamsWithDefaults()
+		if err != nil {
+			return err
+		}
+
+		newTeamIDs := []string{}
+		found := false
+		for _, id := range teamIDs {
+			if id == teamID {
+				found = true
+				if !hasDefaults {
+					continue
+				}
+			}
+			newTeamIDs = append(newTeamIDs, id)
+		}
+		if found == hasDefaults {
+			return nil
+		}
+		if hasDefaults {
+			newTeamIDs = append(newTeamIDs, teamID)
+		}
+
+		newJSONTeams, err := json.Marshal(newTeamIDs)
+		if err != nil {
+			return err
+		}
+
+		ok, appErr := p.API.KVCompareAndSet(StoreTeamsWithDefaultsKey, originalJSONTeams, newJSONTeams)
+		if appErr != nil {
+			return appErr
+		}
+
+		if ok {
+			return nil
+		}
+	}
+
+	return errors.New("unable to store the teams with defaults")
+}
+
+func (p *Plugin) getTeamDefaults(teamID string) (*teamDefaults, error) {
+	defaultsJSON, appErr := p.API.KVGet(teamDefaultsKey(teamID))
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	defaults := &teamDefaults{}
+	if defaultsJSON == nil {
+		return defaults, nil
+	}
+
+	if err := json.Unmarshal(defaultsJSON, defaults); err != nil {
+		return nil, err
+	}
+	return defaults, nil
+}
+
+// getTeamDefault returns the default preference picked from the defaults of the teams of userID,
+// using the first team that sets it. ok is false if none do.
+func (p *Plugin) getTeamDefault(userID string, pick func(defaults *teamDefaults) *bool) (preference bool, ok bool) {
+	teamIDs, _, err := p.getTeamsWithDefaults()
+	if err != nil {
+		p.API.LogError("error getting the teams with defaults, err=", err.Error())
+		return false, false
+	}
+
+	// The teams of the user are only needed when some team set defaults
+	if len(teamIDs) == 0 {
+		return false, false
+	}
+
+	hasDefaults := map[string]bool{}
+	for _, id := range teamIDs {
+		hasDefaults[id] = true
+	}
+
+	teams, appErr := p.API.GetTeamsForUser(userID)
+	if appErr != nil {
+		p.API.LogError("error getting the teams of the user, err=", appErr.Error())
+		return false, false
+	}
+
+	for _, team := range teams {
+		if !hasDefaults[team.Id] {
+			continue
+		}
+
+		defaults, err := p.getTeamDefaults(team.Id)
+		if err != nil {
+			p.API.LogError("error getting the team defaults, err=", err.Error())
+			continue
+		}
+		if value := pick(defaults); value != nil {
+			return *value, true
+		}
+	}
+
+	return false, false
+}
+
+// listAllKeys returns all the keys of the KV store of the plugin.
+func listAllKeys(api plugin.API) ([]string, error) {
+	keys := []string{}
+	for page := 0; ; page++ {
+		pageKeys, appErr := api.KVList(page, StoreKeysPerPage)
+		if appErr != nil {
+			return nil, appErr
+		}
+		keys = append(keys, pageKeys...)
+		if len(pageKeys) < StoreKeysPerPage {
+			return keys, nil
+		}
+	}
+}
+
+// markInstallAnnounced records that the plugin was announced, and returns false if it already was.
+func (p *Plugin) markInstallAnnounced() (bool, error) {
+	ok, appErr := p.API.KVCompareAndSet(StoreInstallAnnouncedKey, nil, []byte("true"))
+	if appErr != nil {
+		return false, appErr
+	}
+	return ok, nil
+}
+
+// getShareSecret returns the secret used to sign shared issues, creating it the first time.
+func (p *Plugin) getShareSecret() ([]byte, error) {
+	secret, appErr := p.API.KVGet(StoreShareSecretKey)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if secret != nil {
+		return secret, nil
+	}
+
+	newSecret := []byte(model.NewRandomString(32))
+	ok, appErr := p.API.KVCompareAndSet(StoreShareSecretKey, nil, newSecret)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if ok {
+		return newSecret, nil
+	}
+
+	// Someone else created the secret in the meantime
+	secret, appErr = p.API.KVGet(StoreShareSecretKey)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if secret == nil {
+		return nil, errors.New("unable to store the share secret")
+	}
+	return secret, nil
+}
+
+// nagRef points to a todo that nags its owner
+type nagRef struct {
+	UserID  string `json:"user_id"`
+	IssueID string `json:"issue_id"`
+}
+
+func (p *Plugin) getNags() ([]*nagRef, []byte, error) {
+	originalJSONNags, appErr := p.API.KVGet(StoreNagsKey)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+
+	if originalJSONNags == nil {
+		return []*nagRef{}, originalJSONNags, nil
+	}
+
+	var nags []*nagRef
+	if err := json.Unmarshal(originalJSONNags, &nags); err != nil {
+		return nil, nil, err
+	}
+
+	return nags, originalJSONNags, nil
+}
+
+// updateNags applies update to the stored nags, retrying if they change in the meantime
+func (p *Plugin) updateNags(update func(nags []*nagRef) []*nagRef) error {
+	for i := 0; i < StoreRetries; i++ {
+		nags, originalJSONNags, err := p.getNags()
+		if err != nil {
+			return err
+		}
+
+		newJSONNags, err := json.Marshal(update(nags))
+		if err != nil {
+			return err
+		}
+
+		ok, appErr := p.API.KVCompareAndSet(StoreNagsKey, originalJSONNags, newJSONNags)
+		if appErr != nil {
+			return appErr
+		}
+
+		if ok {
+			return nil
+		}
+	}
+
+	return errors.New("unable to store nags")
+}
+
+func (p *Plugin) addNag(userID, issueID string) error {
+	return p.updateNags(func(nags []*nagRef) []*nagRef {
+		for _, nag := range nags {
+			if nag.UserID == userID && nag.IssueID == issueID {
+				return nags
+			}
+		}
+		return append(nags, &nagRef{UserID: userID, IssueID: issueID})
+	})
+}
+
+func (p *Plugin) removeNags(stopped []*nagRef) error {
+	return p.updateNags(func(nags []*nagRef) []*nagRef {
+		kept := []*nagRef{}
+		for _, nag := range nags {
+			isStopped := false
+			for _, s := range stopped {
+				if nag.UserID == s.UserID && nag.IssueID == s.IssueID {
+					isStopped = true
+					break
+				}
+			}
+			if !isStopped {
+				kept = append(kept, nag)
+			}
+		}
+		return kept
+	})
+}
+
+// triageReminder is a user reminded every Interval milliseconds about the todos waiting on their incoming list
+type triageReminder struct {
+	UserID     string `json:"user_id"`
+	Interval   int64  `json:"interval"`
+	LastSentAt int64  `json:"last_sent_at"`
+}
+
+func (p *Plugin) getTriageReminders() ([]*triageReminder, []byte, error) {
+	originalJSONReminders, appErr := p.API.KVGet(StoreTriageRemindersKey)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+
+	if originalJSONReminders == nil {
+		return []*triageReminder{}, originalJSONReminders, nil
+	}
+
+	var reminders []*triageReminder
+	if err := json.Unmarshal(originalJSONReminders, &reminders); err != nil {
+		return nil, nil, err
+	}
+
+	return reminders, originalJSONReminders, nil
+}
+
+// updateTriageReminders applies update to the stored triage reminders, retrying if they change in the meantime
+func (p *Plugin) updateTriageReminders(update func(reminders []*triageReminder) []*triageReminder) error {
+	for i := 0; i < StoreRetries; i++ {
+		reminders, originalJSONReminders, err := p.getTriageReminders()
+		if err != nil {
+			return err
+		}
+
+		newJSONReminders, err := json.Marshal(update(reminders))
+		if err != nil {
+			return err
+		}
+
+		ok, appErr := p.API.KVCompareAndSet(StoreTriageRemindersKey, originalJSONReminders, newJSONReminders)
+		if appErr != nil {
+			return appErr
+		}
+
+		if ok {
+			return nil
+		}
+	}
+
+	return errors.New("unable to store triage reminders")
+}
+
+// setTriageReminder reminds userID every interval milliseconds from now about their incoming list, or stops
+// reminding them if interval is 0.
+func (p *Plugin) setTriageReminder(userID string, interval, now int64) error {
+	return p.updateTriageReminders(func(reminders []*triageReminder) []*triageReminder {
+		kept := []*triageReminder{}
+		for _, reminder := range reminders {
+			if reminder.UserID != userID {
+				kept = append(kept, reminder)
+			}
+		}
+		if interval > 0 {
+			kept = append(kept, &triageReminder{UserID: userID, Interval: interval, LastSentAt: now})
+		}
+		return kept
+	})
+}
+
+// getTriageReminderInterval returns how often userID is reminded about their incoming list, or 0 if they are not.
+func (p *Plugin) getTriageReminderInterval(userID string) int64 {
+	reminders, _, err := p.getTriageReminders()
+	if err != nil {
+		p.API.LogError("error getting the triage reminders, err=", err.Error())
+		return 0
+	}
+
+	for _, reminder := range reminders {
+		if reminder.UserID == userID {
+			return reminder.Interval
+		}
+	}
+	return 0
+}
+
+// getReminderUsers returns the IDs of the users the reminder job sends the daily reminder to, and the stored value
+// they were read from.
+func getReminderUsers(api plugin.API) ([]string, []byte, error) {
+	originalJSONUsers, appErr := api.KVGet(StoreReminderUsersKey)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+
+	if originalJSONUsers == nil {
+		return []string{}, originalJSONUsers, nil
+	}
+
+	var userIDs []string
+	if err := json.Unmarshal(originalJSONUsers, &userIDs); err != nil {
+		return nil, nil, err
+	}
+
+	return userIDs, originalJSONUsers, nil
+}
+
+// updateReminderUsers stores the users update returns from the stored ones, retrying if they change in the meantime.
+// Nothing is stored if update reports no change.
+func updateReminderUsers(api plugin.API, update func(userIDs []string) ([]string, bool)) error {
+	for i := 0; i < StoreRetries; i++ {
+		userIDs, originalJSONUsers, err := getReminderUsers(api)
+		if err != nil {
+			return err
+		}
+
+		newUserIDs, changed := update(userIDs)
+		if !changed {
+			return nil
+		}
+
+		newJSONUsers, err := json.Marshal(newUserIDs)
+		if err != nil {
+			return err
+		}
+
+		ok, appErr := api.KVCompareAndSet(StoreReminderUsersKey, originalJSONUsers, newJSONUsers)
+		if appErr != nil {
+			return appErr
+		}
+
+		if ok {
+			return nil
+		}
+	}
+
+	return errors.New("unable to store reminder users")
+}
+
+// registerReminderUser lets the reminder job send the daily reminders of userID.
+func registerReminderUser(api plugin.API, userID string) error {
+	return registerReminderUsers(api, []string{userID})
+}
+
+// registerReminderUsers lets the reminder job send the daily reminders of added.
+func registerReminderUsers(api plugin.API, added []string) error {
+	return updateReminderUsers(api, func(userIDs []string) ([]string, bool) {
+		isRegistered := map[string]bool{}
+		for _, id := range userIDs {
+			isRegistered[id] = true
+		}
+
+		changed := false
+		for _, id := range added {
+			if !isRegistered[id] {
+				isRegistered[id] = true
+				userIDs = append(userIDs, id)
+				changed = true
+			}
+		}
+		return userIDs, changed
+	})
+}
+
+// unregisterReminderUsers stops the reminder job from sending the daily reminders of removed.
+func unregisterReminderUsers(api plugin.API, removed []string) error {
+	if len(removed) == 0 {
+		return nil
+	}
+
+	isRemoved := map[string]bool{}
+	for _, id := range removed {
+		isRemoved[id] = true
+	}
+
+	return updateReminderUsers(api, func(userIDs []string) ([]string, bool) {
+		kept := []string{}
+		for _, id := range userIDs {
+			if !isRemoved[id] {
+				kept = append(kept, id)
+			}
+		}
+		return kept, len(kept) != len(userIDs)
+	})
+}
+
+// claimDailyReminder marks the daily reminder of userID as sent at now, unless it was sent since lastReminderAt.
+// It returns false if the reminder job or a list fetch sent it in the meantime.
+func (p *Plugin) claimDailyReminder(userID string, lastReminderAt, now int64) (bool, error) {
+	var oldValue []byte
+	if lastReminderAt != 0 {
+		oldValue = []byte(strconv.FormatInt(lastReminderAt, 10))
+	}
+
+	ok, appErr := p.API.KVCompareAndSet(reminderKey(userID), oldValue, []byte(strconv.FormatInt(now, 10)))
+	if appErr != nil {
+		return false, errors.New(appErr.Error())
+	}
+	return ok, nil
+}
+
+// focusState is stored while a user is in focus mode, with the notifications held back until it ends
+type focusState struct {
+	// Queued holds the first MaxQueuedFocusNotifications notifications, and Count all of them
+	Queued []string `json:"queued"`
+	Count  int      `json:"count"`
+}
+
+// getFocus gets the focus state of userID, or nil if they are not in focus mode
+func (p *Plugin) getFocus(userID string) (*focusState, []byte, error) {
+	originalJSONFocus, appErr := p.API.KVGet(focusKey(userID))
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+
+	if originalJSONFocus == nil {
+		return nil, nil, nil
+	}
+
+	var focus focusState
+	if err := json.Unmarshal(originalJSONFocus, &focus); err != nil {
+		return nil, nil, err
+	}
+
+	return &focus, originalJSONFocus, nil
+}
+
+// startFocus puts userID in focus mode, returning false if they already were
+func (p *Plugin) startFocus(userID string) (bool, error) {
+	jsonFocus, err := json.Marshal(&focusState{Queued: []string{}})
+	if err != nil {
+		return false, err
+	}
+
+	started, appErr := p.API.KVCompareAndSet(focusKey(userID), nil, jsonFocus)
+	if appErr != nil {
+		return false, appErr
+	}
+
+	return started, nil
+}
+
+// queueFocusNotification holds message back until userID ends focus mode, returning false if they are not in it
+func (p *Plugin) queueFocusNotification(userID, message string) (bool, error) {
+	for i := 0; i < StoreRetries; i++ {
+		focus, originalJSONFocus, err := p.getFocus(userID)
+		if err != nil || focus == nil {
+			return false, err
+		}
+
+		if len(focus.Queued) < MaxQueuedFocusNotifications {
+			focus.Queued = append(focus.Queued, message)
+		}
+		focus.Count++
+
+		newJSONFocus, err := json.Marshal(focus)
+		if err != nil {
+			return false, err
+		}
+
+		ok, appErr := p.API.KVCompareAndSet(focusKey(userID), originalJSONFocus, newJSONFocus)
+		if appErr != nil {
+			return false, appErr
+		}
+
+		if ok {
+			return true, nil
+		}
+	}
+
+	return false, errors.New("unable to store focus notification")
+}
+
+// endFocus takes userID out of focus mode, returning the notifications held back, or nil if they were not in it
+func (p *Plugin) endFocus(userID string) (*focusState, error) {
+	for i := 0; i < StoreRetries; i++ {
+		focus, originalJSONFocus, err := p.getFocus(userID)
+		if err != nil || focus == nil {
+			return nil, err
+		}
+
+		ok, appErr := p.API.KVCompareAndDelete(focusKey(userID), originalJSONFocus)
+		if appErr != nil {
+			return nil, appErr
+		}
+
+		if ok {
+			return focus, nil
+		}
+	}
+
+	return nil, errors.New("unable to end focus mode")
+}